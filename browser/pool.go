@@ -0,0 +1,462 @@
+// Package browser runs the Playwright browsers shared by the browser
+// handler and the enrichment worker: one Playwright driver, a persistent
+// profile per site that one user leases at a time, a cap on open pages and
+// contexts relaunched after a number of pages or a block. Each profile
+// launches with a fingerprint profile, which a block rotates.
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"sync"
+
+	"github.com/playwright-community/playwright-go"
+	"tct_scrooper/config"
+	"tct_scrooper/httputil"
+)
+
+// ErrProfileBusy is returned by TryAcquire when another user holds the profile
+var ErrProfileBusy = errors.New("browser profile in use")
+
+var launchArgs = []string{
+	"--disable-blink-features=AutomationControlled",
+	"--disable-dev-shm-usage",
+	"--no-sandbox",
+	"--disable-gpu",
+}
+
+// Pool hands out leases on per-site browser profiles. Profiles keep their
+// context (and cookies) between leases; Close shuts everything down.
+type Pool struct {
+	cfg          config.BrowserConfig
+	fingerprints *httputil.Fingerprints
+	slots        chan struct{} // one per open page
+
+	mu       sync.Mutex
+	pw       *playwright.Playwright
+	profiles map[string]*profile
+	closed   bool
+}
+
+// profile is a persistent browser profile and its running context, if any
+type profile struct {
+	name  string
+	dir   string
+	token chan struct{} // holds one token while leased
+
+	mu          sync.Mutex
+	holder      string
+	context     playwright.BrowserContext
+	opts        Options // the running context was launched with
+	fingerprint string  // the running context was launched as
+	pages       int     // pages opened since launch
+}
+
+// session is the profile's fingerprint session
+func (prof *profile) session() string {
+	return "browser:" + prof.name
+}
+
+func NewPool(cfg config.BrowserConfig, fingerprints *httputil.Fingerprints) *Pool {
+	maxPages := cfg.MaxPages
+	if maxPages <= 0 {
+		maxPages = 1
+	}
+	return &Pool{
+		cfg:          cfg,
+		fingerprints: fingerprints,
+		slots:        make(chan struct{}, maxPages),
+		profiles:     make(map[string]*profile),
+	}
+}
+
+func (p *Pool) profile(name string) *profile {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	prof, ok := p.profiles[name]
+	if !ok {
+		prof = &profile{
+			name:  name,
+			dir:   filepath.Join(p.cfg.ProfileDir, name),
+			token: make(chan struct{}, 1),
+		}
+		p.profiles[name] = prof
+	}
+	return prof
+}
+
+// Acquire leases the named profile for holder (logged when others wait),
+// waiting while someone else holds it
+func (p *Pool) Acquire(ctx context.Context, name, holder string) (*Lease, error) {
+	prof := p.profile(name)
+	select {
+	case prof.token <- struct{}{}:
+	default:
+		prof.mu.Lock()
+		current := prof.holder
+		prof.mu.Unlock()
+		log.Printf("Browser: %s waiting for profile %s (in use by %s)", holder, name, current)
+		select {
+		case prof.token <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return p.lease(prof, holder), nil
+}
+
+// TryAcquire leases the named profile if it is free, or returns ErrProfileBusy
+func (p *Pool) TryAcquire(name, holder string) (*Lease, error) {
+	prof := p.profile(name)
+	select {
+	case prof.token <- struct{}{}:
+		return p.lease(prof, holder), nil
+	default:
+		return nil, ErrProfileBusy
+	}
+}
+
+func (p *Pool) lease(prof *profile, holder string) *Lease {
+	prof.mu.Lock()
+	prof.holder = holder
+	prof.mu.Unlock()
+	return &Lease{pool: p, prof: prof, open: make(map[playwright.Page]bool)}
+}
+
+// playwright starts the driver on first use
+func (p *Pool) playwright() (*playwright.Playwright, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, errors.New("browser pool closed")
+	}
+	if p.pw == nil {
+		pw, err := playwright.Run()
+		if err != nil {
+			return nil, fmt.Errorf("failed to start playwright: %w", err)
+		}
+		p.pw = pw
+	}
+	return p.pw, nil
+}
+
+// Close closes every profile's context and stops the driver
+func (p *Pool) Close() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.closed = true
+	profiles := make([]*profile, 0, len(p.profiles))
+	for _, prof := range p.profiles {
+		profiles = append(profiles, prof)
+	}
+	pw := p.pw
+	p.pw = nil
+	p.mu.Unlock()
+
+	for _, prof := range profiles {
+		prof.mu.Lock()
+		prof.closeContext()
+		prof.mu.Unlock()
+	}
+	if pw != nil {
+		pw.Stop()
+	}
+}
+
+// launch starts the profile's context with opts; callers hold prof.mu
+func (p *Pool) launch(prof *profile, opts Options) error {
+	pw, err := p.playwright()
+	if err != nil {
+		return err
+	}
+	launchOpts := playwright.BrowserTypeLaunchPersistentContextOptions{
+		Headless: playwright.Bool(p.cfg.Headless),
+		Args:     launchArgs,
+		Proxy:    opts.Proxy,
+	}
+	fp, ok := p.fingerprints.For(prof.session())
+	if ok {
+		applyFingerprint(&launchOpts, fp)
+	}
+	bc, err := pw.Chromium.LaunchPersistentContext(prof.dir, launchOpts)
+	if err != nil {
+		return fmt.Errorf("failed to launch browser: %w", err)
+	}
+	if fp.Platform != "" {
+		if err := bc.AddInitScript(playwright.Script{Content: playwright.String(platformScript(fp.Platform))}); err != nil {
+			bc.Close()
+			return fmt.Errorf("failed to set platform: %w", err)
+		}
+	}
+	prof.context = bc
+	prof.opts = opts
+	prof.fingerprint = fp.Name
+	prof.pages = 0
+	log.Printf("Browser: launched profile %s for %s as %s (headless: %v)", prof.name, prof.holder, fingerprintName(fp.Name), p.cfg.Headless)
+	return nil
+}
+
+// applyFingerprint sets the launch options a fingerprint describes
+func applyFingerprint(opts *playwright.BrowserTypeLaunchPersistentContextOptions, fp config.Fingerprint) {
+	if fp.UserAgent != "" {
+		opts.UserAgent = playwright.String(fp.UserAgent)
+	}
+	if fp.Locale != "" {
+		opts.Locale = playwright.String(fp.Locale)
+	}
+	if fp.Timezone != "" {
+		opts.TimezoneId = playwright.String(fp.Timezone)
+	}
+	if fp.Viewport.Width > 0 && fp.Viewport.Height > 0 {
+		opts.Viewport = &playwright.Size{Width: fp.Viewport.Width, Height: fp.Viewport.Height}
+	}
+	// Chromium sends its own Sec-CH-UA headers; these match them to the user
+	// agent. User-Agent is set above, and UserAgent takes precedence.
+	headers := make(http.Header)
+	httputil.ApplyFingerprint(headers, config.Fingerprint{
+		AcceptLanguage: fp.AcceptLanguage,
+		ClientHints:    fp.ClientHints,
+	})
+	if len(headers) > 0 {
+		opts.ExtraHttpHeaders = make(map[string]string, len(headers))
+		for key := range headers {
+			opts.ExtraHttpHeaders[key] = headers.Get(key)
+		}
+	}
+}
+
+// platformScript makes navigator.platform agree with the user agent
+func platformScript(platform string) string {
+	return fmt.Sprintf("Object.defineProperty(Navigator.prototype, 'platform', {get: () => %q});", platform)
+}
+
+func fingerprintName(name string) string {
+	if name == "" {
+		return "default fingerprint"
+	}
+	return name
+}
+
+// stale says why the running context should be relaunched before serving a
+// lease with opts, or "" if it shouldn't; callers hold prof.mu
+func (prof *profile) stale(opts Options, fingerprints *httputil.Fingerprints, recycleAfter int) string {
+	switch {
+	case prof.context == nil:
+		return ""
+	case !prof.opts.equal(opts):
+		return "new options"
+	case prof.fingerprint != prof.assigned(fingerprints):
+		return "new fingerprint"
+	case recycleAfter > 0 && prof.pages >= recycleAfter:
+		return fmt.Sprintf("after %d pages", prof.pages)
+	}
+	return ""
+}
+
+// assigned names the fingerprint the profile should run as
+func (prof *profile) assigned(fingerprints *httputil.Fingerprints) string {
+	fp, _ := fingerprints.For(prof.session())
+	return fp.Name
+}
+
+// closeContext closes the running context; callers hold prof.mu
+func (prof *profile) closeContext() {
+	if prof.context != nil {
+		prof.context.Close()
+		prof.context = nil
+	}
+	prof.pages = 0
+}
+
+// Options are what a lease needs of the profile's context. A context running
+// with other options is relaunched.
+type Options struct {
+	Proxy *playwright.Proxy
+}
+
+func (o Options) equal(other Options) bool {
+	return proxyKey(o.Proxy) == proxyKey(other.Proxy)
+}
+
+func proxyKey(p *playwright.Proxy) string {
+	if p == nil {
+		return ""
+	}
+	key := p.Server
+	if p.Username != nil {
+		key = *p.Username + "@" + key
+	}
+	return key
+}
+
+// Lease is exclusive use of one profile until Release
+type Lease struct {
+	pool *Pool
+	prof *profile
+	opts Options
+
+	mu       sync.Mutex
+	open     map[playwright.Page]bool // pages holding a slot
+	released bool
+}
+
+// Configure sets the options the lease's pages need
+func (l *Lease) Configure(opts Options) {
+	l.mu.Lock()
+	l.opts = opts
+	l.mu.Unlock()
+}
+
+// Fresh reports whether the next page starts a new session worth warming
+// up: the context isn't running, has served no pages yet or is due to be
+// relaunched
+func (l *Lease) Fresh() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prof.mu.Lock()
+	defer l.prof.mu.Unlock()
+	return l.prof.context == nil || l.prof.pages == 0 || l.prof.stale(l.opts, l.pool.fingerprints, l.pool.cfg.RecycleAfterPages) != ""
+}
+
+// NewPage opens a page on the profile, waiting for a free page slot. The
+// context is (re)launched first if it isn't running, was launched with other
+// options or has served RecycleAfterPages pages.
+func (l *Lease) NewPage(ctx context.Context) (playwright.Page, error) {
+	select {
+	case l.pool.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	page, err := l.newPage()
+	if err != nil {
+		<-l.pool.slots
+		return nil, err
+	}
+	return page, nil
+}
+
+func (l *Lease) newPage() (playwright.Page, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.released {
+		return nil, errors.New("browser lease released")
+	}
+
+	prof := l.prof
+	prof.mu.Lock()
+	defer prof.mu.Unlock()
+
+	if prof.context != nil && len(l.open) == 0 {
+		if reason := prof.stale(l.opts, l.pool.fingerprints, l.pool.cfg.RecycleAfterPages); reason != "" {
+			log.Printf("Browser: recycling profile %s (%s)", prof.name, reason)
+			prof.closeContext()
+		}
+	}
+	if prof.context == nil {
+		if err := l.pool.launch(prof, l.opts); err != nil {
+			return nil, err
+		}
+	}
+
+	page, err := prof.context.NewPage()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create page: %w", err)
+	}
+	prof.pages++
+	l.open[page] = true
+	return page, nil
+}
+
+// ClosePage closes a page from NewPage and frees its slot
+func (l *Lease) ClosePage(page playwright.Page) {
+	if page == nil {
+		return
+	}
+	page.Close()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.open[page] {
+		delete(l.open, page)
+		<-l.pool.slots
+	}
+}
+
+// Recycle closes the profile's context, e.g. after a block or to interrupt
+// the pages in progress; the next NewPage launches a new one. The lease's
+// open pages die with it.
+func (l *Lease) Recycle(reason string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prof.mu.Lock()
+	defer l.prof.mu.Unlock()
+
+	if l.prof.context != nil {
+		log.Printf("Browser: recycling profile %s (%s)", l.prof.name, reason)
+	}
+	l.prof.closeContext()
+	l.freeSlots()
+}
+
+// ReportBlock moves the profile to another fingerprint after a block and
+// recycles its context, so the next NewPage launches as the new fingerprint
+func (l *Lease) ReportBlock() {
+	if l == nil {
+		return
+	}
+	l.prof.mu.Lock()
+	name := l.prof.fingerprint
+	l.prof.mu.Unlock()
+	l.pool.fingerprints.Rotate(l.prof.session(), name)
+	l.Recycle("blocked")
+}
+
+// Fingerprint names the fingerprint the profile's context runs as, for logs
+func (l *Lease) Fingerprint() string {
+	if l == nil {
+		return ""
+	}
+	l.prof.mu.Lock()
+	defer l.prof.mu.Unlock()
+	return l.prof.fingerprint
+}
+
+// Release closes the lease's open pages and hands the profile back; its
+// context stays up for the next lease
+func (l *Lease) Release() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.released {
+		return
+	}
+	for page := range l.open {
+		page.Close()
+	}
+	l.freeSlots()
+	l.released = true
+
+	l.prof.mu.Lock()
+	l.prof.holder = ""
+	l.prof.mu.Unlock()
+	<-l.prof.token
+}
+
+// freeSlots frees the slots of the lease's open pages; callers hold l.mu
+func (l *Lease) freeSlots() {
+	for page := range l.open {
+		delete(l.open, page)
+		<-l.pool.slots
+	}
+}
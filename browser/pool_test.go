@@ -0,0 +1,261 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+	"tct_scrooper/config"
+	"tct_scrooper/httputil"
+)
+
+// fakeContext is a running browser context that opens fakePages
+type fakeContext struct {
+	playwright.BrowserContext
+	closed bool
+}
+
+func (c *fakeContext) NewPage() (playwright.Page, error) {
+	return &fakePage{}, nil
+}
+
+func (c *fakeContext) Close(...playwright.BrowserContextCloseOptions) error {
+	c.closed = true
+	return nil
+}
+
+type fakePage struct {
+	playwright.Page
+	closed bool
+}
+
+func (p *fakePage) Close(...playwright.PageCloseOptions) error {
+	p.closed = true
+	return nil
+}
+
+// running gives the named profile a running context, as launch would
+func running(pool *Pool, name string) *fakeContext {
+	bc := &fakeContext{}
+	prof := pool.profile(name)
+	prof.context = bc
+	prof.fingerprint = prof.assigned(pool.fingerprints)
+	return bc
+}
+
+func TestLeaseExclusive(t *testing.T) {
+	pool := NewPool(config.BrowserConfig{}, nil)
+
+	lease, err := pool.TryAcquire("realtor_ca", "scrape")
+	if err != nil {
+		t.Fatalf("acquire free profile: %v", err)
+	}
+	if _, err := pool.TryAcquire("realtor_ca", "enrich"); !errors.Is(err, ErrProfileBusy) {
+		t.Fatalf("expected ErrProfileBusy while leased, got %v", err)
+	}
+	if other, err := pool.TryAcquire("remax", "enrich"); err != nil {
+		t.Fatalf("expected other profiles free, got %v", err)
+	} else {
+		other.Release()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := pool.Acquire(ctx, "realtor_ca", "enrich"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected Acquire to wait until its deadline, got %v", err)
+	}
+
+	got := make(chan *Lease)
+	go func() {
+		next, _ := pool.Acquire(context.Background(), "realtor_ca", "enrich")
+		got <- next
+	}()
+	lease.Release()
+	lease.Release() // a second release is a no-op, not a second token
+	next := <-got
+	if next == nil || next.prof.holder != "enrich" {
+		t.Fatalf("expected the waiting holder to get the profile")
+	}
+	if _, err := pool.TryAcquire("realtor_ca", "scrape"); !errors.Is(err, ErrProfileBusy) {
+		t.Fatalf("expected the profile held by the waiter, got %v", err)
+	}
+}
+
+func TestLeasePageLimit(t *testing.T) {
+	pool := NewPool(config.BrowserConfig{MaxPages: 2}, nil)
+	running(pool, "a")
+	running(pool, "b")
+	a, _ := pool.TryAcquire("a", "scrape")
+	b, _ := pool.TryAcquire("b", "enrich")
+
+	first, err := a.NewPage(context.Background())
+	if err != nil {
+		t.Fatalf("first page: %v", err)
+	}
+	if _, err := b.NewPage(context.Background()); err != nil {
+		t.Fatalf("second page: %v", err)
+	}
+
+	// Pages are capped across profiles
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := b.NewPage(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the third page to wait for a slot, got %v", err)
+	}
+
+	a.ClosePage(first)
+	a.ClosePage(first) // closing twice frees one slot
+	if !first.(*fakePage).closed || len(pool.slots) != 1 {
+		t.Fatalf("expected the closed page's slot freed, %d in use", len(pool.slots))
+	}
+
+	// Releasing a lease closes its pages and frees their slots
+	page, _ := b.NewPage(context.Background())
+	b.Release()
+	if !page.(*fakePage).closed || len(pool.slots) != 0 {
+		t.Fatalf("expected release to close pages and free slots, %d in use", len(pool.slots))
+	}
+	if _, err := b.NewPage(context.Background()); err == nil || len(pool.slots) != 0 {
+		t.Fatalf("expected a released lease to refuse pages without holding a slot, got %v", err)
+	}
+}
+
+func TestLeaseReleasesSlotOnError(t *testing.T) {
+	// A closed pool can't launch, so NewPage fails after taking its slot
+	pool := NewPool(config.BrowserConfig{MaxPages: 1}, nil)
+	pool.Close()
+	lease, _ := pool.TryAcquire("a", "scrape")
+	for i := 0; i < 3; i++ {
+		if _, err := lease.NewPage(context.Background()); err == nil {
+			t.Fatalf("expected the launch to fail")
+		}
+	}
+	if len(pool.slots) != 0 {
+		t.Fatalf("expected failed pages to free their slot, %d in use", len(pool.slots))
+	}
+	lease.Release()
+	if _, err := pool.TryAcquire("a", "scrape"); err != nil {
+		t.Fatalf("expected the profile free after release: %v", err)
+	}
+}
+
+func TestLeaseRecycles(t *testing.T) {
+	server := "http://10.0.0.1:8080"
+
+	cases := []struct {
+		name    string
+		setup   func(*profile)
+		opts    Options
+		reason  string // "" when the running context is kept
+		recycle bool
+	}{
+		{"fresh context", nil, Options{}, "", false},
+		{"after the page limit", func(p *profile) { p.pages = 3 }, Options{}, "after 3 pages", true},
+		{"below the page limit", func(p *profile) { p.pages = 2 }, Options{}, "", false},
+		{"new proxy", nil, Options{Proxy: &playwright.Proxy{Server: server}}, "new options", true},
+		{"fingerprint rotated", func(p *profile) { p.fingerprint = "stale" }, Options{}, "new fingerprint", true},
+	}
+	for _, c := range cases {
+		pool := NewPool(config.BrowserConfig{MaxPages: 1, RecycleAfterPages: 3}, httputil.NewFingerprints([]config.Fingerprint{
+			{Name: "first", UserAgent: "agent-1"},
+			{Name: "second", UserAgent: "agent-2"},
+		}))
+		bc := running(pool, "a")
+		if c.setup != nil {
+			c.setup(pool.profile("a"))
+		}
+		lease, _ := pool.TryAcquire("a", "scrape")
+		lease.Configure(c.opts)
+
+		prof := lease.prof
+		if got := prof.stale(c.opts, pool.fingerprints, pool.cfg.RecycleAfterPages); got != c.reason {
+			t.Errorf("%s: expected stale %q, got %q", c.name, c.reason, got)
+		}
+		if want := c.reason != "" || prof.pages == 0; lease.Fresh() != want {
+			t.Errorf("%s: expected Fresh=%v", c.name, want)
+		}
+
+		// A closed pool can't relaunch, so a recycle shows as an error
+		pool.closed = true
+		_, err := lease.NewPage(context.Background())
+		if c.recycle != (err != nil) || bc.closed != c.recycle {
+			t.Errorf("%s: expected recycled=%v, got closed=%v err=%v", c.name, c.recycle, bc.closed, err)
+		}
+		if c.recycle && len(pool.slots) != 0 {
+			t.Errorf("%s: expected the failed relaunch to free its slot", c.name)
+		}
+	}
+}
+
+func TestLeaseReportBlock(t *testing.T) {
+	pool := NewPool(config.BrowserConfig{MaxPages: 2}, httputil.NewFingerprints([]config.Fingerprint{
+		{Name: "first", UserAgent: "agent-1"},
+		{Name: "second", UserAgent: "agent-2"},
+	}))
+	bc := running(pool, "a")
+	lease, _ := pool.TryAcquire("a", "scrape")
+	page, _ := lease.NewPage(context.Background())
+	if lease.Fingerprint() != "first" {
+		t.Fatalf("expected the profile to run as first, got %q", lease.Fingerprint())
+	}
+
+	lease.ReportBlock()
+	if !bc.closed || lease.prof.context != nil || len(pool.slots) != 0 {
+		t.Fatalf("expected the blocked context closed and its slots freed")
+	}
+	if got := lease.prof.assigned(pool.fingerprints); got != "second" {
+		t.Fatalf("expected the profile moved to second, got %q", got)
+	}
+	lease.ClosePage(page) // the page died with the context; no slot to free
+	if len(pool.slots) != 0 {
+		t.Fatalf("expected no slot freed twice, %d in use", len(pool.slots))
+	}
+}
+
+func TestApplyFingerprint(t *testing.T) {
+	var opts playwright.BrowserTypeLaunchPersistentContextOptions
+	applyFingerprint(&opts, config.Fingerprint{
+		Name:           "chrome-mac",
+		UserAgent:      "agent-1",
+		Locale:         "en-CA",
+		Timezone:       "America/Toronto",
+		AcceptLanguage: "en-CA,en;q=0.9",
+	})
+	if *opts.UserAgent != "agent-1" || *opts.Locale != "en-CA" || *opts.TimezoneId != "America/Toronto" {
+		t.Fatalf("expected user agent, locale and timezone set, got %+v", opts)
+	}
+	if opts.Viewport != nil {
+		t.Fatalf("expected no viewport without a size")
+	}
+	if opts.ExtraHttpHeaders["Accept-Language"] != "en-CA,en;q=0.9" || opts.ExtraHttpHeaders["User-Agent"] != "" {
+		t.Fatalf("expected Accept-Language only in the headers, got %v", opts.ExtraHttpHeaders)
+	}
+
+	var bare playwright.BrowserTypeLaunchPersistentContextOptions
+	applyFingerprint(&bare, config.Fingerprint{Name: "empty"})
+	if bare.UserAgent != nil || bare.ExtraHttpHeaders != nil {
+		t.Fatalf("expected an empty fingerprint to leave the defaults, got %+v", bare)
+	}
+}
+
+func TestOptionsEqual(t *testing.T) {
+	alice, bob := "alice", "bob"
+	cases := []struct {
+		name  string
+		a, b  *playwright.Proxy
+		equal bool
+	}{
+		{"both direct", nil, nil, true},
+		{"direct and proxied", nil, &playwright.Proxy{Server: "http://10.0.0.1:8080"}, false},
+		{"same server", &playwright.Proxy{Server: "http://10.0.0.1:8080"}, &playwright.Proxy{Server: "http://10.0.0.1:8080"}, true},
+		{"other server", &playwright.Proxy{Server: "http://10.0.0.1:8080"}, &playwright.Proxy{Server: "http://10.0.0.2:8080"}, false},
+		{"other user", &playwright.Proxy{Server: "http://10.0.0.1:8080", Username: &alice}, &playwright.Proxy{Server: "http://10.0.0.1:8080", Username: &bob}, false},
+	}
+	for _, c := range cases {
+		if got := (Options{Proxy: c.a}).equal(Options{Proxy: c.b}); got != c.equal {
+			t.Errorf("%s: expected equal=%v, got %v", c.name, c.equal, got)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+// Package challenge recognizes bot-protection blocks (Incapsula, Cloudflare,
+// captchas, refused or empty responses) in what a fetch got back, reports
+// them as typed errors and keeps count of them so scraping can back off.
+package challenge
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Kind is the kind of block
+type Kind string
+
+const (
+	KindIncapsula  Kind = "incapsula"
+	KindCloudflare Kind = "cloudflare"
+	KindCaptcha    Kind = "captcha"
+	KindHTTP       Kind = "http"       // refused by status: 401, 403 or 429
+	KindSoftBlock  Kind = "soft_block" // success status with an empty body
+)
+
+// ErrBlocked matches every *BlockError with errors.Is
+var ErrBlocked = errors.New("blocked by bot protection")
+
+// BlockError is a fetch that bot protection turned away
+type BlockError struct {
+	Kind    Kind
+	Trigger string // what gave it away: a page marker or the status
+	Host    string
+	Status  int // 0 when not known (browser pages)
+
+	Fingerprint string // the fingerprint profile the fetch went out as, if known
+}
+
+func (e *BlockError) Error() string {
+	msg := fmt.Sprintf("blocked by %s", e.Kind)
+	if e.Trigger != "" {
+		msg += fmt.Sprintf(" (%s)", e.Trigger)
+	}
+	if e.Host != "" {
+		msg += " on " + e.Host
+	}
+	if e.Fingerprint != "" {
+		msg += " as " + e.Fingerprint
+	}
+	return msg
+}
+
+func (e *BlockError) Is(target error) bool {
+	return target == ErrBlocked
+}
+
+// AsBlock returns the BlockError in err's chain, if any
+func AsBlock(err error) (*BlockError, bool) {
+	var b *BlockError
+	ok := errors.As(err, &b)
+	return b, ok
+}
+
+// Page is what a fetch got back
+type Page struct {
+	Host   string
+	Status int    // 0 when not known (browser pages)
+	Body   string // HTML or JSON
+
+	Fingerprint string // the fingerprint profile the fetch went out as
+
+	// Expect are markers of the real content (e.g. listingCard); a body with
+	// any of them is not checked for challenge markers. Without them only
+	// vendor-specific markers and the status are checked: generic markers and
+	// empty bodies need the caller to say what a real page looks like.
+	Expect []string
+}
+
+// missingExpected reports whether the caller said what real content looks
+// like and the body has none of it
+func (p Page) missingExpected() bool {
+	if len(p.Expect) == 0 {
+		return false
+	}
+	for _, want := range p.Expect {
+		if strings.Contains(p.Body, want) {
+			return false
+		}
+	}
+	return true
+}
+
+// Detector recognizes one kind of block; Detect returns what gave it away,
+// or "" if the page isn't blocked
+type Detector interface {
+	Kind() Kind
+	Detect(p Page) string
+}
+
+type markerDetector struct {
+	kind    Kind
+	markers []string // only found on challenge pages
+	generic []string // also found on real pages, e.g. a contact form's captcha
+}
+
+// Markers is a detector that matches any of the markers in the body. Use
+// markers only a challenge page has; see GenericMarkers for the others.
+func Markers(kind Kind, markers ...string) Detector {
+	return markerDetector{kind: kind, markers: markers}
+}
+
+// GenericMarkers is a detector for markers that real pages can contain too
+// ("Access Denied" in help text, a login form's reCAPTCHA). They only count
+// on a page missing its Expect markers.
+func GenericMarkers(kind Kind, markers ...string) Detector {
+	return markerDetector{kind: kind, generic: markers}
+}
+
+func (d markerDetector) Kind() Kind {
+	return d.kind
+}
+
+func (d markerDetector) Detect(p Page) string {
+	for _, want := range p.Expect {
+		if strings.Contains(p.Body, want) {
+			return ""
+		}
+	}
+	for _, m := range d.markers {
+		if strings.Contains(p.Body, m) {
+			return m
+		}
+	}
+	if p.missingExpected() {
+		for _, m := range d.generic {
+			if strings.Contains(p.Body, m) {
+				return m
+			}
+		}
+	}
+	return ""
+}
+
+type statusDetector struct{}
+
+func (statusDetector) Kind() Kind {
+	return KindHTTP
+}
+
+func (statusDetector) Detect(p Page) string {
+	switch p.Status {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests:
+		return fmt.Sprintf("status %d", p.Status)
+	}
+	return ""
+}
+
+type softBlockDetector struct{}
+
+func (softBlockDetector) Kind() Kind {
+	return KindSoftBlock
+}
+
+func (softBlockDetector) Detect(p Page) string {
+	if !p.missingExpected() {
+		return "" // an empty body is only wrong where content was expected
+	}
+	if p.Status >= 200 && p.Status < 300 && p.Status != http.StatusNoContent && strings.TrimSpace(p.Body) == "" {
+		return "empty body"
+	}
+	return ""
+}
+
+var (
+	Incapsula = Markers(KindIncapsula,
+		"Request unsuccessful. Incapsula",
+		"Incapsula incident ID",
+	)
+	// IncapsulaDenied is Incapsula's block page wording, which error and
+	// help pages share
+	IncapsulaDenied = GenericMarkers(KindIncapsula,
+		"Access Denied",
+		"This request was blocked",
+	)
+	Cloudflare = Markers(KindCloudflare,
+		"cf-chl-",
+		"cf_chl_opt",
+		"Attention Required! | Cloudflare",
+		"<title>Just a moment...</title>",
+	)
+	// Captcha is DataDome's captcha, only served to block
+	Captcha = Markers(KindCaptcha,
+		"captcha-delivery.com",
+	)
+	// CaptchaWidget is a captcha widget, which forms embed too
+	CaptchaWidget = GenericMarkers(KindCaptcha,
+		"g-recaptcha",
+		"h-captcha",
+		"hcaptcha.com/",
+		"Please verify you are a human",
+	)
+	// Status flags 401/403/429 responses
+	Status Detector = statusDetector{}
+	// SoftBlock flags a success status with an empty body where content was
+	// expected
+	SoftBlock Detector = softBlockDetector{}
+)
+
+// Detectors are tried in order; page markers first, as a challenge page
+// often comes with a 403
+type Detectors []Detector
+
+// Default is every detector
+var Default = Detectors{Incapsula, Cloudflare, Captcha, IncapsulaDenied, CaptchaWidget, Status, SoftBlock}
+
+// Check returns the block the first matching detector sees, or nil
+func (ds Detectors) Check(p Page) *BlockError {
+	for _, d := range ds {
+		if trigger := d.Detect(p); trigger != "" {
+			return &BlockError{Kind: d.Kind(), Trigger: trigger, Host: p.Host, Status: p.Status, Fingerprint: p.Fingerprint}
+		}
+	}
+	return nil
+}
+
+// Check runs the default detectors; it returns a *BlockError or nil
+func Check(p Page) error {
+	if b := Default.Check(p); b != nil {
+		return b
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package challenge
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	listings := []string{`"Results"`}
+
+	cases := []struct {
+		name    string
+		page    Page
+		kind    Kind // "" for no block
+		trigger string
+	}{
+		{"real results", Page{Status: 200, Body: `{"Results": []}`, Expect: listings}, "", ""},
+		{"incapsula page", Page{Status: 200, Body: `<p>Request unsuccessful. Incapsula incident ID: 123</p>`}, KindIncapsula, "Request unsuccessful. Incapsula"},
+		{"cloudflare interstitial", Page{Status: 503, Body: `<title>Just a moment...</title><script>window._cf_chl_opt={}</script>`}, KindCloudflare, "cf_chl_opt"},
+		{"datadome captcha", Page{Status: 200, Body: `<script src="https://ct.captcha-delivery.com/c.js"></script>`}, KindCaptcha, "captcha-delivery.com"},
+		{"vendor marker beats the status", Page{Status: 403, Body: `Incapsula incident ID: 1`}, KindIncapsula, "Incapsula incident ID"},
+		{"refused", Page{Status: 403, Body: `forbidden`}, KindHTTP, "status 403"},
+		{"rate limited", Page{Status: 429}, KindHTTP, "status 429"},
+		{"not found is not a block", Page{Status: 404, Body: `not found`}, "", ""},
+		{"expected content wins over markers", Page{Status: 200, Body: `{"Results": [], "note": "cf-chl-"}`, Expect: listings}, "", ""},
+
+		// Generic markers are everywhere on real pages
+		{"contact form recaptcha", Page{Status: 200, Body: `<div class="g-recaptcha"></div>`}, "", ""},
+		{"access denied in help text", Page{Status: 200, Body: `<p>Access Denied? Contact support.</p>`}, "", ""},
+		{"recaptcha next to the listings", Page{Status: 200, Body: `{"Results": [], "form": "g-recaptcha"}`, Expect: listings}, "", ""},
+		{"recaptcha instead of the listings", Page{Status: 200, Body: `<div class="g-recaptcha"></div>`, Expect: listings}, KindCaptcha, "g-recaptcha"},
+		{"access denied instead of the listings", Page{Status: 200, Body: `<h1>Access Denied</h1>`, Expect: listings}, KindIncapsula, "Access Denied"},
+	}
+	for _, c := range cases {
+		err := Check(c.page)
+		if c.kind == "" {
+			if err != nil {
+				t.Errorf("%s: expected no block, got %v", c.name, err)
+			}
+			continue
+		}
+		b, ok := AsBlock(err)
+		if !ok || !errors.Is(err, ErrBlocked) {
+			t.Errorf("%s: expected a block, got %v", c.name, err)
+			continue
+		}
+		if b.Kind != c.kind || b.Trigger != c.trigger {
+			t.Errorf("%s: expected %s (%s), got %s (%s)", c.name, c.kind, c.trigger, b.Kind, b.Trigger)
+		}
+	}
+}
+
+func TestSoftBlock(t *testing.T) {
+	listings := []string{"listingCard"}
+
+	cases := []struct {
+		name    string
+		page    Page
+		blocked bool
+	}{
+		{"empty body where listings were expected", Page{Status: 200, Body: "", Expect: listings}, true},
+		{"whitespace body where listings were expected", Page{Status: 200, Body: " \n\t", Expect: listings}, true},
+		{"empty body, nothing expected", Page{Status: 200, Body: ""}, false},
+		{"no content", Page{Status: 204, Body: "", Expect: listings}, false},
+		{"not a success", Page{Status: 500, Body: "", Expect: listings}, false},
+		{"listings", Page{Status: 200, Body: `<div class="listingCard">`, Expect: listings}, false},
+		{"unknown status", Page{Body: "", Expect: listings}, false},
+	}
+	for _, c := range cases {
+		if got := SoftBlock.Detect(c.page) != ""; got != c.blocked {
+			t.Errorf("%s: expected blocked=%v, got %v", c.name, c.blocked, got)
+		}
+	}
+}
+
+func TestBlockError(t *testing.T) {
+	err := error(&BlockError{Kind: KindHTTP, Trigger: "status 403", Host: "example.com", Fingerprint: "chrome"})
+	if got := err.Error(); got != "blocked by http (status 403) on example.com as chrome" {
+		t.Fatalf("unexpected message %q", got)
+	}
+	wrapped := errors.Join(errors.New("fetch page 2"), err)
+	if b, ok := AsBlock(wrapped); !ok || b.Host != "example.com" || !errors.Is(wrapped, ErrBlocked) {
+		t.Fatalf("expected the block found through the wrap")
+	}
+}
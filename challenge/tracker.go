@@ -0,0 +1,134 @@
+package challenge
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Tracker counts blocks per host and per proxy over a sliding window, and
+// turns repeated blocks of a host into a backoff the scheduler honors. A nil
+// Tracker records nothing and never backs off.
+type Tracker struct {
+	window    time.Duration
+	threshold int           // blocks of a host in the window before backing off
+	backoff   time.Duration // first backoff, doubled per further block up to window
+
+	mu     sync.Mutex
+	blocks []block
+}
+
+type block struct {
+	at    time.Time
+	host  string
+	proxy string
+	kind  Kind
+}
+
+func NewTracker(window time.Duration, threshold int, backoff time.Duration) *Tracker {
+	return &Tracker{window: window, threshold: threshold, backoff: backoff}
+}
+
+// Record counts a block of host, seen through proxy ("" when direct)
+func (t *Tracker) Record(host, proxy string, kind Kind) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	t.prune(now)
+	t.blocks = append(t.blocks, block{at: now, host: host, proxy: proxy, kind: kind})
+
+	if n := t.countLocked(func(b block) bool { return b.host == host }); n == t.threshold {
+		log.Printf("Challenge: %d blocks on %s within %v, backing off", n, host, t.window)
+	}
+}
+
+// RecordErr records err if it is a block and reports whether it was
+func (t *Tracker) RecordErr(err error, proxy string) bool {
+	b, ok := AsBlock(err)
+	if ok {
+		t.Record(b.Host, proxy, b.Kind)
+	}
+	return ok
+}
+
+// Count is the number of blocks of host within the window
+func (t *Tracker) Count(host string) int {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.prune(time.Now())
+	return t.countLocked(func(b block) bool { return b.host == host })
+}
+
+// CountProxy is the number of blocks seen through proxy within the window
+func (t *Tracker) CountProxy(proxy string) int {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.prune(time.Now())
+	return t.countLocked(func(b block) bool { return b.proxy == proxy })
+}
+
+// Backoff is how much longer requests to any of hosts should wait: zero
+// below the threshold, then the backoff doubled for each block past it,
+// counted from the latest block
+func (t *Tracker) Backoff(hosts ...string) time.Duration {
+	if t == nil || t.threshold <= 0 {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	t.prune(now)
+	var longest time.Duration
+	for _, host := range hosts {
+		var n int
+		var last time.Time
+		for _, b := range t.blocks {
+			if b.host == host {
+				n++
+				last = b.at
+			}
+		}
+		if n < t.threshold {
+			continue
+		}
+		d := t.backoff << (n - t.threshold)
+		if d > t.window || d <= 0 {
+			d = t.window
+		}
+		if remaining := last.Add(d).Sub(now); remaining > longest {
+			longest = remaining
+		}
+	}
+	return longest
+}
+
+// prune drops blocks older than the window; callers hold mu
+func (t *Tracker) prune(now time.Time) {
+	cutoff := now.Add(-t.window)
+	i := 0
+	for i < len(t.blocks) && t.blocks[i].at.Before(cutoff) {
+		i++
+	}
+	t.blocks = t.blocks[i:]
+}
+
+func (t *Tracker) countLocked(match func(block) bool) int {
+	n := 0
+	for _, b := range t.blocks {
+		if match(b) {
+			n++
+		}
+	}
+	return n
+}
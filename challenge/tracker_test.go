@@ -0,0 +1,104 @@
+package challenge
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// backdate records a block of host through proxy as if it happened ago
+func backdate(t *Tracker, ago time.Duration, host, proxy string) {
+	t.blocks = append(t.blocks, block{at: time.Now().Add(-ago), host: host, proxy: proxy, kind: KindHTTP})
+}
+
+func TestTrackerWindow(t *testing.T) {
+	tr := NewTracker(10*time.Minute, 3, time.Minute)
+	backdate(tr, 20*time.Minute, "a.example.com", "p1")
+	backdate(tr, 11*time.Minute, "a.example.com", "p2")
+	backdate(tr, 5*time.Minute, "a.example.com", "p1")
+	tr.Record("a.example.com", "p2", KindCaptcha)
+	tr.Record("b.example.com", "p1", KindIncapsula)
+	tr.Record("b.example.com", "", KindIncapsula)
+
+	cases := []struct {
+		name string
+		got  int
+		want int
+	}{
+		{"host within the window", tr.Count("a.example.com"), 2},
+		{"other host", tr.Count("b.example.com"), 2},
+		{"unseen host", tr.Count("c.example.com"), 0},
+		{"proxy", tr.CountProxy("p1"), 2},
+		{"proxy whose blocks expired", tr.CountProxy("p2"), 1},
+		{"direct", tr.CountProxy(""), 1},
+	}
+	for _, c := range cases {
+		if c.got != c.want {
+			t.Errorf("%s: expected %d blocks, got %d", c.name, c.want, c.got)
+		}
+	}
+	if len(tr.blocks) != 4 {
+		t.Fatalf("expected blocks past the window pruned, %d left", len(tr.blocks))
+	}
+}
+
+func TestTrackerBackoff(t *testing.T) {
+	cases := []struct {
+		name   string
+		blocks []time.Duration // how long ago each block of the host was
+		min    time.Duration
+		max    time.Duration
+	}{
+		{"below the threshold", []time.Duration{0, 0}, 0, 0},
+		{"at the threshold", []time.Duration{0, 0, 0}, 59 * time.Second, time.Minute},
+		{"doubles per block past it", []time.Duration{0, 0, 0, 0}, 119 * time.Second, 2 * time.Minute},
+		{"capped at the window", []time.Duration{0, 0, 0, 0, 0, 0, 0, 0}, 9 * time.Minute, 10 * time.Minute},
+		{"counted from the latest block", []time.Duration{5 * time.Minute, 5 * time.Minute, 30 * time.Second}, 29 * time.Second, 30 * time.Second},
+		{"expired", []time.Duration{5 * time.Minute, 5 * time.Minute, 2 * time.Minute}, 0, 0},
+		{"blocks past the window don't count", []time.Duration{11 * time.Minute, 0, 0}, 0, 0},
+	}
+	for _, c := range cases {
+		tr := NewTracker(10*time.Minute, 3, time.Minute)
+		for _, ago := range c.blocks {
+			backdate(tr, ago, "a.example.com", "")
+		}
+		if got := tr.Backoff("a.example.com"); got < c.min || got > c.max {
+			t.Errorf("%s: expected backoff in [%v, %v], got %v", c.name, c.min, c.max, got)
+		}
+	}
+
+	// The longest backoff of the hosts applies
+	tr := NewTracker(10*time.Minute, 1, time.Minute)
+	backdate(tr, 0, "a.example.com", "")
+	backdate(tr, 0, "b.example.com", "")
+	backdate(tr, 0, "b.example.com", "")
+	if got := tr.Backoff("a.example.com", "b.example.com"); got < 119*time.Second {
+		t.Fatalf("expected b's doubled backoff, got %v", got)
+	}
+	if got := tr.Backoff("c.example.com"); got != 0 {
+		t.Fatalf("expected no backoff for an unblocked host, got %v", got)
+	}
+}
+
+func TestTrackerRecordErr(t *testing.T) {
+	tr := NewTracker(time.Minute, 1, time.Second)
+	block := fmt.Errorf("page 2: %w", &BlockError{Kind: KindCloudflare, Host: "a.example.com"})
+	if !tr.RecordErr(block, "p1") || tr.Count("a.example.com") != 1 || tr.CountProxy("p1") != 1 {
+		t.Fatalf("expected the wrapped block recorded")
+	}
+	if tr.RecordErr(errors.New("connection reset"), "p1") || tr.CountProxy("p1") != 1 {
+		t.Fatalf("expected other errors ignored")
+	}
+}
+
+func TestNilTracker(t *testing.T) {
+	var tr *Tracker
+	tr.Record("a.example.com", "", KindHTTP)
+	if tr.Count("a.example.com") != 0 || tr.CountProxy("") != 0 || tr.Backoff("a.example.com") != 0 {
+		t.Fatalf("nil tracker should count nothing")
+	}
+	if !tr.RecordErr(&BlockError{Kind: KindHTTP}, "") {
+		t.Fatalf("nil tracker should still recognize blocks")
+	}
+}
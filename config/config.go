@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -12,14 +13,22 @@ import (
 )
 
 type Config struct {
-	Proxy     ProxyConfig
-	Supabase  SupabaseConfig
-	Scheduler SchedulerConfig
-	Scraper   ScraperConfig
-	MediaS3   MediaS3Config
-	DBPath    string
-	LogLevel  string
-	Sites     map[string]*SiteConfig
+	Proxy      ProxyConfig
+	VPN        VPNConfig
+	Egress     EgressConfig
+	Browser    BrowserConfig
+	Supabase   SupabaseConfig
+	Scheduler  SchedulerConfig
+	Scraper    ScraperConfig
+	MediaS3    MediaS3Config
+	Apify      ApifyConfig
+	DBPath     string
+	ArchiveDir string // raw datasets as gzipped JSONL, for replay
+	LogLevel   string
+	Sites      map[string]*SiteConfig
+	RateLimits RateLimitConfig
+
+	Fingerprints []Fingerprint // browser identities for HTTP clients and browser contexts
 }
 
 type MediaS3Config struct {
@@ -30,8 +39,47 @@ type MediaS3Config struct {
 	SecretAccessKey string
 }
 
+// ApifyConfig configures the embedded webhook receiver. Webhooks are only
+// registered when WebhookURL (the publicly reachable base URL) is set.
+type ApifyConfig struct {
+	WebhookURL    string
+	WebhookListen string
+	WebhookSecret string
+}
+
 type ProxyConfig struct {
-	URL string
+	URL      string // one or more proxy URLs, comma-separated
+	ListFile string // optional file of further proxies, one per line
+}
+
+// VPNConfig routes scraping through a VPN client on the host; an empty
+// Provider disables it
+type VPNConfig struct {
+	Provider          string   // expressvpn or wireguard
+	Regions           []string // ExpressVPN locations or wg-quick config names, in rotation order
+	AutoConnect       bool     // connect when the tunnel is found down
+	RotateAfterBlocks int      // bot-protection blocks in a row before moving to the next region; 0 = never
+	KillSwitch        bool     // hold back outbound scraping while the tunnel is down
+	CheckInterval     time.Duration
+}
+
+// EgressConfig is the pre-flight check of the public IP that scraping
+// leaves from, run before each scrape run
+type EgressConfig struct {
+	Enabled        bool
+	EchoURL        string   // returns the caller's IP and country as JSON (ipinfo.io, ip-api.com, ipapi.co)
+	AllowCountries []string // ISO country codes an exit must be in; empty allows any
+	OwnIPs         []string // our own addresses (the droplet's), never a valid exit behind a proxy or VPN
+	CacheTTL       time.Duration
+}
+
+// BrowserConfig is the shared Playwright browser pool used by the browser
+// handler and the enrichment worker
+type BrowserConfig struct {
+	Headless          bool   // headed (false) gets past Incapsula more often; run under xvfb
+	ProfileDir        string // persistent profiles, one subdirectory per site
+	MaxPages          int    // pages open at once across all profiles
+	RecycleAfterPages int    // pages a context serves before it is relaunched; 0 = never
 }
 
 type SupabaseConfig struct {
@@ -44,8 +92,20 @@ type SchedulerConfig struct {
 }
 
 type ScraperConfig struct {
-	DelayMS            int
-	RegionStaggerSecs  int // delay between regions in seconds (e.g., 300 = 5 min)
+	DelayMS                int // default request interval per target host (see RateLimitConfig)
+	RegionStaggerSecs      int // delay between regions in seconds (e.g., 300 = 5 min)
+	RegionRetries          int // extra attempts for a failed region before moving on
+	RegionRetryBackoffSecs int // wait before the first retry, doubled for each further one
+	MaxConcurrency         int // regions scraped at once across all sites
+	MissingGraceHours      int // how long a listing stays missing before a full region scrape delists it; canadesk and capped apify sites never scrape one
+	CoverageMinPct         int // ingested share of the source's reported total below which a region is flagged; apify reports none
+
+	// Bot-protection backoff: a host blocked BlockThreshold times within
+	// BlockWindowMins is left alone for BlockBackoffMins, doubled for each
+	// further block
+	BlockWindowMins  int
+	BlockThreshold   int
+	BlockBackoffMins int
 }
 
 type SiteConfig struct {
@@ -53,12 +113,32 @@ type SiteConfig struct {
 	Name             string            `yaml:"name"`
 	Handler          string            `yaml:"handler"`
 	RateLimitMS      int               `yaml:"rate_limit_ms"`
+	Concurrency      int               `yaml:"concurrency"` // regions of this site scraped at once, default 1
 	Endpoints        map[string]string `yaml:"endpoints"`
 	Regions          map[string]Region `yaml:"regions"`
-	ApifyActor       string            `yaml:"apify_actor"`
+	ApifyActor       ActorList         `yaml:"apify_actor"` // adapters in fallback order
 	ApifyMaxListings int               `yaml:"apify_max_listings"`
+	ApifyBudget      *ApifyBudget      `yaml:"apify_budget"`
+	ApifyShadowActor string            `yaml:"apify_shadow_actor"` // secondary adapter run for comparison only
+
+	Declarative *DeclarativeConfig `yaml:"declarative"`
+	Validation  *ValidationRules   `yaml:"validation"`
 }
 
+// ValidationRules are checked on every listing before it is processed;
+// listings that break any rule are quarantined instead
+type ValidationRules struct {
+	Require               []string `yaml:"require"` // mls, address, city, province, postal_code, price, beds
+	MinPrice              int      `yaml:"min_price"`
+	MaxPrice              int      `yaml:"max_price"`
+	MinBeds               int      `yaml:"min_beds"`
+	BedsExemptTypes       []string `yaml:"beds_exempt_types"`       // property types allowed below min_beds, e.g. Vacant Land
+	PostalMatchesProvince bool     `yaml:"postal_matches_province"` // postal code's first letter must belong to the province
+}
+
+// ValidationFields are the listing fields a ValidationRules.Require entry may name
+var ValidationFields = []string{"mls", "address", "city", "province", "postal_code", "price", "beds"}
+
 type Region struct {
 	Slug    string  `yaml:"slug"`
 	GeoID   string  `yaml:"geo_id"`
@@ -69,12 +149,62 @@ type Region struct {
 	LngMax  float64 `yaml:"lng_max"`
 }
 
+// ActorList is an ordered list of Apify adapters. In YAML it accepts either a
+// single name (apify_actor: canadesk) or a list (apify_actor: [canadesk, scrapemind]).
+type ActorList []string
+
+func (l *ActorList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		if value.Value != "" {
+			*l = ActorList{value.Value}
+		}
+		return nil
+	}
+	var list []string
+	if err := value.Decode(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// ApifyBudget caps a site's Apify spend per calendar month. Past DownscaleAt
+// (a fraction of MonthlyUSD) runs are capped at DownscaleMaxListings; once
+// MonthlyUSD is reached runs are skipped until the next month.
+type ApifyBudget struct {
+	MonthlyUSD           float64 `yaml:"monthly_usd"`
+	DownscaleAt          float64 `yaml:"downscale_at"`
+	DownscaleMaxListings int     `yaml:"downscale_max_listings"`
+}
+
 func Load() (*Config, error) {
 	_ = godotenv.Load()
 
 	cfg := &Config{
 		Proxy: ProxyConfig{
-			URL: os.Getenv("PROXY_URL"),
+			URL:      os.Getenv("PROXY_URL"),
+			ListFile: os.Getenv("PROXY_LIST_FILE"),
+		},
+		VPN: VPNConfig{
+			Provider:          os.Getenv("VPN_PROVIDER"),
+			Regions:           getEnvList("VPN_REGIONS", ""),
+			AutoConnect:       getEnvBool("VPN_AUTO_CONNECT", true),
+			RotateAfterBlocks: getEnvInt("VPN_ROTATE_AFTER_BLOCKS", 3),
+			KillSwitch:        getEnvBool("VPN_KILL_SWITCH", true),
+			CheckInterval:     time.Duration(getEnvInt("VPN_CHECK_SECS", 30)) * time.Second,
+		},
+		Egress: EgressConfig{
+			Enabled:        getEnvBool("EGRESS_CHECK", true),
+			EchoURL:        getEnv("EGRESS_ECHO_URL", "https://ipinfo.io/json"),
+			AllowCountries: getEnvList("EGRESS_ALLOW_COUNTRIES", "CA"),
+			OwnIPs:         getEnvList("EGRESS_OWN_IPS", ""),
+			CacheTTL:       time.Duration(getEnvInt("EGRESS_CACHE_SECS", 300)) * time.Second,
+		},
+		Browser: BrowserConfig{
+			Headless:          getEnvBool("BROWSER_HEADLESS", false),
+			ProfileDir:        getEnv("BROWSER_PROFILE_DIR", "browser_data"),
+			MaxPages:          getEnvInt("BROWSER_MAX_PAGES", 4),
+			RecycleAfterPages: getEnvInt("BROWSER_RECYCLE_PAGES", 200),
 		},
 		Supabase: SupabaseConfig{
 			DBURL: os.Getenv("SUPABASE_DB_URL"),
@@ -83,8 +213,16 @@ func Load() (*Config, error) {
 			Cron: os.Getenv("SCRAPE_CRON"),
 		},
 		Scraper: ScraperConfig{
-			DelayMS:           getEnvInt("SCRAPE_DELAY_MS", 500),
-			RegionStaggerSecs: getEnvInt("SCRAPE_REGION_STAGGER_SECS", 0),
+			DelayMS:                getEnvInt("SCRAPE_DELAY_MS", 500),
+			RegionStaggerSecs:      getEnvInt("SCRAPE_REGION_STAGGER_SECS", 0),
+			RegionRetries:          getEnvInt("SCRAPE_REGION_RETRIES", 2),
+			RegionRetryBackoffSecs: getEnvInt("SCRAPE_REGION_RETRY_BACKOFF_SECS", 60),
+			MaxConcurrency:         getEnvInt("SCRAPE_MAX_CONCURRENCY", 2),
+			MissingGraceHours:      getEnvInt("SCRAPE_MISSING_GRACE_HOURS", 48),
+			CoverageMinPct:         getEnvInt("SCRAPE_COVERAGE_MIN_PCT", 80),
+			BlockWindowMins:        getEnvInt("SCRAPE_BLOCK_WINDOW_MINS", 60),
+			BlockThreshold:         getEnvInt("SCRAPE_BLOCK_THRESHOLD", 3),
+			BlockBackoffMins:       getEnvInt("SCRAPE_BLOCK_BACKOFF_MINS", 30),
 		},
 		MediaS3: MediaS3Config{
 			Bucket:          os.Getenv("MEDIA_S3_BUCKET"),
@@ -93,9 +231,15 @@ func Load() (*Config, error) {
 			AccessKeyID:     os.Getenv("MEDIA_S3_ACCESS_KEY_ID"),
 			SecretAccessKey: os.Getenv("MEDIA_S3_SECRET_ACCESS_KEY"),
 		},
-		DBPath: getEnv("DB_PATH", "scraper.db"),
-		LogLevel: getEnv("LOG_LEVEL", "info"),
-		Sites:    make(map[string]*SiteConfig),
+		Apify: ApifyConfig{
+			WebhookURL:    os.Getenv("APIFY_WEBHOOK_URL"),
+			WebhookListen: getEnv("APIFY_WEBHOOK_LISTEN", ":8089"),
+			WebhookSecret: os.Getenv("APIFY_WEBHOOK_SECRET"),
+		},
+		DBPath:     getEnv("DB_PATH", "scraper.db"),
+		ArchiveDir: getEnv("DATASET_ARCHIVE_DIR", "archive"),
+		LogLevel:   getEnv("LOG_LEVEL", "info"),
+		Sites:      make(map[string]*SiteConfig),
 	}
 
 	if interval := os.Getenv("SCRAPE_INTERVAL"); interval != "" {
@@ -109,6 +253,13 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
+	if err := cfg.loadRateLimits(); err != nil {
+		return nil, err
+	}
+	if err := cfg.loadFingerprints(); err != nil {
+		return nil, err
+	}
+
 	if err := cfg.validate(); err != nil {
 		return nil, err
 	}
@@ -119,8 +270,8 @@ func Load() (*Config, error) {
 func (c *Config) validate() error {
 	var missing []string
 
-	if c.Proxy.URL == "" {
-		missing = append(missing, "PROXY_URL")
+	if c.Proxy.URL == "" && c.Proxy.ListFile == "" {
+		missing = append(missing, "PROXY_URL or PROXY_LIST_FILE")
 	}
 
 	// Supabase DB URL is required for domain data (Postgres)
@@ -137,6 +288,41 @@ func (c *Config) validate() error {
 		}
 	}
 
+	if p := c.VPN.Provider; p != "" && p != "expressvpn" && p != "wireguard" {
+		missing = append(missing, fmt.Sprintf("VPN_PROVIDER expressvpn or wireguard instead of %q", p))
+	}
+	if c.VPN.Provider != "" && c.VPN.CheckInterval <= 0 {
+		missing = append(missing, "VPN_CHECK_SECS > 0 (required with VPN_PROVIDER)")
+	}
+
+	if c.Apify.WebhookURL != "" && c.Apify.WebhookSecret == "" {
+		missing = append(missing, "APIFY_WEBHOOK_SECRET (required when APIFY_WEBHOOK_URL is set)")
+	}
+
+	for _, site := range c.Sites {
+		if site.Handler == "browser" && site.Concurrency > 1 {
+			missing = append(missing, fmt.Sprintf("concurrency <= 1 for browser handler (one shared page) in site config %s", site.ID))
+		}
+		if site.Handler == "declarative" && site.Declarative == nil {
+			missing = append(missing, fmt.Sprintf("declarative block in site config %s", site.ID))
+		}
+		if v := site.Validation; v != nil {
+			for _, field := range v.Require {
+				if !containsString(ValidationFields, field) {
+					missing = append(missing, fmt.Sprintf("validation.require: known field instead of %q in site config %s", field, site.ID))
+				}
+			}
+		}
+		if b := site.ApifyBudget; b != nil {
+			if b.MonthlyUSD <= 0 {
+				missing = append(missing, fmt.Sprintf("apify_budget.monthly_usd > 0 in site config %s", site.ID))
+			}
+			if b.DownscaleAt < 0 || b.DownscaleAt > 1 {
+				missing = append(missing, fmt.Sprintf("apify_budget.downscale_at between 0 and 1 in site config %s", site.ID))
+			}
+		}
+	}
+
 	if len(missing) > 0 {
 		return fmt.Errorf("missing required config:\n  - %s", joinStrings(missing, "\n  - "))
 	}
@@ -165,14 +351,34 @@ func joinStrings(strs []string, sep string) string {
 	return result
 }
 
+func containsString(strs []string, s string) bool {
+	for _, v := range strs {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Config) loadSiteConfigs() error {
-	configDir := "config/sites"
-	entries, err := os.ReadDir(configDir)
+	sites, err := LoadSites("config/sites")
+	if err != nil {
+		return err
+	}
+	c.Sites = sites
+	return nil
+}
+
+// LoadSites reads every *.yaml site config in dir, keyed by site ID
+func LoadSites(dir string) (map[string]*SiteConfig, error) {
+	sites := make(map[string]*SiteConfig)
+
+	entries, err := os.ReadDir(dir)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil
+			return sites, nil
 		}
-		return err
+		return nil, err
 	}
 
 	for _, entry := range entries {
@@ -180,21 +386,30 @@ func (c *Config) loadSiteConfigs() error {
 			continue
 		}
 
-		path := filepath.Join(configDir, entry.Name())
-		data, err := os.ReadFile(path)
+		site, err := LoadSiteFile(filepath.Join(dir, entry.Name()))
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		var site SiteConfig
-		if err := yaml.Unmarshal(data, &site); err != nil {
-			return err
-		}
+		sites[site.ID] = site
+	}
+
+	return sites, nil
+}
 
-		c.Sites[site.ID] = &site
+// LoadSiteFile reads a single site config
+func LoadSiteFile(path string) (*SiteConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	var site SiteConfig
+	if err := yaml.Unmarshal(data, &site); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return &site, nil
 }
 
 func getEnv(key, defaultVal string) string {
@@ -212,3 +427,23 @@ func getEnvInt(key string, defaultVal int) int {
 	}
 	return defaultVal
 }
+
+func getEnvBool(key string, defaultVal bool) bool {
+	if val := os.Getenv(key); val != "" {
+		if b, err := strconv.ParseBool(val); err == nil {
+			return b
+		}
+	}
+	return defaultVal
+}
+
+// getEnvList splits a comma-separated variable, dropping empty entries
+func getEnvList(key, defaultVal string) []string {
+	var list []string
+	for _, v := range strings.Split(getEnv(key, defaultVal), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
@@ -0,0 +1,41 @@
+package config
+
+// DeclarativeConfig describes a source entirely in YAML: how to build the
+// request, how to page through results, and how to map items onto RawListing.
+type DeclarativeConfig struct {
+	Format     string                  `yaml:"format"` // json (default) or html
+	Request    RequestTemplate         `yaml:"request"`
+	Pagination PaginationConfig        `yaml:"pagination"`
+	Items      string                  `yaml:"items"` // JSONPath (json) or CSS selector (html) selecting each listing
+	Total      string                  `yaml:"total"` // JSONPath (json only) to the region's total listing count, for coverage
+	Fields     map[string]FieldMapping `yaml:"fields"`
+}
+
+// RequestTemplate fields are Go text/templates rendered with the region and
+// pagination state, e.g. {{.Region.LatMax}}, {{.Page}}, {{.Offset}}, {{.PageSize}}.
+type RequestTemplate struct {
+	Method  string            `yaml:"method"`
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers"`
+	Body    string            `yaml:"body"`
+}
+
+type PaginationConfig struct {
+	Strategy string `yaml:"strategy"` // none (default), page, offset
+	Start    int    `yaml:"start"`    // first page number or offset
+	PageSize int    `yaml:"page_size"`
+	MaxPages int    `yaml:"max_pages"` // safety cap, 0 = unlimited
+}
+
+// FieldMapping maps one RawListing field. Path is a JSONPath relative to the
+// item for json sources, or a CSS selector within the item for html sources.
+// Each optionally selects sub-elements first, so Path/Fallback apply per element
+// (e.g. photos where each entry has a high-res path or a low-res fallback).
+type FieldMapping struct {
+	Each      string `yaml:"each"`
+	Path      string `yaml:"path"`
+	Fallback  string `yaml:"fallback"`  // used when Path yields nothing
+	Attr      string `yaml:"attr"`      // html only: read attribute instead of text
+	Transform string `yaml:"transform"` // named transform (price, bedrooms, int, ...)
+	Prefix    string `yaml:"prefix"`    // prepended to non-empty values (e.g. base URL)
+}
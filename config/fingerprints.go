@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Fingerprint is one consistent browser identity (config/fingerprints.yaml):
+// the headers the net/http clients send and the settings browser contexts
+// launch with all describe the same browser on the same machine
+type Fingerprint struct {
+	Name           string      `yaml:"name"`
+	UserAgent      string      `yaml:"user_agent"`
+	AcceptLanguage string      `yaml:"accept_language"`
+	Locale         string      `yaml:"locale"`   // e.g. en-CA
+	Timezone       string      `yaml:"timezone"` // IANA, e.g. America/Toronto
+	Platform       string      `yaml:"platform"` // navigator.platform, e.g. Win32
+	Viewport       Viewport    `yaml:"viewport"`
+	ClientHints    ClientHints `yaml:"client_hints"`
+}
+
+type Viewport struct {
+	Width  int `yaml:"width"`
+	Height int `yaml:"height"`
+}
+
+// ClientHints are the Sec-CH-UA headers Chromium sends with every request
+type ClientHints struct {
+	UA       string `yaml:"sec_ch_ua"`
+	Mobile   string `yaml:"sec_ch_ua_mobile"`
+	Platform string `yaml:"sec_ch_ua_platform"`
+}
+
+// DefaultFingerprint is used when no fingerprint file exists
+var DefaultFingerprint = Fingerprint{
+	Name:           "chrome-windows",
+	UserAgent:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36",
+	AcceptLanguage: "en-CA,en;q=0.9",
+	Locale:         "en-CA",
+	Timezone:       "America/Toronto",
+	Platform:       "Win32",
+	Viewport:       Viewport{Width: 1920, Height: 1080},
+	ClientHints: ClientHints{
+		UA:       `"Google Chrome";v="131", "Chromium";v="131", "Not_A Brand";v="24"`,
+		Mobile:   "?0",
+		Platform: `"Windows"`,
+	},
+}
+
+// LoadFingerprints reads the fingerprint file; a missing file gives just
+// DefaultFingerprint
+func LoadFingerprints(path string) ([]Fingerprint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Fingerprint{DefaultFingerprint}, nil
+		}
+		return nil, err
+	}
+	var file struct {
+		Fingerprints []Fingerprint `yaml:"fingerprints"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	seen := make(map[string]bool)
+	for i, fp := range file.Fingerprints {
+		if fp.Name == "" || fp.UserAgent == "" {
+			return nil, fmt.Errorf("%s: fingerprint %d needs a name and user_agent", path, i+1)
+		}
+		if seen[fp.Name] {
+			return nil, fmt.Errorf("%s: duplicate fingerprint %q", path, fp.Name)
+		}
+		seen[fp.Name] = true
+	}
+	if len(file.Fingerprints) == 0 {
+		return []Fingerprint{DefaultFingerprint}, nil
+	}
+	return file.Fingerprints, nil
+}
+
+func (c *Config) loadFingerprints() error {
+	fps, err := LoadFingerprints(getEnv("FINGERPRINTS_FILE", "config/fingerprints.yaml"))
+	if err != nil {
+		return err
+	}
+	c.Fingerprints = fps
+	return nil
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RateLimitConfig bounds the request rate to each target host, shared by
+// every handler and worker (config/rate_limits.yaml). Hosts without an entry
+// use Default.
+type RateLimitConfig struct {
+	Default HostRate            `yaml:"default"`
+	Hosts   map[string]HostRate `yaml:"hosts"` // exact host names, e.g. www.realtor.ca
+}
+
+// HostRate is a token bucket: Burst requests may go back to back, after which
+// one request is allowed per IntervalMS. JitterMS adds a random extra delay
+// of up to that much to every request, for human-like spacing.
+type HostRate struct {
+	IntervalMS int `yaml:"interval_ms"` // 0 = unlimited
+	Burst      int `yaml:"burst"`       // default 1
+	JitterMS   int `yaml:"jitter_ms"`
+}
+
+// LoadRateLimits reads the rate limit file; a missing file leaves every host
+// on the default
+func LoadRateLimits(path string) (RateLimitConfig, error) {
+	var rl RateLimitConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return rl, nil
+		}
+		return rl, err
+	}
+	if err := yaml.Unmarshal(data, &rl); err != nil {
+		return rl, fmt.Errorf("%s: %w", path, err)
+	}
+	return rl, nil
+}
+
+// loadRateLimits reads the rate limit file and fills in what it leaves out:
+// the default interval from SCRAPE_DELAY_MS and, for each site with
+// rate_limit_ms, the hosts of its endpoints
+func (c *Config) loadRateLimits() error {
+	rl, err := LoadRateLimits(getEnv("RATE_LIMITS_FILE", "config/rate_limits.yaml"))
+	if err != nil {
+		return err
+	}
+	if rl.Default.IntervalMS == 0 {
+		rl.Default.IntervalMS = c.Scraper.DelayMS
+	}
+	if rl.Hosts == nil {
+		rl.Hosts = make(map[string]HostRate)
+	}
+
+	for _, site := range c.Sites {
+		if site.RateLimitMS <= 0 {
+			continue
+		}
+		for _, host := range site.Hosts() {
+			if _, ok := rl.Hosts[host]; !ok {
+				rl.Hosts[host] = HostRate{IntervalMS: site.RateLimitMS}
+			}
+		}
+	}
+
+	c.RateLimits = rl
+	return nil
+}
+
+// Hosts returns the hosts the site's endpoints and request template point at
+func (s *SiteConfig) Hosts() []string {
+	urls := make([]string, 0, len(s.Endpoints)+1)
+	for _, u := range s.Endpoints {
+		urls = append(urls, u)
+	}
+	if s.Declarative != nil {
+		urls = append(urls, s.Declarative.Request.URL)
+	}
+
+	var hosts []string
+	seen := make(map[string]bool)
+	for _, raw := range urls {
+		u, err := url.Parse(raw)
+		if err != nil || u.Hostname() == "" || strings.Contains(u.Host, "{{") {
+			continue
+		}
+		host := strings.ToLower(u.Hostname())
+		if !seen[host] {
+			seen[host] = true
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
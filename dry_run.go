@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"tct_scrooper/scraper"
+	"tct_scrooper/services"
+)
+
+// maxReportedPlans caps the per-listing lines printed by -scrape -dry-run;
+// the JSON report always has all of them
+const maxReportedPlans = 50
+
+// runDryRunScrape scrapes without ingesting, prints what ingestion would
+// change and writes the full diff as JSON to reportPath
+func runDryRunScrape(ctx context.Context, orchestrator *scraper.Orchestrator, site, region, reportPath string) error {
+	fmt.Println("Dry run: scraping without writing any data")
+
+	report, err := orchestrator.DryRun(ctx, site, region)
+	if report == nil {
+		return err
+	}
+
+	printDryRunReport(report)
+
+	if reportPath == "" {
+		reportPath = fmt.Sprintf("dry_run_%s.json", report.StartedAt.Format("20060102_150405"))
+	}
+	data, jsonErr := json.MarshalIndent(report, "", "  ")
+	if jsonErr != nil {
+		return jsonErr
+	}
+	if writeErr := os.WriteFile(reportPath, data, 0644); writeErr != nil {
+		return writeErr
+	}
+	fmt.Printf("\nReport written to %s\n", reportPath)
+	return err
+}
+
+func printDryRunReport(r *services.DryRunReport) {
+	fmt.Printf("\n== regions ==\n")
+	for _, reg := range r.Regions {
+		line := fmt.Sprintf("   %s/%s: %d fetched", reg.Site, reg.Region, reg.Fetched)
+		if reg.Expected > 0 {
+			line += fmt.Sprintf(" of %d reported", reg.Expected)
+		}
+		if reg.Quarantined > 0 {
+			line += fmt.Sprintf(", %d would be quarantined", reg.Quarantined)
+		}
+		if reg.Duplicates > 0 {
+			line += fmt.Sprintf(", %d duplicates", reg.Duplicates)
+		}
+		if reg.ParseFailures > 0 {
+			line += fmt.Sprintf(", %d parse failures", reg.ParseFailures)
+		}
+		if reg.ApifyUsageUSD > 0 {
+			line += fmt.Sprintf(", $%.4f apify", reg.ApifyUsageUSD)
+		}
+		if reg.Error != "" {
+			line += " FAILED: " + reg.Error
+		}
+		fmt.Println(line)
+	}
+
+	if len(r.Listings) > 0 {
+		fmt.Printf("\n== changes ==\n")
+	}
+	for i, p := range r.Listings {
+		if i == maxReportedPlans {
+			fmt.Printf("   ... %d more listings\n", len(r.Listings)-maxReportedPlans)
+			break
+		}
+		fmt.Printf("   %s %s (%s): %s\n", p.Source, p.MLS, p.Address, describePlan(&p))
+	}
+
+	t := r.Totals
+	fmt.Printf("\n== summary (%s) ==\n", r.FinishedAt.Sub(r.StartedAt).Round(time.Second))
+	fmt.Printf("   fetched:          %d\n", t.Fetched)
+	fmt.Printf("   new properties:   %d\n", t.NewProperties)
+	fmt.Printf("   new listings:     %d\n", t.NewListings)
+	fmt.Printf("   relisted:         %d\n", t.Relisted)
+	fmt.Printf("   price changes:    %d\n", t.PriceChanges)
+	fmt.Printf("   match candidates: %d\n", t.MatchCandidates)
+	fmt.Printf("   media to queue:   %d\n", t.MediaToQueue)
+	fmt.Printf("   unchanged:        %d\n", t.Unchanged)
+	fmt.Printf("   duplicates:       %d\n", t.Duplicates)
+	fmt.Printf("   quarantined:      %d\n", t.Quarantined)
+	fmt.Printf("   errors:           %d\n", t.Errors)
+	fmt.Printf("   failed regions:   %d\n", t.FailedRegions)
+	if t.ApifyUsageUSD > 0 {
+		fmt.Printf("   apify usage:      $%.4f\n", t.ApifyUsageUSD)
+	}
+}
+
+// describePlan summarizes a listing plan in one line
+func describePlan(p *services.ListingPlan) string {
+	var parts []string
+	if p.NewProperty {
+		parts = append(parts, "new property")
+	}
+	if p.Relisted {
+		parts = append(parts, "relist of "+p.RelistedFrom)
+	} else if p.NewListing {
+		parts = append(parts, "new listing")
+	}
+	if p.PriceChanged {
+		parts = append(parts, fmt.Sprintf("price %.0f -> %.0f", *p.PreviousPrice, *p.Price))
+	}
+	if n := len(p.Matches); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d match candidate(s)", n))
+	}
+	if p.MediaToQueue > 0 {
+		parts = append(parts, fmt.Sprintf("%d media", p.MediaToQueue))
+	}
+	return strings.Join(parts, ", ")
+}
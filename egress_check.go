@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"tct_scrooper/httputil"
+	"tct_scrooper/vpn"
+)
+
+// runEgressCheck brings up the VPN if there is one, then prints each
+// client's exit address and whether it passes the egress policy
+func runEgressCheck(ctx context.Context, egress *httputil.EgressChecker, vpnManager *vpn.Manager) error {
+	if egress == nil {
+		fmt.Println("Egress check disabled (EGRESS_CHECK=false)")
+		return nil
+	}
+	if err := vpnManager.EnsureConnected(ctx); err != nil {
+		return fmt.Errorf("vpn: %w", err)
+	}
+	if region := vpnManager.Region(); region != "" {
+		fmt.Printf("VPN region: %s\n", region)
+	}
+
+	failed := 0
+	for _, r := range egress.Check(ctx) {
+		if r.Egress == nil {
+			fmt.Printf("%-8s FAIL  %v\n", r.Client, r.Err)
+			failed++
+			continue
+		}
+		where := r.Egress.Country
+		if r.Egress.City != "" {
+			where += " " + r.Egress.City
+		}
+		if r.Egress.Org != "" {
+			where += " (" + r.Egress.Org + ")"
+		}
+		if r.Err != nil {
+			fmt.Printf("%-8s FAIL  %s  %s: %v\n", r.Client, r.Egress.IP, where, r.Err)
+			failed++
+			continue
+		}
+		fmt.Printf("%-8s ok    %s  %s\n", r.Client, r.Egress.IP, where)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d client(s) failed", failed)
+	}
+	return nil
+}
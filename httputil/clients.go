@@ -3,29 +3,23 @@ package httputil
 import (
 	"crypto/tls"
 	"net/http"
-	"net/url"
 	"time"
-
-	"tct_scrooper/config"
 )
 
 type Clients struct {
-	Scraping *http.Client // proxied, for target sites
-	API      *http.Client // direct, for Apify/Supabase
+	Scraping *http.Client // through the proxy pool, rate limited, retried and fingerprinted, for target sites
+	API      *http.Client // direct and retried, for Apify/Supabase
 }
 
-func NewClients(proxyCfg *config.ProxyConfig) *Clients {
-	proxyURL, _ := url.Parse(proxyCfg.URL)
-
+func NewClients(proxies *ProxyPool, limiter *HostLimiter, fingerprints *Fingerprints) *Clients {
 	transport := &http.Transport{
-		Proxy:             http.ProxyURL(proxyURL),
 		ForceAttemptHTTP2: false,
 		TLSNextProto:      make(map[string]func(string, *tls.Conn) http.RoundTripper),
 	}
 
+	// Timeouts are per attempt, on the retry policies
 	scraping := &http.Client{
-		Timeout:   15 * time.Second,
-		Transport: transport,
+		Transport: fingerprints.Transport("scraping", ScrapingRetry.WithAttemptTimeout(15*time.Second).Transport(limiter.Transport(proxies.Transport(transport)))),
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		},
@@ -33,6 +27,6 @@ func NewClients(proxyCfg *config.ProxyConfig) *Clients {
 
 	return &Clients{
 		Scraping: scraping,
-		API:      &http.Client{Timeout: 30 * time.Second},
+		API:      &http.Client{Transport: APIRetry.WithAttemptTimeout(30 * time.Second).Transport(nil)},
 	}
 }
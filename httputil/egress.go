@@ -0,0 +1,213 @@
+package httputil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"tct_scrooper/config"
+)
+
+// Egress is the public address a client's requests leave from, as seen by
+// the echo endpoint
+type Egress struct {
+	IP      string
+	Country string // ISO code; empty if the endpoint doesn't report it
+	City    string
+	Org     string
+}
+
+// EgressResult is the check of one client
+type EgressResult struct {
+	Client string
+	Egress *Egress
+	Err    error // lookup failure or policy violation
+}
+
+type egressTarget struct {
+	name         string
+	client       *http.Client
+	allowOwnIP   bool
+	distinctFrom string
+}
+
+// EgressChecker verifies, before scraping, that each registered client
+// leaves from an allowed country and not from our own IP, so a proxy or VPN
+// that silently falls back is caught. Successful checks are cached for
+// CacheTTL. A nil checker passes everything.
+type EgressChecker struct {
+	cfg     config.EgressConfig
+	targets []egressTarget
+
+	mu        sync.Mutex
+	checkedAt time.Time
+}
+
+// NewEgressChecker returns nil when the check is disabled
+func NewEgressChecker(cfg config.EgressConfig) *EgressChecker {
+	if !cfg.Enabled || cfg.EchoURL == "" {
+		return nil
+	}
+	return &EgressChecker{cfg: cfg}
+}
+
+// AddClient registers a client to check. allowOwnIP accepts our own IP as
+// its exit (a client meant to go out directly); distinctFrom names an
+// earlier client whose exit this one must not share, e.g. a proxied client
+// must not leave from the direct one's IP.
+func (c *EgressChecker) AddClient(name string, client *http.Client, allowOwnIP bool, distinctFrom string) {
+	c.targets = append(c.targets, egressTarget{name, client, allowOwnIP, distinctFrom})
+}
+
+// Verify checks every client unless a check passed within CacheTTL; the
+// error names each client that failed and why
+func (c *EgressChecker) Verify(ctx context.Context) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.checkedAt.IsZero() && time.Since(c.checkedAt) < c.cfg.CacheTTL {
+		return nil
+	}
+
+	var failures []string
+	for _, r := range c.check(ctx) {
+		if r.Err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", r.Client, r.Err))
+		}
+	}
+	if len(failures) > 0 {
+		return errors.New(strings.Join(failures, "; "))
+	}
+	c.checkedAt = time.Now()
+	return nil
+}
+
+// Invalidate drops a cached pass, so the next Verify checks again; called
+// when the exit changes
+func (c *EgressChecker) Invalidate() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checkedAt = time.Time{}
+}
+
+// Check looks up and checks every client now, bypassing the cache
+func (c *EgressChecker) Check(ctx context.Context) []EgressResult {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.check(ctx)
+}
+
+func (c *EgressChecker) check(ctx context.Context) []EgressResult {
+	results := make([]EgressResult, 0, len(c.targets))
+	ips := make(map[string]string) // client name -> exit IP
+	for _, t := range c.targets {
+		r := EgressResult{Client: t.name}
+		r.Egress, r.Err = LookupEgress(ctx, t.client, c.cfg.EchoURL)
+		if r.Err == nil {
+			r.Err = c.policyError(t, r.Egress, ips)
+			ips[t.name] = r.Egress.IP
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// policyError applies the allow/deny policy to one client's exit
+func (c *EgressChecker) policyError(t egressTarget, e *Egress, ips map[string]string) error {
+	if !t.allowOwnIP {
+		for _, own := range c.cfg.OwnIPs {
+			if e.IP == own {
+				return fmt.Errorf("exit %s is our own IP", e.IP)
+			}
+		}
+	}
+	if t.distinctFrom != "" && ips[t.distinctFrom] == e.IP {
+		return fmt.Errorf("exit %s is the same as %s's", e.IP, t.distinctFrom)
+	}
+
+	allowed := c.cfg.AllowCountries
+	if len(allowed) == 0 || (len(allowed) == 1 && allowed[0] == "*") {
+		return nil
+	}
+	if e.Country == "" {
+		return fmt.Errorf("exit %s: country unknown (echo endpoint did not report one)", e.IP)
+	}
+	for _, country := range allowed {
+		if strings.EqualFold(country, e.Country) {
+			return nil
+		}
+	}
+	return fmt.Errorf("exit %s is in %s, not %s", e.IP, e.Country, strings.Join(allowed, "/"))
+}
+
+// LookupEgress asks the echo endpoint for the client's public address. It
+// understands the JSON of ipinfo.io, ip-api.com and ipapi.co, and plain-text
+// endpoints that return only the IP.
+func LookupEgress(ctx context.Context, client *http.Client, echoURL string) (*Egress, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", echoURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("echo endpoint status %d", resp.StatusCode)
+	}
+
+	if ip := strings.TrimSpace(string(body)); net.ParseIP(ip) != nil {
+		return &Egress{IP: ip}, nil
+	}
+
+	var echo struct {
+		IP          string `json:"ip"`
+		Query       string `json:"query"` // ip-api.com
+		Country     string `json:"country"`
+		CountryCode string `json:"countryCode"`  // ip-api.com
+		CountryISO  string `json:"country_code"` // ipapi.co
+		City        string `json:"city"`
+		Org         string `json:"org"`
+	}
+	if err := json.Unmarshal(body, &echo); err != nil {
+		return nil, fmt.Errorf("decode echo response: %w", err)
+	}
+
+	e := &Egress{IP: echo.IP, City: echo.City, Org: echo.Org}
+	if e.IP == "" {
+		e.IP = echo.Query
+	}
+	for _, code := range []string{echo.CountryISO, echo.CountryCode, echo.Country} {
+		if len(code) == 2 {
+			e.Country = strings.ToUpper(code)
+			break
+		}
+	}
+	if net.ParseIP(e.IP) == nil {
+		return nil, fmt.Errorf("echo response has no IP")
+	}
+	return e, nil
+}
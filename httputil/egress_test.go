@@ -0,0 +1,152 @@
+package httputil
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"tct_scrooper/config"
+)
+
+// echoClient answers every request with body, as an echo endpoint would
+// for a client leaving from some address; a nil body is a failed lookup
+func echoClient(body *string, calls *int) *http.Client {
+	return &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if calls != nil {
+			*calls++
+		}
+		if body == nil {
+			return nil, errors.New("connection refused")
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(*body)), Header: make(http.Header)}, nil
+	})}
+}
+
+func str(s string) *string { return &s }
+
+func TestLookupEgress(t *testing.T) {
+	cases := []struct {
+		name    string
+		body    string
+		want    Egress
+		wantErr bool
+	}{
+		{"ipinfo", `{"ip": "203.0.113.5", "country": "ca", "city": "Toronto", "org": "AS1 Example"}`, Egress{IP: "203.0.113.5", Country: "CA", City: "Toronto", Org: "AS1 Example"}, false},
+		{"ip-api", `{"query": "203.0.113.6", "country": "Canada", "countryCode": "CA"}`, Egress{IP: "203.0.113.6", Country: "CA"}, false},
+		{"ipapi.co", `{"ip": "203.0.113.7", "country": "CA", "country_code": "CA"}`, Egress{IP: "203.0.113.7", Country: "CA"}, false},
+		{"plain text", "203.0.113.8\n", Egress{IP: "203.0.113.8"}, false},
+		{"no ip", `{"country": "CA"}`, Egress{}, true},
+		{"not json", `<html>captive portal</html>`, Egress{}, true},
+	}
+	for _, c := range cases {
+		got, err := LookupEgress(context.Background(), echoClient(str(c.body), nil), "https://echo.example.com/json")
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %+v", c.name, got)
+			}
+			continue
+		}
+		if err != nil || *got != c.want {
+			t.Errorf("%s: expected %+v, got %+v %v", c.name, c.want, got, err)
+		}
+	}
+}
+
+func TestEgressCheckerPolicy(t *testing.T) {
+	canadian := `{"ip": "203.0.113.5", "country": "CA"}`
+	cfg := config.EgressConfig{
+		Enabled:        true,
+		EchoURL:        "https://echo.example.com/json",
+		AllowCountries: []string{"CA"},
+		OwnIPs:         []string{"198.51.100.1"},
+	}
+
+	cases := []struct {
+		name    string
+		cfg     func(*config.EgressConfig)
+		direct  *string // exit of the direct client
+		proxied *string // exit of the proxied client
+		failing []string
+	}{
+		{"both allowed", nil, str(`{"ip": "198.51.100.1", "country": "CA"}`), str(canadian), nil},
+		{"proxy falls back to our IP", nil, str(`{"ip": "198.51.100.1", "country": "CA"}`), str(`{"ip": "198.51.100.1", "country": "CA"}`), []string{"proxied"}},
+		{"exit shared with the direct client", nil, str(canadian), str(canadian), []string{"proxied"}},
+		{"wrong country", nil, str(`{"ip": "198.51.100.1", "country": "CA"}`), str(`{"ip": "203.0.113.9", "country": "US"}`), []string{"proxied"}},
+		{"country unknown", nil, str(`{"ip": "198.51.100.1", "country": "CA"}`), str("203.0.113.9"), []string{"proxied"}},
+		{"any country", func(c *config.EgressConfig) { c.AllowCountries = []string{"*"} }, str(`{"ip": "198.51.100.1", "country": "CA"}`), str("203.0.113.9"), nil},
+		{"lookup fails", nil, str(`{"ip": "198.51.100.1", "country": "CA"}`), nil, []string{"proxied"}},
+		{"both fail", nil, nil, nil, []string{"direct", "proxied"}},
+	}
+	for _, c := range cases {
+		cfg := cfg
+		if c.cfg != nil {
+			c.cfg(&cfg)
+		}
+		checker := NewEgressChecker(cfg)
+		checker.AddClient("direct", echoClient(c.direct, nil), true, "")
+		checker.AddClient("proxied", echoClient(c.proxied, nil), false, "direct")
+
+		var failing []string
+		for _, r := range checker.Check(context.Background()) {
+			if r.Err != nil {
+				failing = append(failing, r.Client)
+			}
+		}
+		if strings.Join(failing, ",") != strings.Join(c.failing, ",") {
+			t.Errorf("%s: expected %v to fail, got %v", c.name, c.failing, failing)
+		}
+		if err := checker.Verify(context.Background()); (err != nil) != (len(c.failing) > 0) {
+			t.Errorf("%s: Verify returned %v", c.name, err)
+		}
+	}
+}
+
+func TestEgressCheckerCache(t *testing.T) {
+	body := str(`{"ip": "203.0.113.5", "country": "CA"}`)
+	calls := 0
+	checker := NewEgressChecker(config.EgressConfig{Enabled: true, EchoURL: "https://echo.example.com/json", CacheTTL: time.Hour})
+	checker.AddClient("scraping", echoClient(body, &calls), false, "")
+
+	for i := 0; i < 3; i++ {
+		if err := checker.Verify(context.Background()); err != nil {
+			t.Fatalf("verify: %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expected a passing check to be cached, got %d lookups", calls)
+	}
+
+	// A new exit (VPN rotation) drops the cached pass
+	checker.Invalidate()
+	if err := checker.Verify(context.Background()); err != nil || calls != 2 {
+		t.Fatalf("expected a fresh lookup after Invalidate, got %d lookups, %v", calls, err)
+	}
+
+	// A failing check is not cached: the next run checks again
+	failing := NewEgressChecker(config.EgressConfig{Enabled: true, EchoURL: "https://echo.example.com/json", CacheTTL: time.Hour})
+	calls = 0
+	failing.AddClient("scraping", echoClient(nil, &calls), false, "")
+	for i := 0; i < 2; i++ {
+		if err := failing.Verify(context.Background()); err == nil {
+			t.Fatalf("expected a failed lookup to fail the check")
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("expected every run to retry a failed check, got %d lookups", calls)
+	}
+}
+
+func TestEgressCheckerDisabled(t *testing.T) {
+	if c := NewEgressChecker(config.EgressConfig{EchoURL: "https://echo.example.com/json"}); c != nil {
+		t.Fatalf("expected no checker when disabled")
+	}
+	var c *EgressChecker
+	c.Invalidate()
+	if err := c.Verify(context.Background()); err != nil || c.Check(context.Background()) != nil {
+		t.Fatalf("nil checker should pass everything")
+	}
+}
@@ -0,0 +1,124 @@
+package httputil
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+
+	"tct_scrooper/config"
+)
+
+// Fingerprints assigns fingerprint profiles to sessions (an HTTP client, a
+// site's browser profile), spreading sessions over the profiles. A session
+// keeps its profile until it gets blocked, then moves to the next. A nil
+// Fingerprints assigns nothing.
+type Fingerprints struct {
+	mu       sync.Mutex
+	profiles []config.Fingerprint
+	sessions map[string]int // session -> index into profiles
+	next     int
+}
+
+func NewFingerprints(profiles []config.Fingerprint) *Fingerprints {
+	if len(profiles) == 0 {
+		profiles = []config.Fingerprint{config.DefaultFingerprint}
+	}
+	return &Fingerprints{profiles: profiles, sessions: make(map[string]int)}
+}
+
+// For returns the session's profile, assigning one on first use
+func (f *Fingerprints) For(session string) (config.Fingerprint, bool) {
+	if f == nil {
+		return config.Fingerprint{}, false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i, ok := f.sessions[session]
+	if !ok {
+		i = f.next % len(f.profiles)
+		f.next++
+		f.sessions[session] = i
+	}
+	return f.profiles[i], true
+}
+
+// Rotate moves the session off profile name after a block. It does nothing
+// if the session has moved on already, so concurrent blocks rotate once.
+func (f *Fingerprints) Rotate(session, name string) {
+	if f == nil || len(f.profiles) < 2 {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i, ok := f.sessions[session]
+	if !ok || f.profiles[i].Name != name {
+		return
+	}
+	next := (i + 1) % len(f.profiles)
+	f.sessions[session] = next
+	log.Printf("Fingerprint: %s blocked as %s, switching to %s", session, name, f.profiles[next].Name)
+}
+
+// ApplyFingerprint sets the fingerprint's headers on h, leaving those the
+// caller set already
+func ApplyFingerprint(h http.Header, fp config.Fingerprint) {
+	setDefault := func(key, value string) {
+		if value != "" && h.Get(key) == "" {
+			h.Set(key, value)
+		}
+	}
+	setDefault("User-Agent", fp.UserAgent)
+	setDefault("Accept-Language", fp.AcceptLanguage)
+	setDefault("Sec-CH-UA", fp.ClientHints.UA)
+	setDefault("Sec-CH-UA-Mobile", fp.ClientHints.Mobile)
+	setDefault("Sec-CH-UA-Platform", fp.ClientHints.Platform)
+}
+
+type fingerprintKey struct{}
+
+// FingerprintFrom names the fingerprint a request was sent with, from the
+// request's (or its response's request's) context; "" if none
+func FingerprintFrom(ctx context.Context) string {
+	name, _ := ctx.Value(fingerprintKey{}).(string)
+	return name
+}
+
+// fingerprintTag is " as <fingerprint>" for log lines, or ""
+func fingerprintTag(ctx context.Context) string {
+	if name := FingerprintFrom(ctx); name != "" {
+		return " as " + name
+	}
+	return ""
+}
+
+// Transport sends each request with the session's fingerprint headers and
+// records the fingerprint on the request's context. A block (401/403/429)
+// moves the session to another fingerprint for its next requests.
+func (f *Fingerprints) Transport(session string, base http.RoundTripper) http.RoundTripper {
+	if f == nil {
+		return base
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &fingerprintTransport{fingerprints: f, session: session, base: base}
+}
+
+type fingerprintTransport struct {
+	fingerprints *Fingerprints
+	session      string
+	base         http.RoundTripper
+}
+
+func (t *fingerprintTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	fp, _ := t.fingerprints.For(t.session)
+	req = req.Clone(context.WithValue(req.Context(), fingerprintKey{}, fp.Name))
+	ApplyFingerprint(req.Header, fp)
+
+	resp, err := t.base.RoundTrip(req)
+	if Classify(resp, err) == ClassBlocked || (resp != nil && resp.StatusCode == http.StatusTooManyRequests) {
+		t.fingerprints.Rotate(t.session, fp.Name)
+	}
+	return resp, err
+}
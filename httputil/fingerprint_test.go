@@ -0,0 +1,151 @@
+package httputil
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"tct_scrooper/config"
+)
+
+var testFingerprints = []config.Fingerprint{
+	{Name: "chrome-win", UserAgent: "agent-1", AcceptLanguage: "en-CA", ClientHints: config.ClientHints{Platform: `"Windows"`}},
+	{Name: "chrome-mac", UserAgent: "agent-2"},
+	{Name: "firefox", UserAgent: "agent-3"},
+}
+
+func TestFingerprintsAssign(t *testing.T) {
+	f := NewFingerprints(testFingerprints)
+
+	cases := []struct {
+		session string
+		want    string
+	}{
+		{"api", "chrome-win"},
+		{"browser:realtor_ca", "chrome-mac"},
+		{"enrichment", "firefox"},
+		{"browser:remax", "chrome-win"}, // wraps around
+		{"api", "chrome-win"},           // sessions keep their profile
+		{"browser:realtor_ca", "chrome-mac"},
+	}
+	for _, c := range cases {
+		if fp, ok := f.For(c.session); !ok || fp.Name != c.want {
+			t.Errorf("%s: expected %s, got %s", c.session, c.want, fp.Name)
+		}
+	}
+
+	if fp, _ := NewFingerprints(nil).For("api"); fp.Name != config.DefaultFingerprint.Name {
+		t.Fatalf("expected the default fingerprint without profiles, got %s", fp.Name)
+	}
+}
+
+func TestFingerprintsRotate(t *testing.T) {
+	f := NewFingerprints(testFingerprints)
+	f.For("api")
+
+	cases := []struct {
+		name    string
+		session string
+		blocked string // the profile the block was seen as
+		want    string
+	}{
+		{"block moves to the next profile", "api", "chrome-win", "chrome-mac"},
+		{"a late block as the old profile is ignored", "api", "chrome-win", "chrome-mac"},
+		{"next block moves on again", "api", "chrome-mac", "firefox"},
+		{"wraps around", "api", "firefox", "chrome-win"},
+		{"unassigned session is left alone", "other", "chrome-win", "chrome-mac"},
+	}
+	for _, c := range cases {
+		f.Rotate(c.session, c.blocked)
+		if fp, _ := f.For(c.session); fp.Name != c.want {
+			t.Errorf("%s: expected %s, got %s", c.name, c.want, fp.Name)
+		}
+	}
+
+	single := NewFingerprints(testFingerprints[:1])
+	single.For("api")
+	single.Rotate("api", "chrome-win")
+	if fp, _ := single.For("api"); fp.Name != "chrome-win" {
+		t.Fatalf("expected a single profile never to rotate, got %s", fp.Name)
+	}
+}
+
+func TestApplyFingerprint(t *testing.T) {
+	h := make(http.Header)
+	h.Set("User-Agent", "caller")
+	ApplyFingerprint(h, testFingerprints[0])
+
+	want := map[string]string{
+		"User-Agent":         "caller",
+		"Accept-Language":    "en-CA",
+		"Sec-CH-UA-Platform": `"Windows"`,
+		"Sec-CH-UA":          "",
+	}
+	for key, value := range want {
+		if got := h.Get(key); got != value {
+			t.Errorf("%s: expected %q, got %q", key, value, got)
+		}
+	}
+}
+
+func TestFingerprintTransport(t *testing.T) {
+	f := NewFingerprints(testFingerprints)
+	status := http.StatusOK
+	var agents, names []string
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		agents = append(agents, req.Header.Get("User-Agent"))
+		names = append(names, FingerprintFrom(req.Context()))
+		resp := response(status)
+		resp.Request = req
+		return resp, nil
+	})
+	client := &http.Client{Transport: f.Transport("api", base)}
+
+	get := func() *http.Response {
+		t.Helper()
+		resp, err := client.Get("https://api.example.com/search")
+		if err != nil {
+			t.Fatalf("request: %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	resp := get()
+	get()
+	if FingerprintFrom(resp.Request.Context()) != "chrome-win" {
+		t.Fatalf("expected the response's request to carry the fingerprint")
+	}
+	status = http.StatusForbidden
+	get()
+	status = http.StatusTooManyRequests
+	get()
+	status = http.StatusNotFound
+	get()
+
+	wantAgents := []string{"agent-1", "agent-1", "agent-1", "agent-2", "agent-3"}
+	wantNames := []string{"chrome-win", "chrome-win", "chrome-win", "chrome-mac", "firefox"}
+	for i := range wantAgents {
+		if i >= len(agents) || agents[i] != wantAgents[i] || names[i] != wantNames[i] {
+			t.Fatalf("expected %v as %v, got %v as %v", wantAgents, wantNames, agents, names)
+		}
+	}
+	if fp, _ := f.For("api"); fp.Name != "firefox" {
+		t.Fatalf("expected a 404 not to rotate, got %s", fp.Name)
+	}
+}
+
+func TestNilFingerprints(t *testing.T) {
+	var f *Fingerprints
+	if _, ok := f.For("api"); ok {
+		t.Fatalf("nil fingerprints should assign nothing")
+	}
+	f.Rotate("api", "chrome-win")
+	base := &http.Transport{}
+	if f.Transport("api", base) != base {
+		t.Fatalf("nil fingerprints should not wrap the transport")
+	}
+	if FingerprintFrom(context.Background()) != "" || fingerprintTag(context.Background()) != "" {
+		t.Fatalf("expected no fingerprint on a plain context")
+	}
+}
@@ -0,0 +1,297 @@
+package httputil
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"tct_scrooper/challenge"
+	"tct_scrooper/models"
+)
+
+const (
+	// proxyCooldown is how long a proxy is rotated out after a block, doubled
+	// for each further block in a row up to proxyMaxCooldown
+	proxyCooldown    = 5 * time.Minute
+	proxyMaxCooldown = 2 * time.Hour
+
+	// proxyLatencyRef is the latency at which a proxy's score is halved
+	proxyLatencyRef = 2 * time.Second
+)
+
+// ProxyPool spreads requests over several proxies, preferring those with the
+// best success, block and latency record. A proxy that gets blocked is
+// rotated out for a cooldown. Browser sessions, whose cookies are tied to an
+// IP, hold one proxy per key (Sticky) until it is blocked. A nil or empty
+// pool sends requests directly.
+type ProxyPool struct {
+	mu      sync.Mutex
+	proxies []*Proxy
+	sticky  map[string]*Proxy
+	tracker *challenge.Tracker
+}
+
+// Proxy is one endpoint in the pool; its counters are guarded by the pool
+type Proxy struct {
+	URL   *url.URL
+	Label string // user@host:port, safe to log
+
+	requests          int64
+	successes         int64
+	blocks            int64
+	failures          int64
+	latency           time.Duration // moving average of answered requests
+	consecutiveBlocks int
+	cooldownUntil     time.Time
+}
+
+func NewProxyPool(urls []string) (*ProxyPool, error) {
+	pool := &ProxyPool{sticky: make(map[string]*Proxy)}
+	seen := make(map[string]bool)
+	for _, raw := range urls {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, err
+		}
+		if seen[u.String()] {
+			continue
+		}
+		seen[u.String()] = true
+
+		label := u.Host
+		if u.User != nil {
+			label = u.User.Username() + "@" + u.Host
+		}
+		pool.proxies = append(pool.proxies, &Proxy{URL: u, Label: label})
+	}
+	return pool, nil
+}
+
+// SetTracker makes the pool's transport count the blocks it sees per host
+// and proxy
+func (pp *ProxyPool) SetTracker(t *challenge.Tracker) {
+	if pp != nil {
+		pp.tracker = t
+	}
+}
+
+// Len is the number of proxies in the pool
+func (pp *ProxyPool) Len() int {
+	if pp == nil {
+		return 0
+	}
+	return len(pp.proxies)
+}
+
+// Proxies returns every proxy in the pool, in configuration order
+func (pp *ProxyPool) Proxies() []*Proxy {
+	if pp == nil {
+		return nil
+	}
+	return pp.proxies
+}
+
+// Client is a plain client that always goes through p, for checks that must
+// reach every proxy rather than a picked one. Its requests are not reported
+// to the pool.
+func (p *Proxy) Client(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: &http.Transport{Proxy: http.ProxyURL(p.URL)}}
+}
+
+// Pick chooses a proxy for one request: a random one weighted by score among
+// those not cooling down, or the one whose cooldown ends first if all are
+func (pp *ProxyPool) Pick() *Proxy {
+	if pp.Len() == 0 {
+		return nil
+	}
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	return pp.pickLocked(time.Now(), nil)
+}
+
+func (pp *ProxyPool) pickLocked(now time.Time, avoid *Proxy) *Proxy {
+	var candidates []*Proxy
+	var total float64
+	for _, p := range pp.proxies {
+		if p != avoid && !now.Before(p.cooldownUntil) {
+			candidates = append(candidates, p)
+			total += p.score()
+		}
+	}
+
+	if len(candidates) == 0 {
+		var soonest *Proxy
+		for _, p := range pp.proxies {
+			if p == avoid && len(pp.proxies) > 1 {
+				continue
+			}
+			if soonest == nil || p.cooldownUntil.Before(soonest.cooldownUntil) {
+				soonest = p
+			}
+		}
+		return soonest
+	}
+
+	r := rand.Float64() * total
+	for _, p := range candidates {
+		if r -= p.score(); r < 0 {
+			return p
+		}
+	}
+	return candidates[len(candidates)-1]
+}
+
+// Sticky returns the proxy held by key (a site ID), picking one if key holds
+// none or its proxy has since been blocked
+func (pp *ProxyPool) Sticky(key string) *Proxy {
+	if pp.Len() == 0 {
+		return nil
+	}
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+
+	now := time.Now()
+	if p := pp.sticky[key]; p != nil && !now.Before(p.cooldownUntil) {
+		return p
+	}
+	p := pp.pickLocked(now, pp.sticky[key])
+	pp.sticky[key] = p
+	log.Printf("Proxy pool: %s session now on %s", key, p.Label)
+	return p
+}
+
+// Report records the outcome of a request through p. ClassBlocked rotates
+// p out for a cooldown and releases it from the sessions holding it;
+// ClassRetryable counts as a proxy failure; any other answer counts as a
+// success, since the proxy delivered it.
+func (pp *ProxyPool) Report(p *Proxy, class ErrorClass, latency time.Duration) {
+	if pp == nil || p == nil {
+		return
+	}
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+
+	p.requests++
+	switch class {
+	case ClassBlocked:
+		p.blocks++
+		p.consecutiveBlocks++
+		cooldown := proxyCooldown << (p.consecutiveBlocks - 1)
+		if cooldown > proxyMaxCooldown || cooldown <= 0 {
+			cooldown = proxyMaxCooldown
+		}
+		p.cooldownUntil = time.Now().Add(cooldown)
+		for key, held := range pp.sticky {
+			if held == p {
+				delete(pp.sticky, key)
+			}
+		}
+		log.Printf("Proxy pool: %s blocked (%d in a row), rotated out for %v", p.Label, p.consecutiveBlocks, cooldown)
+		return
+	case ClassRetryable:
+		p.failures++
+		return
+	}
+
+	p.successes++
+	p.consecutiveBlocks = 0
+	if latency > 0 {
+		if p.latency == 0 {
+			p.latency = latency
+		} else {
+			p.latency = (p.latency*4 + latency) / 5
+		}
+	}
+}
+
+// score weighs a proxy for Pick: its smoothed success rate, discounted by its
+// block rate and latency. A new proxy starts at 0.5 so it gets tried.
+func (p *Proxy) score() float64 {
+	n := float64(p.requests + 2)
+	success := float64(p.successes+1) / n
+	block := float64(p.blocks) / n
+	latency := 1.0
+	if p.latency > 0 {
+		latency = 1 / (1 + float64(p.latency)/float64(proxyLatencyRef))
+	}
+	return success * (1 - block) * latency
+}
+
+// Stats returns the current counters and score of every proxy
+func (pp *ProxyPool) Stats() []models.ProxyStat {
+	if pp == nil {
+		return nil
+	}
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+
+	now := time.Now()
+	stats := make([]models.ProxyStat, 0, len(pp.proxies))
+	for _, p := range pp.proxies {
+		st := models.ProxyStat{
+			Proxy:        p.Label,
+			Requests:     p.requests,
+			Successes:    p.successes,
+			Blocks:       p.blocks,
+			Failures:     p.failures,
+			AvgLatencyMS: int(p.latency / time.Millisecond),
+			Score:        p.score(),
+		}
+		if now.Before(p.cooldownUntil) {
+			until := p.cooldownUntil
+			st.CooldownUntil = &until
+		}
+		stats = append(stats, st)
+	}
+	return stats
+}
+
+type proxyKey struct{}
+
+// Transport sends each request through a proxy picked from the pool and
+// reports its outcome; 429 counts as a block, as it is the IP being limited.
+// base's Proxy setting is replaced. With no proxies base is used as is.
+func (pp *ProxyPool) Transport(base *http.Transport) http.RoundTripper {
+	if pp.Len() == 0 {
+		return base
+	}
+	t := base.Clone()
+	t.Proxy = func(req *http.Request) (*url.URL, error) {
+		if p, ok := req.Context().Value(proxyKey{}).(*Proxy); ok {
+			return p.URL, nil
+		}
+		return nil, fmt.Errorf("proxy pool: request has no proxy")
+	}
+	return &proxyTransport{pool: pp, base: t}
+}
+
+type proxyTransport struct {
+	pool *ProxyPool
+	base http.RoundTripper
+}
+
+func (t *proxyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	p := t.pool.Pick()
+	req = req.WithContext(context.WithValue(req.Context(), proxyKey{}, p))
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil && req.Context().Err() != nil {
+		return resp, err // cancelled by the caller, not the proxy's fault
+	}
+
+	class := Classify(resp, err)
+	if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+		class = ClassBlocked
+	}
+	t.pool.Report(p, class, time.Since(start))
+	if class == ClassBlocked {
+		t.pool.tracker.Record(req.URL.Hostname(), p.Label, challenge.KindHTTP)
+	}
+	return resp, err
+}
@@ -0,0 +1,154 @@
+package httputil
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"tct_scrooper/config"
+)
+
+// HostLimiter is a token-bucket rate limiter keyed by host. One limiter is
+// shared by every client that talks to target sites, so handlers and workers
+// together stay within each host's rate. A nil limiter allows everything.
+type HostLimiter struct {
+	cfg  config.RateLimitConfig
+	gate Gate
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	interval time.Duration
+	burst    float64
+	jitter   time.Duration
+	tokens   float64
+	last     time.Time
+}
+
+// Gate can hold back every request to target sites, e.g. the VPN kill
+// switch while the tunnel is down
+type Gate interface {
+	Wait(ctx context.Context) error
+}
+
+func NewHostLimiter(cfg config.RateLimitConfig) *HostLimiter {
+	return &HostLimiter{cfg: cfg, buckets: make(map[string]*bucket)}
+}
+
+// SetGate makes every Wait pass gate first; set it before the limiter is used
+func (l *HostLimiter) SetGate(gate Gate) {
+	l.gate = gate
+}
+
+// Wait blocks until a request to host may be sent or ctx is done
+func (l *HostLimiter) Wait(ctx context.Context, host string) error {
+	if l == nil {
+		return nil
+	}
+	if l.gate != nil {
+		if err := l.gate.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	if host == "" {
+		return nil
+	}
+	d := l.reserve(strings.ToLower(host), time.Now())
+	if d <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WaitURL waits for the host of rawURL, for requests made on our behalf by a
+// third party (ScrapingBee) or a browser
+func (l *HostLimiter) WaitURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	return l.Wait(ctx, u.Hostname())
+}
+
+// reserve takes a token from the host's bucket and returns how long the
+// caller must wait for it. Tokens may go negative: each waiting caller holds
+// a later slot, so concurrent callers are spaced out rather than released
+// together.
+func (l *HostLimiter) reserve(host string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.buckets[host]
+	if b == nil {
+		rate, ok := l.cfg.Hosts[host]
+		if !ok {
+			rate = l.cfg.Default
+		}
+		burst := rate.Burst
+		if burst < 1 {
+			burst = 1
+		}
+		b = &bucket{
+			interval: time.Duration(rate.IntervalMS) * time.Millisecond,
+			burst:    float64(burst),
+			jitter:   time.Duration(rate.JitterMS) * time.Millisecond,
+			tokens:   float64(burst),
+			last:     now,
+		}
+		l.buckets[host] = b
+	}
+
+	var wait time.Duration
+	if b.interval > 0 {
+		b.tokens += float64(now.Sub(b.last)) / float64(b.interval)
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+		b.tokens--
+		if b.tokens < 0 {
+			wait = time.Duration(-b.tokens * float64(b.interval))
+		}
+	}
+	if b.jitter > 0 {
+		wait += time.Duration(rand.Int63n(int64(b.jitter)))
+	}
+	return wait
+}
+
+// Transport wraps base so every request first waits for its host
+func (l *HostLimiter) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if l == nil {
+		return base
+	}
+	return &limitedTransport{limiter: l, base: base}
+}
+
+type limitedTransport struct {
+	limiter *HostLimiter
+	base    http.RoundTripper
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context(), req.URL.Hostname()); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
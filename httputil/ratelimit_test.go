@@ -0,0 +1,123 @@
+package httputil
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"tct_scrooper/config"
+)
+
+func TestHostLimiterReserve(t *testing.T) {
+	l := NewHostLimiter(config.RateLimitConfig{
+		Default: config.HostRate{IntervalMS: 1000},
+		Hosts: map[string]config.HostRate{
+			"burst.example.com": {IntervalMS: 1000, Burst: 3},
+			"free.example.com":  {},
+		},
+	})
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name string
+		host string
+		at   time.Duration // after now
+		want time.Duration
+	}{
+		{"first request is free", "default.example.com", 0, 0},
+		{"second waits an interval", "default.example.com", 0, time.Second},
+		{"concurrent callers queue up", "default.example.com", 0, 2 * time.Second},
+		{"waiting refills the bucket", "default.example.com", 3 * time.Second, 0},
+		{"hosts have their own buckets", "other.example.com", 3 * time.Second, 0},
+		{"burst 1", "burst.example.com", 0, 0},
+		{"burst 2", "burst.example.com", 0, 0},
+		{"burst 3", "burst.example.com", 0, 0},
+		{"past the burst", "burst.example.com", 0, time.Second},
+		{"refill is capped at the burst", "burst.example.com", time.Hour, 0},
+		{"unlimited host", "free.example.com", 0, 0},
+		{"unlimited host again", "free.example.com", 0, 0},
+	}
+	for _, c := range cases {
+		if got := l.reserve(c.host, now.Add(c.at)); got != c.want {
+			t.Errorf("%s: expected wait %v, got %v", c.name, c.want, got)
+		}
+	}
+
+	// After an hour idle the burst host holds 3 tokens, not 3600
+	at := now.Add(time.Hour)
+	for i := 0; i < 2; i++ {
+		l.reserve("burst.example.com", at)
+	}
+	if got := l.reserve("burst.example.com", at); got != time.Second {
+		t.Fatalf("expected the refilled burst to run out after 3 requests, got wait %v", got)
+	}
+}
+
+func TestHostLimiterJitter(t *testing.T) {
+	l := NewHostLimiter(config.RateLimitConfig{Default: config.HostRate{JitterMS: 50}})
+	now := time.Now()
+	for i := 0; i < 20; i++ {
+		if got := l.reserve("example.com", now); got < 0 || got >= 50*time.Millisecond {
+			t.Fatalf("expected jitter under 50ms, got %v", got)
+		}
+	}
+}
+
+// blockingGate is a kill switch that is engaged until opened
+type blockingGate struct{ open chan struct{} }
+
+func (g *blockingGate) Wait(ctx context.Context) error {
+	select {
+	case <-g.open:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestHostLimiterGate(t *testing.T) {
+	var sent int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent++
+	}))
+	defer srv.Close()
+
+	gate := &blockingGate{open: make(chan struct{})}
+	l := NewHostLimiter(config.RateLimitConfig{})
+	l.SetGate(gate)
+	client := &http.Client{Transport: l.Transport(nil)}
+
+	// While the kill switch is engaged nothing goes out
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL, nil)
+	if _, err := client.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the request to be held until its deadline, got %v", err)
+	}
+	if sent != 0 {
+		t.Fatalf("expected no request while the gate is closed, %d sent", sent)
+	}
+
+	close(gate.open)
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("request after the gate opened: %v", err)
+	}
+	resp.Body.Close()
+	if sent != 1 {
+		t.Fatalf("expected the request to go out once the gate opened, %d sent", sent)
+	}
+}
+
+func TestNilHostLimiter(t *testing.T) {
+	var l *HostLimiter
+	if err := l.Wait(context.Background(), "example.com"); err != nil {
+		t.Fatalf("nil limiter should allow everything: %v", err)
+	}
+	if l.Transport(nil) != http.DefaultTransport {
+		t.Fatalf("nil limiter should not wrap the transport")
+	}
+}
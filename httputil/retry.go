@@ -0,0 +1,293 @@
+package httputil
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrorClass says what a failed request means for retrying it
+type ErrorClass int
+
+const (
+	ClassOK        ErrorClass = iota // 2xx/3xx
+	ClassRetryable                   // network error, 408, 429, 5xx: may succeed later
+	ClassBlocked                     // 401/403: refused by the site's bot protection; retrying won't help
+	ClassGone                        // 404/410: the resource no longer exists
+	ClassClient                      // any other 4xx: the request itself is wrong
+)
+
+func (c ErrorClass) String() string {
+	switch c {
+	case ClassOK:
+		return "ok"
+	case ClassRetryable:
+		return "retryable"
+	case ClassBlocked:
+		return "blocked"
+	case ClassGone:
+		return "gone"
+	case ClassClient:
+		return "client error"
+	}
+	return "unknown"
+}
+
+// Classify sorts the outcome of a request; transport errors are retryable
+// (the caller's context decides whether there is time for another attempt)
+func Classify(resp *http.Response, err error) ErrorClass {
+	if err != nil {
+		return ClassRetryable
+	}
+	return ClassifyStatus(resp.StatusCode)
+}
+
+// ClassifyStatus sorts a response status code
+func ClassifyStatus(code int) ErrorClass {
+	switch {
+	case code < 400:
+		return ClassOK
+	case code == http.StatusRequestTimeout, code == http.StatusTooManyRequests, code >= 500:
+		return ClassRetryable
+	case code == http.StatusUnauthorized, code == http.StatusForbidden:
+		return ClassBlocked
+	case code == http.StatusNotFound, code == http.StatusGone:
+		return ClassGone
+	}
+	return ClassClient
+}
+
+// RetryPolicy is how a client retries retryable failures: exponential
+// backoff from BaseDelay, capped at MaxDelay, with jitter. A Retry-After on
+// 429/503 replaces the backoff unless it is longer than MaxRetryAfter, in
+// which case the response is returned as is.
+type RetryPolicy struct {
+	Name          string // shown in retry logs
+	MaxAttempts   int    // including the first; <= 1 disables retries
+	BaseDelay     time.Duration
+	MaxDelay      time.Duration
+	MaxRetryAfter time.Duration
+
+	// RetryUnsafe also retries non-idempotent requests (POST) after network
+	// errors and 5xx. Without it they are only retried on 429/503, which
+	// mean the server did not process them.
+	RetryUnsafe bool
+
+	// AttemptTimeout limits each attempt, reading its body included; 0 for
+	// none. Retried clients use it instead of http.Client.Timeout, which
+	// covers all attempts and their waits together.
+	AttemptTimeout time.Duration
+}
+
+// WithAttemptTimeout is the policy with each attempt limited to d
+func (p RetryPolicy) WithAttemptTimeout(d time.Duration) RetryPolicy {
+	p.AttemptTimeout = d
+	return p
+}
+
+var (
+	// APIRetry is for service APIs (Apify)
+	APIRetry = RetryPolicy{
+		Name:           "api",
+		MaxAttempts:    4,
+		BaseDelay:      2 * time.Second,
+		MaxDelay:       30 * time.Second,
+		MaxRetryAfter:  2 * time.Minute,
+		AttemptTimeout: 60 * time.Second,
+	}
+
+	// ScrapingRetry is for target sites; blocks are never retried here
+	ScrapingRetry = RetryPolicy{
+		Name:           "scraping",
+		MaxAttempts:    3,
+		BaseDelay:      2 * time.Second,
+		MaxDelay:       20 * time.Second,
+		MaxRetryAfter:  time.Minute,
+		AttemptTimeout: 30 * time.Second,
+	}
+
+	// MediaRetry is for photo downloads from CDNs
+	MediaRetry = RetryPolicy{
+		Name:           "media",
+		MaxAttempts:    3,
+		BaseDelay:      time.Second,
+		MaxDelay:       10 * time.Second,
+		MaxRetryAfter:  30 * time.Second,
+		AttemptTimeout: 60 * time.Second,
+	}
+)
+
+// Transport wraps base so requests are retried according to the policy.
+// Wrap it around the rate limited transport so every attempt waits its turn.
+func (p RetryPolicy) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if p.MaxAttempts <= 1 && p.AttemptTimeout <= 0 {
+		return base
+	}
+	return &retryTransport{policy: p, base: base}
+}
+
+type retryTransport struct {
+	policy RetryPolicy
+	base   http.RoundTripper
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	p := t.policy
+
+	for attempt := 1; ; attempt++ {
+		attemptReq := req
+		if attempt > 1 {
+			var err error
+			if attemptReq, err = rewind(req); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := t.attempt(attemptReq)
+		class := Classify(resp, err)
+		if class != ClassRetryable || attempt >= p.MaxAttempts || ctx.Err() != nil {
+			return resp, err
+		}
+		if !idempotent(req) && !p.RetryUnsafe && !notProcessed(resp) {
+			return resp, err
+		}
+		if req.Body != nil && req.GetBody == nil {
+			return resp, err // body can't be replayed
+		}
+
+		wait := p.backoff(attempt)
+		reason := ""
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Status
+			if after, ok := retryAfter(resp); ok {
+				if after > p.MaxRetryAfter {
+					log.Printf("HTTP %s: %s %s%s: %s, Retry-After %v exceeds %v, not retrying",
+						p.Name, req.Method, req.URL.Host, fingerprintTag(ctx), reason, after, p.MaxRetryAfter)
+					return resp, nil
+				}
+				wait = after
+				reason += fmt.Sprintf(" (Retry-After %v)", after)
+			}
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(deadline) {
+			return resp, err
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+			resp.Body.Close()
+		}
+		log.Printf("HTTP %s: %s %s%s%s attempt %d/%d failed: %s; retrying in %v",
+			p.Name, req.Method, req.URL.Host, req.URL.Path, fingerprintTag(ctx), attempt, p.MaxAttempts, reason, wait.Round(time.Millisecond))
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt sends one attempt under the policy's AttemptTimeout, which runs
+// until the response body is closed
+func (t *retryTransport) attempt(req *http.Request) (*http.Response, error) {
+	if t.policy.AttemptTimeout <= 0 {
+		return t.base.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), t.policy.AttemptTimeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose ends an attempt's timeout when its body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// backoff is the wait after the given failed attempt: BaseDelay doubled per
+// attempt up to MaxDelay, randomized to between half and all of it
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay << (attempt - 1)
+	if d > p.MaxDelay || d <= 0 {
+		d = p.MaxDelay
+	}
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + time.Duration(rand.Int63n(int64(half)))
+}
+
+// retryAfter reads Retry-After (seconds or an HTTP date) from a 429 or 503
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// notProcessed reports a response that says the server turned the request
+// away without acting on it
+func notProcessed(resp *http.Response) bool {
+	return resp != nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable)
+}
+
+// rewind clones req with a fresh body for another attempt
+func rewind(req *http.Request) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = body
+	}
+	return r, nil
+}
@@ -0,0 +1,257 @@
+package httputil
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func response(status int, header ...string) *http.Response {
+	resp := &http.Response{StatusCode: status, Status: http.StatusText(status), Header: make(http.Header), Body: io.NopCloser(strings.NewReader(""))}
+	for i := 0; i+1 < len(header); i += 2 {
+		resp.Header.Set(header[i], header[i+1])
+	}
+	return resp
+}
+
+var testRetry = RetryPolicy{
+	Name:          "test",
+	MaxAttempts:   3,
+	BaseDelay:     time.Millisecond,
+	MaxDelay:      2 * time.Millisecond,
+	MaxRetryAfter: time.Second,
+}
+
+func TestRetryTransport(t *testing.T) {
+	unsafe := testRetry
+	unsafe.RetryUnsafe = true
+
+	cases := []struct {
+		name      string
+		policy    RetryPolicy
+		method    string
+		responses []*http.Response // a nil entry is a network error
+		attempts  int
+		status    int // 0 for the network error
+	}{
+		{"recovers from 5xx", testRetry, "GET", []*http.Response{response(500), response(502), response(200)}, 3, 200},
+		{"gives up after max attempts", testRetry, "GET", []*http.Response{response(500), response(500), response(500)}, 3, 500},
+		{"retries network errors", testRetry, "GET", []*http.Response{nil, response(200)}, 2, 200},
+		{"never retries a block", testRetry, "GET", []*http.Response{response(403)}, 1, 403},
+		{"never retries not found", testRetry, "GET", []*http.Response{response(404)}, 1, 404},
+		{"POST not replayed after 5xx", testRetry, "POST", []*http.Response{response(500)}, 1, 500},
+		{"POST not replayed after network error", testRetry, "POST", []*http.Response{nil}, 1, 0},
+		{"POST replayed after 429", testRetry, "POST", []*http.Response{response(429), response(200)}, 2, 200},
+		{"POST replayed after 503", testRetry, "POST", []*http.Response{response(503), response(200)}, 2, 200},
+		{"RetryUnsafe replays POST after 5xx", unsafe, "POST", []*http.Response{response(500), response(200)}, 2, 200},
+		{"Retry-After within limit", testRetry, "GET", []*http.Response{response(429, "Retry-After", "0"), response(200)}, 2, 200},
+		{"Retry-After over the limit", testRetry, "GET", []*http.Response{response(503, "Retry-After", "120"), response(200)}, 1, 503},
+		{"Retry-After ignored on 5xx other than 503", testRetry, "GET", []*http.Response{response(500, "Retry-After", "120"), response(200)}, 2, 200},
+	}
+	for _, c := range cases {
+		attempts := 0
+		var bodies []string
+		base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			attempts++
+			if req.Body != nil {
+				data, _ := io.ReadAll(req.Body)
+				bodies = append(bodies, string(data))
+			}
+			if resp := c.responses[attempts-1]; resp != nil {
+				return resp, nil
+			}
+			return nil, errors.New("connection reset")
+		})
+
+		var body io.Reader
+		if c.method == "POST" {
+			body = strings.NewReader(`{"page":1}`)
+		}
+		req, _ := http.NewRequest(c.method, "https://api.example.com/search", body)
+		resp, err := c.policy.Transport(base).RoundTrip(req)
+
+		if attempts != c.attempts {
+			t.Errorf("%s: expected %d attempts, got %d", c.name, c.attempts, attempts)
+		}
+		if c.status == 0 {
+			if err == nil {
+				t.Errorf("%s: expected the network error", c.name)
+			}
+			continue
+		}
+		if err != nil || resp.StatusCode != c.status {
+			t.Errorf("%s: expected status %d, got %v %v", c.name, c.status, resp, err)
+			continue
+		}
+		for i, b := range bodies {
+			if b != `{"page":1}` {
+				t.Errorf("%s: attempt %d sent body %q, want the original", c.name, i+1, b)
+			}
+		}
+	}
+}
+
+func TestRetryTransportUnreplayableBody(t *testing.T) {
+	attempts := 0
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		attempts++
+		return response(503), nil
+	})
+	req, _ := http.NewRequest("PUT", "https://api.example.com/item", io.NopCloser(strings.NewReader("x")))
+	req.GetBody = nil
+	resp, err := testRetry.Transport(base).RoundTrip(req)
+	if err != nil || resp.StatusCode != 503 || attempts != 1 {
+		t.Fatalf("expected one attempt returning 503, got %d attempts, %v %v", attempts, resp, err)
+	}
+}
+
+func TestRetryTransportContext(t *testing.T) {
+	slow := testRetry
+	slow.BaseDelay, slow.MaxDelay = time.Hour, time.Hour
+
+	t.Run("cancelled while waiting", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		attempts := 0
+		base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			attempts++
+			time.AfterFunc(10*time.Millisecond, cancel)
+			return response(500), nil
+		})
+		req, _ := http.NewRequestWithContext(ctx, "GET", "https://api.example.com/", nil)
+		if _, err := slow.Transport(base).RoundTrip(req); !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+		if attempts != 1 {
+			t.Fatalf("expected no attempt after cancel, got %d", attempts)
+		}
+	})
+
+	t.Run("backoff past the deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		attempts := 0
+		base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			attempts++
+			return response(500), nil
+		})
+		req, _ := http.NewRequestWithContext(ctx, "GET", "https://api.example.com/", nil)
+		resp, err := slow.Transport(base).RoundTrip(req)
+		if err != nil || resp.StatusCode != 500 || attempts != 1 {
+			t.Fatalf("expected the 500 back without waiting, got %d attempts, %v %v", attempts, resp, err)
+		}
+	})
+
+	t.Run("already cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		attempts := 0
+		base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			attempts++
+			return nil, req.Context().Err()
+		})
+		req, _ := http.NewRequestWithContext(ctx, "GET", "https://api.example.com/", nil)
+		if _, err := testRetry.Transport(base).RoundTrip(req); !errors.Is(err, context.Canceled) || attempts != 1 {
+			t.Fatalf("expected one cancelled attempt, got %d attempts, %v", attempts, err)
+		}
+	})
+}
+
+func TestRetryAttemptTimeout(t *testing.T) {
+	policy := testRetry.WithAttemptTimeout(20 * time.Millisecond)
+	attempts := 0
+	var second context.Context
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		attempts++
+		if attempts == 1 {
+			<-req.Context().Done() // a hung server
+			return nil, req.Context().Err()
+		}
+		second = req.Context()
+		resp := response(200)
+		resp.Body = io.NopCloser(strings.NewReader("ok"))
+		return resp, nil
+	})
+	req, _ := http.NewRequest("GET", "https://api.example.com/", nil)
+	resp, err := policy.Transport(base).RoundTrip(req)
+	if err != nil || attempts != 2 {
+		t.Fatalf("expected the timed-out attempt retried, got %d attempts, %v", attempts, err)
+	}
+	if data, err := io.ReadAll(resp.Body); err != nil || string(data) != "ok" {
+		t.Fatalf("expected the body readable after RoundTrip, got %q %v", data, err)
+	}
+	if second.Err() != nil {
+		t.Fatalf("expected the attempt live until its body is closed")
+	}
+	resp.Body.Close()
+	if second.Err() == nil {
+		t.Fatalf("expected closing the body to end the attempt")
+	}
+
+	single := RetryPolicy{MaxAttempts: 1, AttemptTimeout: time.Second}
+	if _, wrapped := single.Transport(base).(*retryTransport); !wrapped {
+		t.Fatalf("expected a single attempt to keep its timeout")
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	cases := []struct {
+		name   string
+		status int
+		value  string
+		want   time.Duration
+		ok     bool
+	}{
+		{"seconds", 429, "30", 30 * time.Second, true},
+		{"zero", 503, "0", 0, true},
+		{"date in the past", 503, "Mon, 02 Jan 2006 15:04:05 GMT", 0, true},
+		{"garbage", 429, "soon", 0, false},
+		{"negative", 429, "-5", 0, false},
+		{"missing", 429, "", 0, false},
+		{"not a 429 or 503", 500, "30", 0, false},
+	}
+	for _, c := range cases {
+		got, ok := retryAfter(response(c.status, "Retry-After", c.value))
+		if got != c.want || ok != c.ok {
+			t.Errorf("%s: expected %v %v, got %v %v", c.name, c.want, c.ok, got, ok)
+		}
+	}
+
+	future := time.Now().Add(90 * time.Second).UTC().Format(http.TimeFormat)
+	if got, ok := retryAfter(response(429, "Retry-After", future)); !ok || got < 80*time.Second || got > 90*time.Second {
+		t.Errorf("date: expected ~90s, got %v %v", got, ok)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for attempt, max := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 400 * time.Millisecond, 5: time.Second, 40: time.Second} {
+		for i := 0; i < 20; i++ {
+			if d := p.backoff(attempt); d < max/2 || d > max {
+				t.Fatalf("attempt %d: backoff %v outside [%v, %v]", attempt, d, max/2, max)
+			}
+		}
+	}
+}
+
+func TestClassifyStatus(t *testing.T) {
+	cases := map[int]ErrorClass{
+		200: ClassOK, 302: ClassOK,
+		408: ClassRetryable, 429: ClassRetryable, 500: ClassRetryable, 503: ClassRetryable,
+		401: ClassBlocked, 403: ClassBlocked,
+		404: ClassGone, 410: ClassGone,
+		400: ClassClient, 422: ClassClient,
+	}
+	for code, want := range cases {
+		if got := ClassifyStatus(code); got != want {
+			t.Errorf("%d: expected %s, got %s", code, want, got)
+		}
+	}
+}
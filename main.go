@@ -9,6 +9,8 @@ import (
 	"syscall"
 	"time"
 
+	"tct_scrooper/browser"
+	"tct_scrooper/challenge"
 	"tct_scrooper/config"
 	"tct_scrooper/httputil"
 	"tct_scrooper/logging"
@@ -17,18 +19,56 @@ import (
 	"tct_scrooper/scraper"
 	"tct_scrooper/services"
 	"tct_scrooper/storage"
+	"tct_scrooper/vpn"
 	"tct_scrooper/workers"
 )
 
 var (
 	scrapeNow = flag.Bool("scrape", false, "Run scrape once and exit")
+	reportOut = flag.String("report", "", "JSON report path for -scrape -dry-run (default dry_run_<timestamp>.json)")
 	resetData = flag.Bool("reset", false, "Nuke all domain data and exit (for testing)")
+
+	validateSite = flag.String("validate-site", "", "Run a declarative site mapping (site ID or YAML path) against -fixture and print the listings")
+	fixturePath  = flag.String("fixture", "", "Fixture file (JSON or HTML response body) for -validate-site")
+
+	replaySource = flag.String("replay", "", "Re-ingest an archived dataset (.jsonl.gz path) or Apify dataset ID and exit")
+	replaySchema = flag.String("replay-schema", "", "Postgres schema to replay into (scratch copy of the domain tables)")
+	siteFlag     = flag.String("site", "", "Site ID (for -replay, inferred from the archive path when omitted; filter for -reprocess, -quarantine, -scrape -dry-run)")
+	regionFlag   = flag.String("region", "", "Region ID (for -replay, inferred from the archive path when omitted; filter for -scrape -dry-run)")
+
+	shadowReport = flag.Bool("shadow-report", false, "Print the primary vs shadow adapter comparison for -site (latest run) or -run and exit")
+	runFlag      = flag.Int64("run", 0, "Scrape run ID (for -shadow-report)")
+
+	reprocess = flag.Bool("reprocess", false, "Re-parse stored listings.raw_data with the current adapters, backfill newly derived fields and exit")
+	cityFlag  = flag.String("city", "", "City filter (for -reprocess)")
+	sinceFlag = flag.String("since", "", "Only listings last seen on or after this date, YYYY-MM-DD (for -reprocess)")
+	untilFlag = flag.String("until", "", "Only listings last seen on or before this date, YYYY-MM-DD (for -reprocess)")
+	dryRun    = flag.Bool("dry-run", false, "Report changes without writing them (for -reprocess, -scrape)")
+
+	quarantineList    = flag.Bool("quarantine", false, "List pending quarantined listings (optionally for -site) and exit")
+	quarantineShow    = flag.Int64("quarantine-show", 0, "Print a quarantined listing with its reasons and raw payload and exit")
+	quarantineRelease = flag.Int64("quarantine-release", 0, "Apply -fix values to a quarantined listing, re-validate and process it, then exit")
+	quarantineDiscard = flag.Int64("quarantine-discard", 0, "Discard a quarantined listing and exit")
+	quarantineForce   = flag.Bool("force", false, "Release a quarantined listing even if it still fails validation")
+	quarantineFixes   = fixFlags{}
+
+	checkEgress = flag.Bool("check-egress", false, "Look up the public IP and country of each client through EGRESS_ECHO_URL, check them against the egress policy and exit")
+
+	resetSchemaBaseline = flag.String("reset-schema-baseline", "", "Drop the payload schema baseline for a source (adapter name or browser:<site>) and exit; the next dataset becomes the baseline")
 )
 
 func main() {
+	flag.Var(quarantineFixes, "fix", "Listing field=value to set before -quarantine-release (repeatable)")
 	flag.Parse()
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 
+	if *validateSite != "" {
+		if err := runValidateSite(*validateSite, *fixturePath); err != nil {
+			log.Fatalf("validate-site: %v", err)
+		}
+		return
+	}
+
 	logFile, err := logging.Setup("daemon.log")
 	if err != nil {
 		log.Printf("Warning: could not set up file logging: %v", err)
@@ -48,18 +88,82 @@ func main() {
 		log.Printf("  - %s (%s)", site.Name, id)
 	}
 
-	clients := httputil.NewClients(&cfg.Proxy)
-	log.Printf("Proxy: %s", cfg.Proxy.URL)
+	proxyURLs, err := cfg.Proxy.ProxyURLs()
+	if err != nil {
+		log.Fatalf("Failed to load proxies: %v", err)
+	}
+	proxies, err := httputil.NewProxyPool(proxyURLs)
+	if err != nil {
+		log.Fatalf("Failed to load proxies: %v", err)
+	}
+	log.Printf("Proxy pool: %d proxies", proxies.Len())
+
+	// Bot-protection blocks seen by every fetch path, per host and proxy;
+	// repeatedly blocked sites are backed off
+	challenges := challenge.NewTracker(
+		time.Duration(cfg.Scraper.BlockWindowMins)*time.Minute,
+		cfg.Scraper.BlockThreshold,
+		time.Duration(cfg.Scraper.BlockBackoffMins)*time.Minute,
+	)
+	proxies.SetTracker(challenges)
+
+	limiter := httputil.NewHostLimiter(cfg.RateLimits)
+
+	// Browser identities for target-site requests, one per session, rotated
+	// when blocked
+	fingerprints := httputil.NewFingerprints(cfg.Fingerprints)
+	log.Printf("Fingerprints: %d profiles", len(cfg.Fingerprints))
+
+	clients := httputil.NewClients(proxies, limiter, fingerprints)
+
+	vpnManager, err := vpn.New(cfg.VPN, vpn.ExecRunner{})
+	if err != nil {
+		log.Fatalf("Failed to set up VPN: %v", err)
+	}
+	if vpnManager != nil {
+		limiter.SetGate(vpnManager) // kill switch: target requests wait while the tunnel is down
+		log.Printf("VPN: %s, regions %v", cfg.VPN.Provider, cfg.VPN.Regions)
+	}
+
+	// Pre-flight egress check: the direct path may only leave from our own IP
+	// when there is no VPN, and no proxy may fall back to it. Each proxy is
+	// checked on its own, as the scraping client picks one per request.
+	egress := httputil.NewEgressChecker(cfg.Egress)
+	if egress != nil {
+		egress.AddClient("direct", clients.API, vpnManager == nil, "")
+		for _, p := range proxies.Proxies() {
+			egress.AddClient("proxy "+p.Label, p.Client(30*time.Second), false, "direct")
+		}
+		vpnManager.SetOnRotate(egress.Invalidate)
+	}
 
 	ctx := context.Background()
 
+	if *checkEgress {
+		if err := runEgressCheck(ctx, egress, vpnManager); err != nil {
+			log.Fatalf("Egress check failed: %v", err)
+		}
+		return
+	}
+
 	// Initialize Postgres store (required for domain data)
-	pgStore, err := storage.NewPostgresStore(ctx, cfg.Supabase.DBURL)
+	var pgStore *storage.PostgresStore
+	if *replaySchema != "" {
+		if *replaySource == "" {
+			log.Fatalf("-replay-schema is only valid with -replay")
+		}
+		pgStore, err = storage.NewPostgresStoreInSchema(ctx, cfg.Supabase.DBURL, *replaySchema)
+	} else {
+		pgStore, err = storage.NewPostgresStore(ctx, cfg.Supabase.DBURL)
+	}
 	if err != nil {
 		log.Fatalf("Failed to connect to Postgres: %v", err)
 	}
 	defer pgStore.Close()
 	log.Printf("Connected to Postgres: %s", maskConnectionString(cfg.Supabase.DBURL))
+	if *replaySchema != "" {
+		log.Printf("Using schema: %s", *replaySchema)
+	}
 
 	// Initialize SQLite for operational data (TUI commands, legacy support)
 	sqliteStore, err := storage.NewSQLiteStore(cfg.DBPath)
@@ -84,19 +188,114 @@ func main() {
 		return
 	}
 
+	if *resetSchemaBaseline != "" {
+		existed, err := pgStore.DeleteSchemaBaseline(ctx, *resetSchemaBaseline)
+		if err != nil {
+			log.Fatalf("reset-schema-baseline: %v", err)
+		}
+		if !existed {
+			log.Printf("No schema baseline for %s", *resetSchemaBaseline)
+		} else {
+			log.Printf("Schema baseline for %s dropped; the next dataset becomes the new baseline", *resetSchemaBaseline)
+		}
+		return
+	}
+
+	if *shadowReport {
+		if err := runShadowReport(ctx, pgStore, *siteFlag, *runFlag); err != nil {
+			log.Fatalf("shadow-report: %v", err)
+		}
+		return
+	}
+
 	// Initialize services
 	matchService := services.NewMatchService(pgStore)
 	mediaService := services.NewMediaService(pgStore)
 	listingService := services.NewListingService(pgStore, matchService, mediaService)
 	healthcheckService := services.NewHealthcheckService(pgStore, listingService)
+	quarantineService := services.NewQuarantineService(pgStore, listingService)
 
 	log.Println("Services initialized")
 
+	switch {
+	case *reprocess:
+		if err := runReprocess(ctx, cfg, services.NewReprocessService(pgStore), *siteFlag, *cityFlag, *sinceFlag, *untilFlag, *dryRun); err != nil {
+			log.Fatalf("reprocess: %v", err)
+		}
+		return
+	case *quarantineList:
+		if err := runQuarantineList(ctx, quarantineService, *siteFlag); err != nil {
+			log.Fatalf("quarantine: %v", err)
+		}
+		return
+	case *quarantineShow != 0:
+		if err := runQuarantineShow(ctx, quarantineService, *quarantineShow); err != nil {
+			log.Fatalf("quarantine-show: %v", err)
+		}
+		return
+	case *quarantineRelease != 0:
+		if err := runQuarantineRelease(ctx, cfg, quarantineService, *quarantineRelease, quarantineFixes, *quarantineForce); err != nil {
+			log.Fatalf("quarantine-release: %v", err)
+		}
+		return
+	case *quarantineDiscard != 0:
+		if err := quarantineService.Discard(ctx, *quarantineDiscard); err != nil {
+			log.Fatalf("quarantine-discard: %v", err)
+		}
+		log.Printf("Quarantined listing %d discarded", *quarantineDiscard)
+		return
+	}
+
 	// Create orchestrator
 	orchestrator := scraper.NewOrchestrator(cfg, sqliteStore)
 	orchestrator.SetServices(pgStore, listingService, matchService, mediaService, healthcheckService)
+	orchestrator.SetQuarantine(quarantineService)
+	orchestrator.SetRateLimiter(limiter)
+	orchestrator.SetFingerprints(fingerprints)
+	orchestrator.SetVPN(vpnManager)
+	orchestrator.SetEgressCheck(egress)
+	orchestrator.SetChallengeTracker(challenges)
+
+	// One Playwright driver and a profile per site, shared by the browser
+	// handler and the enrichment worker
+	browsers := browser.NewPool(cfg.Browser, fingerprints)
+	defer browsers.Close()
+	orchestrator.SetBrowserPool(browsers)
+
+	orchestrator.SetArchive(storage.NewDatasetArchive(cfg.ArchiveDir))
+
+	if *replaySource != "" {
+		siteID, regionID := *siteFlag, *regionFlag
+		key := storage.ParseArchivePath(*replaySource)
+		if siteID == "" {
+			siteID = key.Site
+		}
+		if regionID == "" {
+			regionID = key.Region
+		}
+		if err := orchestrator.Replay(ctx, siteID, regionID, *replaySource); err != nil {
+			log.Fatalf("Replay failed: %v", err)
+		}
+		return
+	}
+
+	// Apify webhook receiver (falls back to polling when not configured)
+	if cfg.Apify.WebhookURL != "" {
+		webhooks := scraper.NewApifyWebhookServer(cfg.Apify)
+		if err := webhooks.Start(); err != nil {
+			log.Fatalf("Failed to start Apify webhook receiver: %v", err)
+		}
+		defer webhooks.Stop(context.Background())
+		orchestrator.SetApifyWebhooks(webhooks)
+	}
 
 	// Handle one-shot commands
+	if *scrapeNow && *dryRun {
+		if err := runDryRunScrape(ctx, orchestrator, *siteFlag, *regionFlag, *reportOut); err != nil {
+			log.Fatalf("Dry run failed: %v", err)
+		}
+		return
+	}
 	if *scrapeNow {
 		log.Println("Running scrape...")
 		if err := orchestrator.RunAll(ctx); err != nil {
@@ -111,6 +310,20 @@ func main() {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	// Bring the tunnel up before anything scrapes; the watcher reconnects it
+	// if it drops, with scraping held back by the kill switch meanwhile
+	if err := vpnManager.EnsureConnected(ctx); err != nil {
+		log.Printf("VPN: %v; scraping waits for the tunnel", err)
+	}
+	go vpnManager.Watch(ctx, cfg.VPN.CheckInterval)
+
+	// Resume runs left unfinished by a previous daemon (reattaching in-flight
+	// Apify runs) before scheduling new ones; sites being resumed are skipped
+	// by the scheduler meanwhile
+	if err := orchestrator.ResumeRuns(ctx); err != nil {
+		log.Printf("Failed to resume unfinished runs: %v", err)
+	}
+
 	if err := sched.Start(ctx); err != nil {
 		log.Fatalf("Failed to start scheduler: %v", err)
 	}
@@ -121,12 +334,14 @@ func main() {
 	}
 
 	// Start background workers
-	enrichmentWorker := workers.NewEnrichmentWorker(pgStore, mediaService, cfg.Proxy.URL)
+	enrichmentWorker := workers.NewEnrichmentWorker(pgStore, mediaService, proxies, limiter, browsers)
 	enrichmentWorker.SetLogger(workerLog)
+	enrichmentWorker.SetVPN(vpnManager)
+	enrichmentWorker.SetChallengeTracker(challenges)
 	go enrichmentWorker.Run(ctx, 25, 5*time.Minute) // batch of 25 every 5 min
 	log.Println("Enrichment worker started")
 
-	healthcheckWorker := workers.NewHealthcheckWorker(pgStore, cfg.Proxy.URL)
+	healthcheckWorker := workers.NewHealthcheckWorker(pgStore, proxies, limiter, fingerprints)
 	healthcheckWorker.SetLogger(workerLog)
 	go healthcheckWorker.Run(ctx, 24*time.Hour, 50, 5*time.Minute) // check listings older than 24h, batch 50, every 5 min
 	log.Println("Healthcheck worker started")
@@ -150,7 +365,7 @@ func main() {
 		mediaUploader = workers.NewNoOpUploader()
 		log.Println("S3 not configured - media worker will skip uploads")
 	}
-	mediaWorker := workers.NewMediaWorker(pgStore, mediaUploader, cfg.Proxy.URL)
+	mediaWorker := workers.NewMediaWorker(pgStore, mediaUploader, proxies, limiter, fingerprints)
 	mediaWorker.SetLogger(workerLog)
 	go mediaWorker.Run(ctx, 50, 1*time.Minute) // batch of 50 every 1 min
 	log.Println("Media worker started")
@@ -158,6 +373,8 @@ func main() {
 	// Register workers with scheduler for manual triggering
 	sched.SetWorkers(mediaWorker, enrichmentWorker, healthcheckWorker)
 
+	go saveProxyStats(ctx, proxies, pgStore, time.Minute)
+
 	log.Println("Daemon running. Press Ctrl+C to stop.")
 
 	sigCh := make(chan os.Signal, 1)
@@ -169,6 +386,23 @@ func main() {
 	log.Println("Goodbye!")
 }
 
+// saveProxyStats writes the proxy pool's stats for the TUI every interval
+func saveProxyStats(ctx context.Context, proxies *httputil.ProxyPool, store *storage.PostgresStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := store.SaveProxyStats(ctx, proxies.Stats()); err != nil {
+				log.Printf("Failed to save proxy stats: %v", err)
+			}
+		}
+	}
+}
+
 // maskConnectionString masks password in connection string for logging
 func maskConnectionString(connStr string) string {
 	// Simple mask - find :// and mask until @
@@ -0,0 +1,19 @@
+-- Per-region progress for scrape runs, including the Apify actor run backing
+-- each region, so in-flight actor runs can be reattached after a restart
+
+CREATE TABLE IF NOT EXISTS scrape_run_regions (
+	run_id BIGINT NOT NULL REFERENCES scrape_runs(id) ON DELETE CASCADE,
+	region TEXT NOT NULL,
+	-- status: running, completed, failed
+	status TEXT NOT NULL DEFAULT 'running',
+	apify_run_id TEXT,
+	apify_actor TEXT,
+	-- apify_status: READY, RUNNING, SUCCEEDED, FAILED, ABORTED, TIMED-OUT
+	apify_status TEXT,
+	apify_dataset_id TEXT,
+	started_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW(),
+	PRIMARY KEY (run_id, region)
+);
+
+CREATE INDEX IF NOT EXISTS idx_scrape_run_regions_apify ON scrape_run_regions(apify_run_id) WHERE apify_run_id IS NOT NULL;
@@ -0,0 +1,26 @@
+-- Shadow mode: a secondary Apify adapter scrapes the same region as the
+-- primary one. Both result sets land here (not in the domain tables) so the
+-- adapters can be compared by MLS overlap, field agreement and cost.
+
+CREATE TABLE IF NOT EXISTS shadow_results (
+	id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+	run_id BIGINT NOT NULL REFERENCES scrape_runs(id) ON DELETE CASCADE,
+	region TEXT NOT NULL,
+	adapter TEXT NOT NULL,
+	-- role: primary, shadow
+	role TEXT NOT NULL,
+	listings_count INTEGER DEFAULT 0,
+	usage_usd NUMERIC(10, 4) DEFAULT 0,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	UNIQUE (run_id, region, role)
+);
+
+CREATE TABLE IF NOT EXISTS shadow_listings (
+	result_id BIGINT NOT NULL REFERENCES shadow_results(id) ON DELETE CASCADE,
+	mls TEXT NOT NULL,
+	address TEXT,
+	postal_code TEXT,
+	price INTEGER,
+	beds INTEGER,
+	PRIMARY KEY (result_id, mls)
+);
@@ -0,0 +1,4 @@
+-- Listings returned per region, the history Apify result sizes are checked
+-- against before falling back to the next adapter
+
+ALTER TABLE scrape_run_regions ADD COLUMN IF NOT EXISTS listings_count INTEGER;
@@ -0,0 +1,11 @@
+-- Baseline fingerprint (JSON key paths, dominant value type and presence) of
+-- each source's payloads, used to detect schema drift in later datasets.
+-- source is the Apify adapter name or browser:<site>.
+
+CREATE TABLE IF NOT EXISTS schema_baselines (
+	source TEXT PRIMARY KEY,
+	fields JSONB NOT NULL,
+	items INTEGER DEFAULT 0,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW()
+);
@@ -0,0 +1,23 @@
+-- Listings that failed their site's validation rules, held back from the
+-- domain tables until they are fixed and released or discarded
+
+CREATE TABLE IF NOT EXISTS quarantined_listings (
+	id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+	run_id BIGINT REFERENCES scrape_runs(id) ON DELETE SET NULL,
+	source TEXT NOT NULL,
+	region TEXT,
+	-- listing_key: MLS number, else source ID; one pending row per key
+	listing_key TEXT NOT NULL,
+	reasons TEXT[] NOT NULL,
+	-- listing: the parsed RawListing without its payload; raw_data: source payload
+	listing JSONB NOT NULL,
+	raw_data JSONB,
+	-- status: pending, released, discarded
+	status TEXT NOT NULL DEFAULT 'pending',
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW(),
+	resolved_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_quarantine_pending ON quarantined_listings(source, listing_key) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_quarantine_status ON quarantined_listings(status, created_at);
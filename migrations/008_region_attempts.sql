@@ -0,0 +1,5 @@
+-- Region retry checkpoints: attempts per region and the last error, so a
+-- restarted daemon can resume unfinished runs region by region
+
+ALTER TABLE scrape_run_regions ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE scrape_run_regions ADD COLUMN IF NOT EXISTS last_error TEXT;
@@ -0,0 +1,10 @@
+-- Disappearance detection: the region that last returned a listing, and when
+-- a full scrape of that region first stopped returning it. Missing listings
+-- get a priority healthcheck and are delisted once their grace period ends.
+
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS region TEXT;
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS missing_since TIMESTAMPTZ;
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS missing_checked_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_listings_source_region ON listings(source, region) WHERE region IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_listings_missing ON listings(missing_since) WHERE status = 'missing';
@@ -0,0 +1,7 @@
+-- Coverage reconciliation per region: the total the source reported, the
+-- listings fetched before region filtering and those ingested. Together with
+-- listings_count (post-filter) they show where listings are lost.
+
+ALTER TABLE scrape_run_regions ADD COLUMN IF NOT EXISTS expected_total INTEGER;
+ALTER TABLE scrape_run_regions ADD COLUMN IF NOT EXISTS fetched_count INTEGER;
+ALTER TABLE scrape_run_regions ADD COLUMN IF NOT EXISTS ingested_count INTEGER;
@@ -0,0 +1,15 @@
+-- Outcome counters and score per proxy in the daemon's pool, written
+-- periodically by the daemon for the TUI. Counters restart with the daemon.
+-- proxy is the label (user@host:port), never the password.
+
+CREATE TABLE IF NOT EXISTS proxy_stats (
+	proxy TEXT PRIMARY KEY,
+	requests BIGINT NOT NULL DEFAULT 0,
+	successes BIGINT NOT NULL DEFAULT 0,
+	blocks BIGINT NOT NULL DEFAULT 0,
+	failures BIGINT NOT NULL DEFAULT 0,
+	avg_latency_ms INTEGER,
+	score REAL,
+	cooldown_until TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ DEFAULT NOW()
+);
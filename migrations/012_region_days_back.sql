@@ -0,0 +1,6 @@
+-- The listing window a region was scraped with. Canadesk is asked for the
+-- last 30 days on a region's first scrape and only the days since the last
+-- run afterwards, so anomaly baselines must only compare like windows.
+-- Existing rows stay NULL and drop out of windowed baselines.
+
+ALTER TABLE scrape_run_regions ADD COLUMN IF NOT EXISTS days_back INTEGER;
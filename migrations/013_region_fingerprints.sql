@@ -0,0 +1,5 @@
+-- The fingerprint profiles a region's requests went out as, so a block or
+-- a drop in results can be traced to the profile in use. Comma-separated;
+-- NULL for Apify regions, whose actors do the fetching.
+
+ALTER TABLE scrape_run_regions ADD COLUMN IF NOT EXISTS fingerprints TEXT;
@@ -15,6 +15,8 @@ const (
 	CmdRunMedia       CommandType = "run_media"
 	CmdRunEnrichment  CommandType = "run_enrichment"
 	CmdRunHealthcheck CommandType = "run_healthcheck"
+	CmdCancelRun      CommandType = "cancel_run"
+	CmdCancelSite     CommandType = "cancel_site"
 )
 
 type Command struct {
@@ -28,4 +30,5 @@ type Command struct {
 type CommandParams struct {
 	Site   string `json:"site,omitempty"`
 	Region string `json:"region,omitempty"`
+	RunID  int64  `json:"run_id,omitempty"` // Postgres scrape run, for cancel_run
 }
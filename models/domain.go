@@ -209,6 +209,84 @@ type DomainScrapeRun struct {
 	Metadata      json.RawMessage `json:"metadata" db:"metadata"`
 }
 
+// ScrapeRunRegion tracks one region of a scrape run, including the Apify
+// actor run backing it so it can be reattached after a restart
+type ScrapeRunRegion struct {
+	RunID          int64     `json:"run_id" db:"run_id"`
+	Source         string    `json:"source" db:"source"` // from scrape_runs, read-only
+	Region         string    `json:"region" db:"region"`
+	Status         string    `json:"status" db:"status"` // running, completed, failed
+	ApifyRunID     string    `json:"apify_run_id" db:"apify_run_id"`
+	ApifyActor     string    `json:"apify_actor" db:"apify_actor"`
+	ApifyStatus    string    `json:"apify_status" db:"apify_status"` // RUNNING, SUCCEEDED, FAILED, ...
+	ApifyDatasetID string    `json:"apify_dataset_id" db:"apify_dataset_id"`
+	ListingsCount  *int      `json:"listings_count" db:"listings_count"` // nil until the region completes
+	ExpectedTotal  *int      `json:"expected_total" db:"expected_total"` // total the source reported, if it did
+	FetchedCount   *int      `json:"fetched_count" db:"fetched_count"`   // listings before region filtering
+	IngestedCount  *int      `json:"ingested_count" db:"ingested_count"` // listings processed without error or quarantine
+	DaysBack       *int      `json:"days_back" db:"days_back"`           // listing window the actor was asked for; nil for the full set
+	Fingerprints   string    `json:"fingerprints" db:"fingerprints"`     // fingerprint profiles the last attempt went out as, comma-separated
+	Attempts       int       `json:"attempts" db:"attempts"` // scrape attempts, incl. retries
+	LastError      string    `json:"last_error" db:"last_error"`
+	StartedAt      time.Time `json:"started_at" db:"started_at"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ShadowResult is one adapter's result for a region in shadow mode, kept
+// apart from the domain tables for comparison
+type ShadowResult struct {
+	ID            int64           `json:"id" db:"id"`
+	RunID         int64           `json:"run_id" db:"run_id"`
+	Region        string          `json:"region" db:"region"`
+	Adapter       string          `json:"adapter" db:"adapter"`
+	Role          string          `json:"role" db:"role"` // primary, shadow
+	ListingsCount int             `json:"listings_count" db:"listings_count"`
+	UsageUSD      float64         `json:"usage_usd" db:"usage_usd"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	Listings      []ShadowListing `json:"listings,omitempty"`
+}
+
+// ShadowListing holds the fields compared between adapters
+type ShadowListing struct {
+	MLS        string `json:"mls" db:"mls"`
+	Address    string `json:"address" db:"address"`
+	PostalCode string `json:"postal_code" db:"postal_code"`
+	Price      int    `json:"price" db:"price"`
+	Beds       int    `json:"beds" db:"beds"`
+}
+
+// SchemaBaseline is the reference fingerprint of a source's payloads
+type SchemaBaseline struct {
+	Source    string                 `json:"source" db:"source"` // adapter name or browser:<site>
+	Fields    map[string]SchemaField `json:"fields" db:"fields"`
+	Items     int                    `json:"items" db:"items"`
+	CreatedAt time.Time              `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time              `json:"updated_at" db:"updated_at"`
+}
+
+// SchemaField is a key path's dominant JSON type and the share of items
+// that carry it with that type
+type SchemaField struct {
+	Type     string  `json:"type"`
+	Presence float64 `json:"presence"`
+}
+
+// QuarantinedListing is a listing held back because it failed its site's
+// validation rules
+type QuarantinedListing struct {
+	ID         int64      `json:"id" db:"id"`
+	RunID      *int64     `json:"run_id" db:"run_id"`
+	Source     string     `json:"source" db:"source"`
+	Region     string     `json:"region" db:"region"`
+	ListingKey string     `json:"listing_key" db:"listing_key"`
+	Reasons    []string   `json:"reasons" db:"reasons"`
+	Listing    RawListing `json:"listing" db:"listing"` // Data holds the raw payload
+	Status     string     `json:"status" db:"status"`   // pending, released, discarded
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+	ResolvedAt *time.Time `json:"resolved_at" db:"resolved_at"`
+}
+
 // DomainScrapeLog represents a log entry for a scrape run
 type DomainScrapeLog struct {
 	ID        int64     `json:"id" db:"id"`
@@ -233,6 +311,7 @@ const (
 // Listing status
 const (
 	ListingStatusActive    = "active"
+	ListingStatusMissing   = "missing" // absent from a full region scrape, within its grace period
 	ListingStatusDelisted  = "delisted"
 	ListingStatusExpired   = "expired"
 	ListingStatusWithdrawn = "withdrawn"
@@ -8,6 +8,7 @@ const (
 	RunStatusRunning   RunStatus = "running"
 	RunStatusCompleted RunStatus = "completed"
 	RunStatusFailed    RunStatus = "failed"
+	RunStatusCancelled RunStatus = "cancelled"
 )
 
 type ScrapeRun struct {
@@ -33,3 +34,15 @@ type SiteStats struct {
 	SuccessRate       float64    `json:"success_rate" db:"success_rate"`
 	AvgRunDurationSec int        `json:"avg_run_duration_sec" db:"avg_run_duration_sec"`
 }
+
+// ProxyStat is one proxy's outcomes in the daemon's pool since it started
+type ProxyStat struct {
+	Proxy         string     `json:"proxy" db:"proxy"` // user@host:port, no password
+	Requests      int64      `json:"requests" db:"requests"`
+	Successes     int64      `json:"successes" db:"successes"`
+	Blocks        int64      `json:"blocks" db:"blocks"` // 401/403/429 or a bot challenge
+	Failures      int64      `json:"failures" db:"failures"`
+	AvgLatencyMS  int        `json:"avg_latency_ms" db:"avg_latency_ms"`
+	Score         float64    `json:"score" db:"score"`
+	CooldownUntil *time.Time `json:"cooldown_until" db:"cooldown_until"` // set while rotated out after blocks
+}
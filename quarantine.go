@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"tct_scrooper/config"
+	"tct_scrooper/services"
+)
+
+// quarantineListLimit caps how many pending listings -quarantine prints
+const quarantineListLimit = 100
+
+// fixFlags collects repeated -fix field=value arguments
+type fixFlags map[string]string
+
+func (f fixFlags) String() string {
+	var parts []string
+	for k, v := range f {
+		parts = append(parts, k+"="+v)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (f fixFlags) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("expected field=value, got %q", s)
+	}
+	f[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	return nil
+}
+
+// runQuarantineList prints pending quarantined listings, optionally for one site
+func runQuarantineList(ctx context.Context, quarantine *services.QuarantineService, site string) error {
+	items, err := quarantine.List(ctx, site, quarantineListLimit)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		fmt.Println("No quarantined listings")
+		return nil
+	}
+
+	for _, q := range items {
+		fmt.Printf("%6d  %-12s %-14s %-12s %s\n", q.ID, q.Source, q.Region, q.ListingKey, q.UpdatedAt.Format("2006-01-02 15:04"))
+		fmt.Printf("        %s\n", strings.Join(q.Reasons, "; "))
+	}
+	if len(items) == quarantineListLimit {
+		fmt.Printf("(showing the latest %d)\n", quarantineListLimit)
+	}
+	return nil
+}
+
+// runQuarantineShow prints one quarantined listing with its parsed fields and
+// raw payload
+func runQuarantineShow(ctx context.Context, quarantine *services.QuarantineService, id int64) error {
+	q, err := quarantine.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Quarantined listing %d (%s)\n", q.ID, q.Status)
+	fmt.Printf("   source:  %s\n", q.Source)
+	fmt.Printf("   region:  %s\n", q.Region)
+	fmt.Printf("   key:     %s\n", q.ListingKey)
+	if q.RunID != nil {
+		fmt.Printf("   run:     %d\n", *q.RunID)
+	}
+	fmt.Printf("   created: %s\n", q.CreatedAt.Format("2006-01-02 15:04"))
+	fmt.Println("   reasons:")
+	for _, r := range q.Reasons {
+		fmt.Printf("     - %s\n", r)
+	}
+
+	raw := q.Listing.Data
+	listing := q.Listing
+	listing.Data = nil
+	fields, err := json.MarshalIndent(listing, "   ", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\n   listing: %s\n", fields)
+
+	if len(raw) > 0 {
+		var payload interface{}
+		if err := json.Unmarshal(raw, &payload); err == nil {
+			if pretty, err := json.MarshalIndent(payload, "   ", "  "); err == nil {
+				raw = pretty
+			}
+		}
+		fmt.Printf("\n   raw_data: %s\n", raw)
+	}
+	return nil
+}
+
+// runQuarantineRelease applies fixes to a quarantined listing and processes
+// it, re-checking the site's validation rules unless force is set
+func runQuarantineRelease(ctx context.Context, cfg *config.Config, quarantine *services.QuarantineService, id int64, fixes map[string]string, force bool) error {
+	q, err := quarantine.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	var rules *config.ValidationRules
+	if siteCfg, ok := cfg.Sites[q.Source]; ok {
+		rules = siteCfg.Validation
+	}
+
+	result, err := quarantine.Release(ctx, id, fixes, rules, force)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Released quarantined listing %d: property %s, listing %s", id, result.PropertyID, result.ListingID)
+	if result.IsNewProperty {
+		fmt.Print(" (new property)")
+	}
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"tct_scrooper/config"
+	"tct_scrooper/models"
+	"tct_scrooper/scraper"
+	"tct_scrooper/services"
+	"tct_scrooper/storage"
+)
+
+// maxReportedChanges caps the per-listing change lines printed by -reprocess
+const maxReportedChanges = 50
+
+// runReprocess re-parses stored raw_data for the listings matching the
+// filters and backfills newly derived fields, then prints a change summary
+func runReprocess(ctx context.Context, cfg *config.Config, service *services.ReprocessService, site, city, since, until string, dryRun bool) error {
+	filter := storage.ReprocessFilter{Source: site, City: city}
+	var err error
+	if filter.From, err = parseDateFlag("since", since); err != nil {
+		return err
+	}
+	if filter.To, err = parseDateFlag("until", until); err != nil {
+		return err
+	}
+	if !filter.To.IsZero() {
+		filter.To = filter.To.AddDate(0, 0, 1) // -until is inclusive
+	}
+
+	if dryRun {
+		fmt.Println("Dry run: no changes will be written")
+	}
+
+	reported := 0
+	onChange := func(listing *models.Listing, changes []services.FieldChange) {
+		reported++
+		if reported > maxReportedChanges {
+			return
+		}
+		fmt.Printf("%s %s\n", listing.Source, listing.ExternalID)
+		for _, c := range changes {
+			fmt.Printf("   %s.%s = %q\n", c.Table, c.Field, c.Value)
+		}
+	}
+
+	summary, err := scraper.NewReprocessor(cfg, service).Run(ctx, filter, dryRun, onChange)
+	if reported > maxReportedChanges {
+		fmt.Printf("... %d more listings changed\n", reported-maxReportedChanges)
+	}
+	if summary != nil {
+		printReprocessSummary(summary, dryRun)
+	}
+	return err
+}
+
+func printReprocessSummary(s *services.ReprocessSummary, dryRun bool) {
+	verb := "updated"
+	if dryRun {
+		verb = "would update"
+	}
+
+	fmt.Printf("\n== summary ==\n")
+	fmt.Printf("   scanned:   %d\n", s.Scanned)
+	fmt.Printf("   %s: %d\n", verb, s.Changed)
+	fmt.Printf("   unchanged: %d\n", s.Unchanged)
+	fmt.Printf("   unparsed:  %d\n", s.Unparsed)
+	fmt.Printf("   errors:    %d\n", s.Errors)
+
+	fields := make([]string, 0, len(s.FieldCounts))
+	for field := range s.FieldCounts {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		fmt.Printf("     %-26s %d\n", field, s.FieldCounts[field])
+	}
+}
+
+// parseDateFlag parses an optional YYYY-MM-DD flag value
+func parseDateFlag(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("-%s: want YYYY-MM-DD, got %q", name, value)
+	}
+	return t, nil
+}
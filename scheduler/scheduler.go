@@ -61,7 +61,7 @@ func (s *Scheduler) Start(ctx context.Context) error {
 	if s.cfg.Scheduler.Cron != "" {
 		log.Printf("Starting scheduler with cron: %s", s.cfg.Scheduler.Cron)
 		_, err := s.cron.AddFunc(s.cfg.Scheduler.Cron, func() {
-			if err := s.orchestrator.RunAll(ctx); err != nil {
+			if err := s.scheduledRun(ctx); err != nil {
 				log.Printf("Scheduled run error: %v", err)
 			}
 		})
@@ -82,7 +82,7 @@ func (s *Scheduler) Start(ctx context.Context) error {
 		if !lastRun.IsZero() && timeSinceLastRun >= s.cfg.Scheduler.Interval {
 			log.Printf("Last scrape was %s ago (>= %s interval), triggering immediate run", timeSinceLastRun.Round(time.Minute), s.cfg.Scheduler.Interval)
 			go func() {
-				if err := s.orchestrator.RunAll(ctx); err != nil {
+				if err := s.scheduledRun(ctx); err != nil {
 					log.Printf("Immediate run error: %v", err)
 				}
 			}()
@@ -95,7 +95,7 @@ func (s *Scheduler) Start(ctx context.Context) error {
 			for {
 				select {
 				case <-s.ticker.C:
-					if err := s.orchestrator.RunAll(ctx); err != nil {
+					if err := s.scheduledRun(ctx); err != nil {
 						log.Printf("Scheduled run error: %v", err)
 					}
 				case <-s.stopCh:
@@ -137,7 +137,7 @@ func (s *Scheduler) pollCommands(ctx context.Context) {
 
 			for _, cmd := range cmds {
 				log.Printf("Processing command: %s", cmd.Command)
-				if err := s.handleCommand(&cmd); err != nil {
+				if err := s.handleCommand(ctx, &cmd); err != nil {
 					log.Printf("Command error: %v", err)
 				}
 				if err := s.store.MarkCommandProcessed(cmd.ID); err != nil {
@@ -152,7 +152,7 @@ func (s *Scheduler) pollCommands(ctx context.Context) {
 	}
 }
 
-func (s *Scheduler) handleCommand(cmd *models.Command) error {
+func (s *Scheduler) handleCommand(ctx context.Context, cmd *models.Command) error {
 	switch cmd.Command {
 	case models.CmdRunMedia:
 		if s.mediaWorker != nil {
@@ -173,10 +173,24 @@ func (s *Scheduler) handleCommand(cmd *models.Command) error {
 		}
 		return nil
 	default:
-		return s.orchestrator.HandleCommand(cmd)
+		return s.orchestrator.HandleCommand(ctx, cmd)
 	}
 }
 
+// scheduledRun runs every site except those backed off after repeated
+// bot-protection blocks; they come back on a later schedule
+func (s *Scheduler) scheduledRun(ctx context.Context) error {
+	var siteIDs []string
+	for _, siteID := range s.orchestrator.GetSiteIDs() {
+		if wait := s.orchestrator.SiteBackoff(siteID); wait > 0 {
+			log.Printf("Skipping scheduled run of %s: blocked repeatedly, backing off for %v", siteID, wait.Round(time.Minute))
+			continue
+		}
+		siteIDs = append(siteIDs, siteID)
+	}
+	return s.orchestrator.RunSites(ctx, siteIDs)
+}
+
 func (s *Scheduler) TriggerNow(ctx context.Context) error {
 	return s.orchestrator.RunAll(ctx)
 }
@@ -203,6 +217,10 @@ func (s *Scheduler) pollResumes(ctx context.Context) {
 					continue
 				}
 
+				if wait := s.orchestrator.SiteBackoff(siteID); wait > 0 {
+					log.Printf("Not resuming %s yet: backing off for %v after repeated blocks", siteID, wait.Round(time.Minute))
+					continue
+				}
 				if time.Since(lastRun) >= resumeDelay {
 					log.Printf("Resuming scrape for %s", siteID)
 					if err := s.orchestrator.RunSite(ctx, siteID); err != nil {
@@ -239,9 +257,7 @@ func (s *Scheduler) pollHealthcheck(ctx context.Context) {
 				log.Printf("Healthcheck error creating request: %v", err)
 				continue
 			}
-			req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
 			req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
-			req.Header.Set("Accept-Language", "en-CA,en;q=0.9")
 
 			resp, err := s.clients.Scraping.Do(req)
 			if err != nil {
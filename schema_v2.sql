@@ -50,7 +50,7 @@ CREATE TABLE listings (
 	url TEXT,
 	-- type: sale, rent, sale_and_rent
 	type TEXT NOT NULL,
-	-- status: active, delisted, expired, withdrawn, pending, terminated
+	-- status: active, missing, delisted, expired, withdrawn, pending, terminated
 	status TEXT,
 	price NUMERIC,
 	currency TEXT DEFAULT 'CAD',
@@ -71,6 +71,11 @@ CREATE TABLE listings (
 	listed_at TIMESTAMPTZ DEFAULT NOW(),
 	delisted_at TIMESTAMPTZ,
 	enrichment_attempts INTEGER DEFAULT 0,
+	-- region that last returned the listing; missing_since is set when a full
+	-- scrape of it no longer does, missing_checked_at once healthchecked since
+	region TEXT,
+	missing_since TIMESTAMPTZ,
+	missing_checked_at TIMESTAMPTZ,
 	created_at TIMESTAMPTZ DEFAULT NOW(),
 	updated_at TIMESTAMPTZ DEFAULT NOW(),
 	UNIQUE(source, external_id)
@@ -354,6 +359,105 @@ CREATE TABLE scrape_runs (
 	metadata JSONB
 );
 
+-- Per-region progress within a run, the checkpoint a restarted daemon resumes
+-- from (Apify run IDs for reattach after restart)
+CREATE TABLE scrape_run_regions (
+	run_id BIGINT NOT NULL REFERENCES scrape_runs(id) ON DELETE CASCADE,
+	region TEXT NOT NULL,
+	-- status: running, completed, failed, cancelled
+	status TEXT NOT NULL DEFAULT 'running',
+	apify_run_id TEXT,
+	apify_actor TEXT,
+	-- apify_status: READY, RUNNING, SUCCEEDED, FAILED, ABORTED, TIMED-OUT
+	apify_status TEXT,
+	apify_dataset_id TEXT,
+	-- listings returned for the region (post-filter), baseline for anomaly checks
+	listings_count INTEGER,
+	-- coverage: total the source reported (NULL when it doesn't), listings
+	-- fetched before region filtering, and listings ingested
+	expected_total INTEGER,
+	fetched_count INTEGER,
+	ingested_count INTEGER,
+	-- listing window in days the actor was asked for (NULL: the full set);
+	-- anomaly baselines only compare results over the same window
+	days_back INTEGER,
+	-- fingerprint profiles the region's last attempt went out as,
+	-- comma-separated (NULL for Apify, whose actors do the fetching)
+	fingerprints TEXT,
+	-- scrape attempts including retries, and the error of the last failed one
+	attempts INTEGER NOT NULL DEFAULT 0,
+	last_error TEXT,
+	started_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW(),
+	PRIMARY KEY (run_id, region)
+);
+
+-- Shadow mode: primary and secondary adapter results for comparison only
+CREATE TABLE shadow_results (
+	id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+	run_id BIGINT NOT NULL REFERENCES scrape_runs(id) ON DELETE CASCADE,
+	region TEXT NOT NULL,
+	adapter TEXT NOT NULL,
+	-- role: primary, shadow
+	role TEXT NOT NULL,
+	listings_count INTEGER DEFAULT 0,
+	usage_usd NUMERIC(10, 4) DEFAULT 0,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	UNIQUE (run_id, region, role)
+);
+
+CREATE TABLE shadow_listings (
+	result_id BIGINT NOT NULL REFERENCES shadow_results(id) ON DELETE CASCADE,
+	mls TEXT NOT NULL,
+	address TEXT,
+	postal_code TEXT,
+	price INTEGER,
+	beds INTEGER,
+	PRIMARY KEY (result_id, mls)
+);
+
+-- Payload fingerprint per source (adapter or browser:<site>) for drift detection
+CREATE TABLE schema_baselines (
+	source TEXT PRIMARY KEY,
+	-- {"<key path>": {"type": "...", "presence": 0.0-1.0}}
+	fields JSONB NOT NULL,
+	items INTEGER DEFAULT 0,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Listings that failed validation, held until fixed-and-released or discarded
+CREATE TABLE quarantined_listings (
+	id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+	run_id BIGINT REFERENCES scrape_runs(id) ON DELETE SET NULL,
+	source TEXT NOT NULL,
+	region TEXT,
+	-- listing_key: MLS number, else source ID; one pending row per key
+	listing_key TEXT NOT NULL,
+	reasons TEXT[] NOT NULL,
+	-- listing: the parsed RawListing without its payload; raw_data: source payload
+	listing JSONB NOT NULL,
+	raw_data JSONB,
+	-- status: pending, released, discarded
+	status TEXT NOT NULL DEFAULT 'pending',
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW(),
+	resolved_at TIMESTAMPTZ
+);
+
+-- Proxy pool outcomes and score, written by the daemon (label, no password)
+CREATE TABLE proxy_stats (
+	proxy TEXT PRIMARY KEY,
+	requests BIGINT NOT NULL DEFAULT 0,
+	successes BIGINT NOT NULL DEFAULT 0,
+	blocks BIGINT NOT NULL DEFAULT 0,
+	failures BIGINT NOT NULL DEFAULT 0,
+	avg_latency_ms INTEGER,
+	score REAL,
+	cooldown_until TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
 CREATE TABLE scrape_logs (
 	id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
 	run_id BIGINT REFERENCES scrape_runs(id),
@@ -421,7 +525,10 @@ CREATE INDEX idx_links_listing ON property_links(listing_id);
 CREATE INDEX idx_links_site ON property_links(site);
 
 CREATE INDEX idx_runs_status ON scrape_runs(status, started_at);
+CREATE INDEX idx_scrape_run_regions_apify ON scrape_run_regions(apify_run_id) WHERE apify_run_id IS NOT NULL;
 CREATE INDEX idx_logs_run ON scrape_logs(run_id, timestamp);
+CREATE UNIQUE INDEX idx_quarantine_pending ON quarantined_listings(source, listing_key) WHERE status = 'pending';
+CREATE INDEX idx_quarantine_status ON quarantined_listings(status, created_at);
 
 -- ============================================
 -- CONSTRAINTS
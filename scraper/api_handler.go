@@ -8,26 +8,55 @@ import (
 	"io"
 	"log"
 	"net/http"
-	"time"
 
+	"tct_scrooper/challenge"
 	"tct_scrooper/config"
+	"tct_scrooper/httputil"
 	"tct_scrooper/models"
 )
 
+// realtorSearchRetry retries the realtor.ca search POST on 5xx too: it only
+// reads, so replaying it is safe
+var realtorSearchRetry = func() httputil.RetryPolicy {
+	p := httputil.ScrapingRetry
+	p.RetryUnsafe = true
+	return p
+}()
+
 type APIHandler struct {
-	cfg    *config.SiteConfig
-	client *http.Client
+	cfg          *config.SiteConfig
+	client       *http.Client
+	limiter      *httputil.HostLimiter
+	fingerprints *httputil.Fingerprints
 }
 
 func NewAPIHandler(cfg *config.SiteConfig) *APIHandler {
 	return &APIHandler{
 		cfg: cfg,
 		client: &http.Client{
-			Timeout: 30 * time.Second,
+			Transport: realtorSearchRetry.Transport(nil),
 		},
 	}
 }
 
+// SetRateLimiter routes the handler's requests through the shared per-host
+// limiter, beneath the retries so each attempt waits its turn
+func (h *APIHandler) SetRateLimiter(limiter *httputil.HostLimiter) {
+	h.limiter = limiter
+	h.client.Transport = h.transport()
+}
+
+// SetFingerprints sends the handler's requests with the site's fingerprint
+// profile, rotated when the site blocks it
+func (h *APIHandler) SetFingerprints(fingerprints *httputil.Fingerprints) {
+	h.fingerprints = fingerprints
+	h.client.Transport = h.transport()
+}
+
+func (h *APIHandler) transport() http.RoundTripper {
+	return h.fingerprints.Transport("site:"+h.cfg.ID, realtorSearchRetry.Transport(h.limiter.Transport(nil)))
+}
+
 func (h *APIHandler) ID() string {
 	return h.cfg.ID
 }
@@ -42,14 +71,18 @@ func (h *APIHandler) Scrape(ctx context.Context, region config.Region) ([]models
 func (h *APIHandler) scrapeRealtorCA(ctx context.Context, region config.Region) ([]models.RawListing, error) {
 	var allListings []models.RawListing
 	recordsPerPage := 200
+	totalRecords := 0
 
 	for page := 1; ; page++ {
 		log.Printf("API: fetching page %d for %s", page, region.GeoName)
 
-		listings, err := h.fetchRealtorCAPage(ctx, region, page, recordsPerPage)
+		listings, total, err := h.fetchRealtorCAPage(ctx, region, page, recordsPerPage)
 		if err != nil {
 			return nil, fmt.Errorf("page %d: %w", page, err)
 		}
+		if total > 0 {
+			totalRecords = total
+		}
 
 		if len(listings) == 0 {
 			log.Printf("API: no more listings at page %d", page)
@@ -65,10 +98,13 @@ func (h *APIHandler) scrapeRealtorCA(ctx context.Context, region config.Region)
 		}
 	}
 
+	reportCoverage(ctx, totalRecords, len(allListings))
 	return allListings, nil
 }
 
-func (h *APIHandler) fetchRealtorCAPage(ctx context.Context, region config.Region, page, recordsPerPage int) ([]models.RawListing, error) {
+// fetchRealtorCAPage returns one page of listings and the region's total
+// record count as reported in the response's Paging
+func (h *APIHandler) fetchRealtorCAPage(ctx context.Context, region config.Region, page, recordsPerPage int) ([]models.RawListing, int, error) {
 	endpoint := h.cfg.Endpoints["search"]
 
 	reqBody := map[string]interface{}{
@@ -84,33 +120,48 @@ func (h *APIHandler) fetchRealtorCAPage(ctx context.Context, region config.Regio
 
 	body, err := json.Marshal(reqBody)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
 	req.Header.Set("Origin", "https://www.realtor.ca")
 	req.Header.Set("Referer", "https://www.realtor.ca/")
 
 	resp, err := h.client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer resp.Body.Close()
 
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	fingerprint := httputil.FingerprintFrom(resp.Request.Context())
+	reportFingerprint(ctx, fingerprint)
+	if err := challenge.Check(challenge.Page{
+		Host:        req.URL.Hostname(),
+		Status:      resp.StatusCode,
+		Body:        string(respBody),
+		Expect:      []string{`"Paging"`},
+		Fingerprint: fingerprint,
+	}); err != nil {
+		reportBlock(ctx, err)
+		return nil, 0, err
+	}
 	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("realtor.ca API error %d: %s", resp.StatusCode, string(respBody))
+		return nil, 0, fmt.Errorf("realtor.ca API error %d (%s): %s",
+			resp.StatusCode, httputil.ClassifyStatus(resp.StatusCode), string(respBody))
 	}
 
 	var result realtorCASearchResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, err
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, 0, err
 	}
 
 	var listings []models.RawListing
@@ -134,7 +185,7 @@ func (h *APIHandler) fetchRealtorCAPage(ctx context.Context, region config.Regio
 		listings = append(listings, listing)
 	}
 
-	return listings, nil
+	return listings, result.Paging.TotalRecords, nil
 }
 
 type realtorCASearchResponse struct {
@@ -9,6 +9,10 @@ import (
 	"tct_scrooper/models"
 )
 
+// canadeskMaxDays is the widest listing window canadesk is asked for, used
+// for a region's first scrape; later scrapes only cover the days since
+const canadeskMaxDays = 30
+
 // CanadeskAdapter handles canadesk/realtor-canada actor
 type CanadeskAdapter struct {
 	DaysBack int // set by handler before BuildInput
@@ -27,7 +31,7 @@ func (a *CanadeskAdapter) BuildInput(region config.Region, isIncremental bool) m
 	days := a.DaysBack
 	if days == 0 {
 		// fallback to old behavior if not set
-		days = 30
+		days = canadeskMaxDays
 		if isIncremental {
 			days = 1
 		}
@@ -98,6 +102,15 @@ func (a *CanadeskAdapter) ParseListing(data json.RawMessage) (models.RawListing,
 	return listing, nil
 }
 
+// RequiredPaths lists the payload fields ParseListing depends on
+func (a *CanadeskAdapter) RequiredPaths() []string {
+	return []string{
+		"Id", "MlsNumber", "PostalCode", "ProvinceName",
+		"Property.Price", "Property.Address.AddressText",
+		"Building.Bedrooms", "Building.BathroomTotal", "Building.SizeInterior",
+	}
+}
+
 // FilterListings filters by city name in address (canadesk is fuzzy about location)
 func (a *CanadeskAdapter) FilterListings(listings []models.RawListing, region config.Region) []models.RawListing {
 	cityName := strings.ToLower(extractCityName(region.GeoName))
@@ -0,0 +1,194 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	"tct_scrooper/config"
+	"tct_scrooper/models"
+)
+
+const (
+	// A region's result is anomalous when it falls below anomalyMinRatio of
+	// the median of its last anomalyHistoryRuns completed runs by the same
+	// adapter over the same window. Regions need anomalyMinHistory such runs
+	// and a median of anomalyMinBaseline listings before they are checked,
+	// so small or new regions never trip it.
+	anomalyHistoryRuns = 5
+	anomalyMinHistory  = 3
+	anomalyMinBaseline = 50
+	anomalyMinRatio    = 0.1
+)
+
+// resultAnomalyError is returned for a run that succeeded but returned far
+// fewer listings than the region normally has
+type resultAnomalyError struct {
+	Count    int
+	Baseline int
+}
+
+func (e *resultAnomalyError) Error() string {
+	return fmt.Sprintf("%d listings, region usually has ~%d", e.Count, e.Baseline)
+}
+
+// scrapeWithFallback scrapes the region with each apify_actor adapter in turn
+// until one succeeds with a plausible result, and returns the listings and
+// the adapter that produced them. If every adapter only returned anomalous
+// results, the largest of them is used rather than failing the region.
+func (h *ApifyHandler) scrapeWithFallback(ctx context.Context, region config.Region) ([]models.RawListing, string, error) {
+	chain := append([]*ApifyHandler{h}, h.fallbacks...)
+	baseline, hasBaseline := h.regionBaseline(ctx, region)
+
+	var report *RegionReport
+	if scope := regionScopeFrom(ctx); scope != nil {
+		report = scope.Report
+	}
+
+	var errs []error
+	var best []models.RawListing
+	bestActor := ""
+	var bestReport RegionReport
+	for i, a := range chain {
+		if report != nil {
+			report.resetAttempt()
+		}
+		listings, err := a.scrape(ctx, region)
+		if err == nil && hasBaseline {
+			if n := len(listings); isAnomalousCount(n, baseline) {
+				err = &resultAnomalyError{Count: n, Baseline: baseline}
+				if bestActor == "" || n > len(best) {
+					best, bestActor = listings, a.actor
+					if report != nil {
+						bestReport = *report
+					}
+				}
+			}
+		}
+		if err == nil {
+			a.reportAdapter(ctx)
+			return listings, a.actor, nil
+		}
+		if ctx.Err() != nil {
+			return nil, "", err
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", a.actor, err))
+		if i < len(chain)-1 {
+			log.Printf("Apify: %s failed for %s (%v), falling back to %s", a.actor, region.GeoName, err, chain[i+1].actor)
+		}
+	}
+
+	if bestActor != "" {
+		log.Printf("Apify: all adapters returned anomalous results for %s, using %s (%d listings)", region.GeoName, bestActor, len(best))
+		if report != nil {
+			report.resetAttempt()
+			report.Schemas = bestReport.Schemas
+			report.Partial = bestReport.Partial
+			report.DaysBack = bestReport.DaysBack
+		}
+		reportPartial(ctx, "anomalously small result")
+		reportCoverage(ctx, bestReport.ExpectedTotal, bestReport.Fetched)
+		h.forActor(bestActor).reportAdapter(ctx)
+		return best, bestActor, nil
+	}
+	return nil, "", errors.Join(errs...)
+}
+
+// regionBaseline returns the median listing count of the region's recent
+// completed runs, if there is enough history to judge a result against.
+// Only results of this adapter over the window it is about to scrape count:
+// a fallback's result or a wider window would set the bar too high.
+// Incremental windows are never checked, as a quiet day can rightly return
+// next to nothing.
+func (h *ApifyHandler) regionBaseline(ctx context.Context, region config.Region) (int, bool) {
+	scope := regionScopeFrom(ctx)
+	if scope == nil || h.pgStore == nil {
+		return 0, false
+	}
+
+	daysBack, ok := baselineWindow(h.window(region))
+	if !ok {
+		return 0, false
+	}
+
+	var runID int64
+	if scope.PgRunID != nil {
+		runID = *scope.PgRunID
+	}
+	counts, err := h.pgStore.GetRecentRegionCounts(ctx, h.cfg.ID, scope.RegionID, h.adapter.ActorID(), daysBack, runID, anomalyHistoryRuns)
+	if err != nil {
+		log.Printf("Warning: failed to load history for %s, skipping anomaly check: %v", scope.RegionID, err)
+		return 0, false
+	}
+	if len(counts) < anomalyMinHistory {
+		return 0, false
+	}
+
+	median := medianInt(counts)
+	if median < anomalyMinBaseline {
+		return 0, false
+	}
+	return median, true
+}
+
+// baselineWindow returns the days_back a result over days must be compared
+// with (nil for adapters without a window), or false for an incremental
+// window, which is not checked
+func baselineWindow(days int) (*int, bool) {
+	if days <= 0 {
+		return nil, true
+	}
+	if days < canadeskMaxDays {
+		return nil, false
+	}
+	return &days, true
+}
+
+// isAnomalousCount reports whether count is implausibly low for baseline
+func isAnomalousCount(count, baseline int) bool {
+	return float64(count) < float64(baseline)*anomalyMinRatio
+}
+
+func medianInt(values []int) int {
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// forActor returns the handler in the fallback chain for an adapter name or
+// actor ID, or a new one sharing this handler's stores if it is no longer
+// configured. Unknown or empty names resolve to this handler.
+func (h *ApifyHandler) forActor(actor string) *ApifyHandler {
+	if actor == "" {
+		return h
+	}
+	for _, a := range append([]*ApifyHandler{h}, h.fallbacks...) {
+		if a.actor == actor || a.adapter.ActorID() == actor {
+			return a
+		}
+	}
+	if _, err := GetApifyAdapter(actor); err != nil {
+		return h
+	}
+
+	a := newApifyHandler(h.cfg, actor)
+	a.store = h.store
+	a.pgStore = h.pgStore
+	a.webhooks = h.webhooks
+	a.archive = h.archive
+	return a
+}
+
+// reportAdapter records in the region report which adapter produced the data
+func (h *ApifyHandler) reportAdapter(ctx context.Context) {
+	if scope := regionScopeFrom(ctx); scope != nil && scope.Report != nil {
+		scope.Report.Adapter = h.actor
+	}
+}
@@ -0,0 +1,78 @@
+package scraper
+
+import (
+	"testing"
+
+	"tct_scrooper/config"
+)
+
+func TestAnomalyCheck(t *testing.T) {
+	baseline := medianInt([]int{420, 390, 12, 450, 400})
+	if baseline != 400 {
+		t.Fatalf("expected median 400, got %d", baseline)
+	}
+
+	if !isAnomalousCount(0, baseline) {
+		t.Fatalf("empty dataset should be anomalous against %d", baseline)
+	}
+	if !isAnomalousCount(25, baseline) {
+		t.Fatalf("25 listings should be anomalous against %d", baseline)
+	}
+	if isAnomalousCount(120, baseline) {
+		t.Fatalf("120 listings should be accepted against %d", baseline)
+	}
+
+	if got := medianInt([]int{10, 30}); got != 20 {
+		t.Fatalf("expected median 20, got %d", got)
+	}
+}
+
+func TestBaselineWindow(t *testing.T) {
+	cases := []struct {
+		name  string
+		days  int
+		want  int // 0 for no days_back filter
+		check bool
+	}{
+		{"unwindowed adapter", 0, 0, true},
+		{"first scrape", canadeskMaxDays, canadeskMaxDays, true},
+		{"daily incremental", 1, 0, false},
+		{"incremental after a gap", canadeskMaxDays - 1, 0, false},
+	}
+	for _, c := range cases {
+		got, check := baselineWindow(c.days)
+		if check != c.check || (got == nil) != (c.want == 0) || (got != nil && *got != c.want) {
+			t.Errorf("%s: expected %d %v, got %v %v", c.name, c.want, c.check, got, check)
+		}
+	}
+}
+
+func TestResetAttemptClearsWindow(t *testing.T) {
+	report := &RegionReport{DaysBack: 1, Partial: "canadesk only returns recently listed properties", ExpectedTotal: 10, Fetched: 10, ApifyUsageUSD: 0.5}
+	report.resetAttempt()
+	if report.DaysBack != 0 || report.Partial != "" || report.ExpectedTotal != 0 || report.Fetched != 0 {
+		t.Fatalf("expected the attempt's result cleared, got %+v", report)
+	}
+	if report.ApifyUsageUSD != 0.5 {
+		t.Fatalf("expected costs kept across attempts, got %v", report.ApifyUsageUSD)
+	}
+}
+
+func TestApifyFullRegion(t *testing.T) {
+	cases := []struct {
+		name  string
+		actor string
+		max   int
+		full  bool
+	}{
+		{"canadesk windows", "canadesk", 0, false},
+		{"capped scrapemind", "scrapemind", 600, false},
+		{"uncapped scrapemind", "scrapemind", 0, true},
+	}
+	for _, c := range cases {
+		h := newApifyHandler(&config.SiteConfig{ID: "a", ApifyMaxListings: c.max}, c.actor)
+		if got := h.fullRegion(); got != c.full {
+			t.Errorf("%s: expected full=%v, got %v", c.name, c.full, got)
+		}
+	}
+}
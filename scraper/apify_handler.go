@@ -4,14 +4,17 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	neturl "net/url"
 	"os"
 	"time"
 
 	"tct_scrooper/config"
+	"tct_scrooper/httputil"
 	"tct_scrooper/models"
 	"tct_scrooper/storage"
 )
@@ -20,34 +23,75 @@ const (
 	apifyAPIBase     = "https://api.apify.com/v2"
 	apifyPollTimeout = 15 * time.Minute
 	apifyPollDelay   = 10 * time.Second
+
+	// With webhooks enabled, polling is only a safety net for missed callbacks
+	apifyWebhookPollDelay = 2 * time.Minute
+	apifyMaxPollErrors    = 5
+
+	apifyDatasetPageSize = 250
 )
 
 type ApifyHandler struct {
-	cfg     *config.SiteConfig
-	client  *http.Client
-	apiKey  string
-	adapter ApifyActorAdapter
-	store   *storage.SQLiteStore
-	pgStore *storage.PostgresStore
+	cfg      *config.SiteConfig
+	client   *http.Client
+	apiKey   string
+	adapter  ApifyActorAdapter
+	actor    string // adapter name from config (canadesk, scrapemind)
+	store    *storage.SQLiteStore
+	pgStore  *storage.PostgresStore
+	webhooks *ApifyWebhookServer
+	archive  *storage.DatasetArchive
+
+	// fallbacks are the remaining apify_actor adapters, tried in order when
+	// this one fails or returns an anomalous result
+	fallbacks []*ApifyHandler
+
+	// shadow scrapes each region with apify_shadow_actor alongside the
+	// primary adapter; its results only go to the comparison tables
+	shadow   *ApifyHandler
+	isShadow bool
 }
 
 func NewApifyHandler(cfg *config.SiteConfig) *ApifyHandler {
-	actorType := cfg.ApifyActor
-	if actorType == "" {
-		actorType = "canadesk"
+	actors := cfg.ApifyActor
+	if len(actors) == 0 {
+		actors = config.ActorList{"canadesk"}
+	}
+
+	h := newApifyHandler(cfg, actors[0])
+	for _, actor := range actors[1:] {
+		if _, err := GetApifyAdapter(actor); err != nil {
+			log.Printf("Warning: %v, skipping fallback", err)
+			continue
+		}
+		h.fallbacks = append(h.fallbacks, newApifyHandler(cfg, actor))
 	}
 
+	if cfg.ApifyShadowActor != "" && cfg.ApifyShadowActor != h.actor {
+		if _, err := GetApifyAdapter(cfg.ApifyShadowActor); err != nil {
+			log.Printf("Warning: %v, shadow mode disabled", err)
+		} else {
+			h.shadow = newApifyHandler(cfg, cfg.ApifyShadowActor)
+			h.shadow.isShadow = true
+		}
+	}
+	return h
+}
+
+func newApifyHandler(cfg *config.SiteConfig, actorType string) *ApifyHandler {
 	adapter, err := GetApifyAdapter(actorType)
 	if err != nil {
 		log.Printf("Warning: %v, using canadesk adapter", err)
 		adapter = &CanadeskAdapter{}
+		actorType = "canadesk"
 	}
 
 	return &ApifyHandler{
 		cfg:     cfg,
 		apiKey:  os.Getenv("APIFY_API_KEY"),
-		client:  &http.Client{Timeout: 60 * time.Second},
+		client:  &http.Client{Transport: httputil.APIRetry.Transport(nil)},
 		adapter: adapter,
+		actor:   actorType,
 	}
 }
 
@@ -57,10 +101,44 @@ func (h *ApifyHandler) ID() string {
 
 func (h *ApifyHandler) SetStore(store *storage.SQLiteStore) {
 	h.store = store
+	for _, fb := range h.fallbacks {
+		fb.SetStore(store)
+	}
+	if h.shadow != nil {
+		h.shadow.SetStore(store)
+	}
 }
 
 func (h *ApifyHandler) SetPgStore(store *storage.PostgresStore) {
 	h.pgStore = store
+	for _, fb := range h.fallbacks {
+		fb.SetPgStore(store)
+	}
+	if h.shadow != nil {
+		h.shadow.SetPgStore(store)
+	}
+}
+
+// SetWebhooks enables webhook-driven completion; polling remains as fallback
+func (h *ApifyHandler) SetWebhooks(server *ApifyWebhookServer) {
+	h.webhooks = server
+	for _, fb := range h.fallbacks {
+		fb.SetWebhooks(server)
+	}
+	if h.shadow != nil {
+		h.shadow.SetWebhooks(server)
+	}
+}
+
+// SetArchive enables archiving of every fetched dataset for later replay
+func (h *ApifyHandler) SetArchive(archive *storage.DatasetArchive) {
+	h.archive = archive
+	for _, fb := range h.fallbacks {
+		fb.SetArchive(archive)
+	}
+	if h.shadow != nil {
+		h.shadow.SetArchive(archive)
+	}
 }
 
 func (h *ApifyHandler) Scrape(ctx context.Context, region config.Region) ([]models.RawListing, error) {
@@ -68,28 +146,127 @@ func (h *ApifyHandler) Scrape(ctx context.Context, region config.Region) ([]mode
 		return nil, fmt.Errorf("APIFY_API_KEY not set")
 	}
 
+	scope := regionScopeFrom(ctx)
+	if h.shadow == nil || h.pgStore == nil || scope == nil || scope.PgRunID == nil || scope.Report == nil {
+		listings, _, err := h.scrapeWithFallback(ctx, region)
+		return listings, err
+	}
+
+	// Run the shadow adapter concurrently with its own report so the two
+	// don't race; its cost is still added to the run's spend afterwards
+	shadowReport := &RegionReport{}
+	shadowScope := *scope
+	shadowScope.Report = shadowReport
+
+	var shadowListings []models.RawListing
+	var shadowErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		shadowListings, shadowErr = h.shadow.scrape(withRegionScope(ctx, &shadowScope), region)
+	}()
+
+	usageBefore := scope.Report.ApifyUsageUSD
+	listings, actor, err := h.scrapeWithFallback(ctx, region)
+	primaryUsage := scope.Report.ApifyUsageUSD - usageBefore
+	<-done
+
+	scope.Report.ApifyUsageUSD += shadowReport.ApifyUsageUSD
+	scope.Report.ApifyComputeUnits += shadowReport.ApifyComputeUnits
+
+	if err == nil {
+		h.saveShadowResult(ctx, scope, "primary", actor, listings, primaryUsage)
+	}
+	if shadowErr != nil {
+		log.Printf("Apify shadow (%s) failed for %s: %v", h.shadow.actor, region.GeoName, shadowErr)
+	} else {
+		h.saveShadowResult(ctx, scope, "shadow", h.shadow.actor, shadowListings, shadowReport.ApifyUsageUSD)
+	}
+
+	return listings, err
+}
+
+func (h *ApifyHandler) scrape(ctx context.Context, region config.Region) ([]models.RawListing, error) {
 	isIncremental := h.hasExistingData()
 	daysBack := h.calculateDaysBack(region)
 
-	// Set days on canadesk adapter if applicable
+	// Set days on a copy of the canadesk adapter; regions may run concurrently
+	adapter := h.adapter
 	if cdk, ok := h.adapter.(*CanadeskAdapter); ok {
-		cdk.DaysBack = daysBack
+		withDays := *cdk
+		withDays.DaysBack = daysBack
+		adapter = &withDays
+		reportWindow(ctx, daysBack)
 	}
 
-	log.Printf("Apify: scraping %s (days=%d, incremental=%v)", region.GeoName, daysBack, isIncremental)
+	log.Printf("Apify: scraping %s with %s (days=%d, incremental=%v)", region.GeoName, h.actor, daysBack, isIncremental)
 
-	runID, err := h.startRun(ctx, region, isIncremental)
+	runID, err := h.startRun(ctx, adapter, region, isIncremental)
 	if err != nil {
 		return nil, fmt.Errorf("failed to start apify run: %w", err)
 	}
 	log.Printf("Apify run started: %s (actor: %s)", runID, h.adapter.ActorID())
+	h.recordRun(ctx, runID, "RUNNING", "")
 
 	datasetID, err := h.waitForRun(ctx, runID)
 	if err != nil {
+		if runCancelled(ctx) {
+			h.abortRun(ctx, runID)
+		}
+		var runErr *apifyRunError
+		if errors.As(err, &runErr) {
+			h.recordRun(ctx, runID, runErr.Status, "")
+			h.recordUsage(ctx, runID)
+		}
 		return nil, fmt.Errorf("apify run failed: %w", err)
 	}
 	log.Printf("Apify run complete, dataset: %s", datasetID)
+	h.recordRun(ctx, runID, "SUCCEEDED", datasetID)
+	h.recordUsage(ctx, runID)
 
+	return h.collect(ctx, region, datasetID)
+}
+
+// Resume reattaches to an actor run started before a restart, waits for it
+// if it is still going, and returns its listings without starting a new run.
+// actor is the adapter recorded for the run, which may be a fallback.
+func (h *ApifyHandler) Resume(ctx context.Context, region config.Region, runID, actor string) ([]models.RawListing, error) {
+	if h.apiKey == "" {
+		return nil, fmt.Errorf("APIFY_API_KEY not set")
+	}
+	if a := h.forActor(actor); a != h {
+		return a.Resume(ctx, region, runID, actor)
+	}
+	h.reportAdapter(ctx)
+
+	status, datasetID, err := h.getRunStatus(ctx, runID)
+	if err != nil {
+		return nil, fmt.Errorf("apify run %s: %w", runID, err)
+	}
+	log.Printf("Apify: reattaching to run %s for %s (status %s)", runID, region.GeoName, status)
+
+	done, datasetID, err := runOutcome(runID, status, datasetID)
+	if !done {
+		datasetID, err = h.waitForRun(ctx, runID)
+		if err != nil && runCancelled(ctx) {
+			h.abortRun(ctx, runID)
+		}
+	}
+	if err != nil {
+		var runErr *apifyRunError
+		if errors.As(err, &runErr) {
+			h.recordRun(ctx, runID, runErr.Status, "")
+			h.recordUsage(ctx, runID)
+		}
+		return nil, fmt.Errorf("apify run failed: %w", err)
+	}
+	h.recordRun(ctx, runID, "SUCCEEDED", datasetID)
+	h.recordUsage(ctx, runID)
+
+	return h.collect(ctx, region, datasetID)
+}
+
+func (h *ApifyHandler) collect(ctx context.Context, region config.Region, datasetID string) ([]models.RawListing, error) {
 	listings, err := h.fetchDataset(ctx, datasetID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch dataset: %w", err)
@@ -97,9 +274,64 @@ func (h *ApifyHandler) Scrape(ctx context.Context, region config.Region) ([]mode
 
 	filtered := h.adapter.FilterListings(listings, region)
 	log.Printf("Fetched %d listings from Apify, %d after filtering", len(listings), len(filtered))
+
+	if _, ok := h.adapter.(*CanadeskAdapter); ok {
+		reportPartial(ctx, "canadesk only returns recently listed properties")
+	} else if max := h.maxListings(ctx); max > 0 && len(listings) >= max {
+		reportPartial(ctx, fmt.Sprintf("capped at %d listings", max))
+	}
 	return filtered, nil
 }
 
+// recordRun persists the actor run against the current region of the scrape
+// run, so it can be reattached if the daemon restarts before ingesting it
+func (h *ApifyHandler) recordRun(ctx context.Context, runID, status, datasetID string) {
+	scope := regionScopeFrom(ctx)
+	if scope == nil || scope.PgRunID == nil || h.pgStore == nil || h.isShadow {
+		return
+	}
+
+	err := h.pgStore.UpsertScrapeRunRegion(context.WithoutCancel(ctx), &models.ScrapeRunRegion{
+		RunID:          *scope.PgRunID,
+		Region:         scope.RegionID,
+		Status:         "running",
+		ApifyRunID:     runID,
+		ApifyActor:     h.adapter.ActorID(),
+		ApifyStatus:    status,
+		ApifyDatasetID: datasetID,
+	})
+	if err != nil {
+		log.Printf("Warning: failed to record apify run %s: %v", runID, err)
+	}
+}
+
+// saveShadowResult stores one side of a shadow comparison
+func (h *ApifyHandler) saveShadowResult(ctx context.Context, scope *regionScope, role, adapter string, listings []models.RawListing, usage float64) {
+	result := &models.ShadowResult{
+		RunID:    *scope.PgRunID,
+		Region:   scope.RegionID,
+		Adapter:  adapter,
+		Role:     role,
+		UsageUSD: usage,
+	}
+	for _, l := range listings {
+		if l.MLS == "" {
+			continue
+		}
+		result.Listings = append(result.Listings, models.ShadowListing{
+			MLS:        l.MLS,
+			Address:    l.Address,
+			PostalCode: l.PostalCode,
+			Price:      l.Price,
+			Beds:       l.Beds,
+		})
+	}
+
+	if err := h.pgStore.SaveShadowResult(context.WithoutCancel(ctx), result); err != nil {
+		log.Printf("Warning: failed to save %s shadow result for %s: %v", role, scope.RegionID, err)
+	}
+}
+
 func (h *ApifyHandler) hasExistingData() bool {
 	if h.store == nil {
 		return false
@@ -112,9 +344,28 @@ func (h *ApifyHandler) hasExistingData() bool {
 	return count > 0
 }
 
+// fullRegion reports whether the primary adapter can return a region's full
+// listing set, which disappearance detection needs: canadesk only returns
+// recent listings and apify_max_listings caps the rest
+func (h *ApifyHandler) fullRegion() bool {
+	if _, ok := h.adapter.(*CanadeskAdapter); ok {
+		return false
+	}
+	return h.cfg.ApifyMaxListings <= 0
+}
+
+// window is the listing window in days the region would be scraped with,
+// or 0 for adapters that return the region's full listing set
+func (h *ApifyHandler) window(region config.Region) int {
+	if _, ok := h.adapter.(*CanadeskAdapter); !ok {
+		return 0
+	}
+	return h.calculateDaysBack(region)
+}
+
 func (h *ApifyHandler) calculateDaysBack(region config.Region) int {
 	if h.store == nil {
-		return 30
+		return canadeskMaxDays
 	}
 
 	// Check if this region has any listings - if not, do full 30-day scrape
@@ -123,8 +374,8 @@ func (h *ApifyHandler) calculateDaysBack(region config.Region) int {
 		if cityName != "" {
 			count, err := h.pgStore.GetListingCountByCity(context.Background(), cityName)
 			if err == nil && count == 0 {
-				log.Printf("No existing listings for %s, using 30 days", cityName)
-				return 30
+				log.Printf("No existing listings for %s, using %d days", cityName, canadeskMaxDays)
+				return canadeskMaxDays
 			}
 		}
 	}
@@ -132,11 +383,11 @@ func (h *ApifyHandler) calculateDaysBack(region config.Region) int {
 	// Region has data, use incremental based on last run
 	lastRun, err := h.store.GetLastRunTime(h.cfg.ID)
 	if err != nil || lastRun.IsZero() {
-		return 30
+		return canadeskMaxDays
 	}
 	days := int(time.Since(lastRun).Hours()/24) + 1
-	if days > 30 {
-		days = 30
+	if days > canadeskMaxDays {
+		days = canadeskMaxDays
 	}
 	if days < 1 {
 		days = 1
@@ -144,12 +395,18 @@ func (h *ApifyHandler) calculateDaysBack(region config.Region) int {
 	return days
 }
 
-func (h *ApifyHandler) startRun(ctx context.Context, region config.Region, isIncremental bool) (string, error) {
-	input := h.adapter.BuildInput(region, isIncremental)
+func (h *ApifyHandler) startRun(ctx context.Context, adapter ApifyActorAdapter, region config.Region, isIncremental bool) (string, error) {
+	input := adapter.BuildInput(region, isIncremental)
 	body, _ := json.Marshal(input)
 	log.Printf("Apify input: %s", string(body))
 
-	url := fmt.Sprintf("%s/acts/%s/runs?token=%s", apifyAPIBase, h.adapter.ActorID(), h.apiKey)
+	url := fmt.Sprintf("%s/acts/%s/runs?token=%s", apifyAPIBase, adapter.ActorID(), h.apiKey)
+	if maxItems := h.maxListings(ctx); maxItems > 0 {
+		url += fmt.Sprintf("&maxItems=%d", maxItems)
+	}
+	if h.webhooks != nil {
+		url += "&webhooks=" + neturl.QueryEscape(h.webhooks.WebhooksParam())
+	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
 	if err != nil {
@@ -180,53 +437,136 @@ func (h *ApifyHandler) startRun(ctx context.Context, region config.Region, isInc
 	return result.Data.ID, nil
 }
 
+// maxListings is the cap on results for this run: the budget downscale set by
+// the orchestrator if any, else apify_max_listings
+func (h *ApifyHandler) maxListings(ctx context.Context) int {
+	if scope := regionScopeFrom(ctx); scope != nil && scope.MaxListings > 0 {
+		return scope.MaxListings
+	}
+	return h.cfg.ApifyMaxListings
+}
+
+// waitForRun blocks until the run finishes and returns its dataset ID. With
+// webhooks enabled it wakes on the callback and polls rarely as a fallback.
 func (h *ApifyHandler) waitForRun(ctx context.Context, runID string) (string, error) {
-	url := fmt.Sprintf("%s/actor-runs/%s?token=%s", apifyAPIBase, runID, h.apiKey)
-	deadline := time.Now().Add(apifyPollTimeout)
+	var events <-chan apifyRunEvent
+	pollDelay := apifyPollDelay
+	if h.webhooks != nil {
+		events = h.webhooks.Register(runID)
+		defer h.webhooks.Unregister(runID)
+		pollDelay = apifyWebhookPollDelay
+	}
+
+	deadline := time.NewTimer(apifyPollTimeout)
+	defer deadline.Stop()
+	poll := time.NewTicker(pollDelay)
+	defer poll.Stop()
 
-	for time.Now().Before(deadline) {
+	pollErrors := 0
+	for {
 		select {
 		case <-ctx.Done():
 			return "", ctx.Err()
-		default:
-		}
+		case <-deadline.C:
+			return "", fmt.Errorf("timeout waiting for run %s", runID)
+		case event := <-events:
+			if done, datasetID, err := runOutcome(runID, event.Status, event.DatasetID); done {
+				return datasetID, err
+			}
+			// Non-terminal status in a callback; keep waiting on polls
+			events = nil
+		case <-poll.C:
+			status, datasetID, err := h.getRunStatus(ctx, runID)
+			if err != nil {
+				pollErrors++
+				log.Printf("Apify run %s: status check failed (%d/%d): %v", runID, pollErrors, apifyMaxPollErrors, err)
+				if pollErrors >= apifyMaxPollErrors {
+					return "", fmt.Errorf("run %s: status checks failing: %w", runID, err)
+				}
+				continue
+			}
+			pollErrors = 0
 
-		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-		if err != nil {
-			return "", err
+			if done, datasetID, err := runOutcome(runID, status, datasetID); done {
+				return datasetID, err
+			}
+			log.Printf("Apify run status: %s", status)
 		}
+	}
+}
 
-		resp, err := h.client.Do(req)
-		if err != nil {
-			time.Sleep(apifyPollDelay)
-			continue
-		}
+// abortRun stops an actor run of a cancelled scrape, so it is not billed
+// further, and records what it cost up to then
+func (h *ApifyHandler) abortRun(ctx context.Context, runID string) {
+	ctx = context.WithoutCancel(ctx)
+	url := fmt.Sprintf("%s/actor-runs/%s/abort?token=%s", apifyAPIBase, runID, h.apiKey)
 
-		var result struct {
-			Data struct {
-				Status           string `json:"status"`
-				DefaultDatasetID string `json:"defaultDatasetId"`
-			} `json:"data"`
-		}
-		json.NewDecoder(resp.Body).Decode(&result)
-		resp.Body.Close()
-
-		switch result.Data.Status {
-		case "SUCCEEDED":
-			return result.Data.DefaultDatasetID, nil
-		case "FAILED", "ABORTED", "TIMED-OUT":
-			return "", fmt.Errorf("run %s: %s", runID, result.Data.Status)
-		}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
+	if err != nil {
+		log.Printf("Warning: failed to abort apify run %s: %v", runID, err)
+		return
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		log.Printf("Warning: failed to abort apify run %s: %v", runID, err)
+		return
+	}
+	defer resp.Body.Close()
 
-		log.Printf("Apify run status: %s", result.Data.Status)
-		time.Sleep(apifyPollDelay)
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		log.Printf("Warning: failed to abort apify run %s: status %d: %s", runID, resp.StatusCode, string(respBody))
+		return
 	}
+	log.Printf("Apify run %s aborted", runID)
+	h.recordRun(ctx, runID, "ABORTED", "")
+	h.recordUsage(ctx, runID)
+}
 
-	return "", fmt.Errorf("timeout waiting for run %s", runID)
+// apifyRunError is returned when an actor run ends in a non-success state
+type apifyRunError struct {
+	RunID  string
+	Status string
 }
 
-func (h *ApifyHandler) fetchDataset(ctx context.Context, datasetID string) ([]models.RawListing, error) {
-	url := fmt.Sprintf("%s/datasets/%s/items?token=%s&format=json", apifyAPIBase, datasetID, h.apiKey)
+func (e *apifyRunError) Error() string {
+	return fmt.Sprintf("run %s: %s", e.RunID, e.Status)
+}
+
+// runOutcome reports whether status is terminal, and the dataset or error if so
+func runOutcome(runID, status, datasetID string) (bool, string, error) {
+	switch status {
+	case "SUCCEEDED":
+		if datasetID == "" {
+			return true, "", fmt.Errorf("run %s succeeded without a dataset", runID)
+		}
+		return true, datasetID, nil
+	case "FAILED", "ABORTED", "TIMED-OUT":
+		return true, "", &apifyRunError{RunID: runID, Status: status}
+	}
+	return false, "", nil
+}
+
+// apifyRun is the subset of the actor run object the handler uses
+type apifyRun struct {
+	Status           string  `json:"status"`
+	DefaultDatasetID string  `json:"defaultDatasetId"`
+	UsageTotalUSD    float64 `json:"usageTotalUsd"`
+	Stats            struct {
+		ComputeUnits float64 `json:"computeUnits"`
+	} `json:"stats"`
+}
+
+func (h *ApifyHandler) getRunStatus(ctx context.Context, runID string) (string, string, error) {
+	run, err := h.getRun(ctx, runID)
+	if err != nil {
+		return "", "", err
+	}
+	return run.Status, run.DefaultDatasetID, nil
+}
+
+func (h *ApifyHandler) getRun(ctx context.Context, runID string) (*apifyRun, error) {
+	url := fmt.Sprintf("%s/actor-runs/%s?token=%s", apifyAPIBase, runID, h.apiKey)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -241,23 +581,203 @@ func (h *ApifyHandler) fetchDataset(ctx context.Context, datasetID string) ([]mo
 
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("dataset fetch failed %d: %s", resp.StatusCode, string(respBody))
+		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, string(respBody))
 	}
 
-	var items []json.RawMessage
-	if err := json.NewDecoder(resp.Body).Decode(&items); err != nil {
-		return nil, err
+	var result struct {
+		Data apifyRun `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode run status: %w", err)
+	}
+
+	return &result.Data, nil
+}
+
+// recordUsage reads what a finished actor run cost and adds it to the region
+// report, so it ends up in the scrape run metadata and counts toward the
+// site's monthly budget. Failed runs are billed too.
+func (h *ApifyHandler) recordUsage(ctx context.Context, runID string) {
+	scope := regionScopeFrom(ctx)
+	if scope == nil || scope.Report == nil {
+		return
+	}
+
+	run, err := h.getRun(context.WithoutCancel(ctx), runID)
+	if err != nil {
+		log.Printf("Warning: failed to read usage of apify run %s: %v", runID, err)
+		return
+	}
+	scope.Report.ApifyUsageUSD += run.UsageTotalUSD
+	scope.Report.ApifyComputeUnits += run.Stats.ComputeUnits
+	log.Printf("Apify run %s usage: $%.4f (%.3f CU)", runID, run.UsageTotalUSD, run.Stats.ComputeUnits)
+}
+
+// fetchDataset reads the dataset a page at a time (offset/limit), parsing
+// and archiving each page as it arrives; the parsed listings are returned
+// together. Items the adapter can't parse are counted as parse failures on
+// the region report, along with the listings fetched and the payloads'
+// schema for drift checks. No expected total is reported: the dataset only
+// holds what the actor got to, so its size says nothing about the region.
+func (h *ApifyHandler) fetchDataset(ctx context.Context, datasetID string) ([]models.RawListing, error) {
+	var listings []models.RawListing
+	parseFailures := 0
+
+	archive := h.createArchive(ctx)
+
+	var required []string
+	if r, ok := h.adapter.(schemaRequirer); ok {
+		required = r.RequiredPaths()
+	}
+	schema := NewSchemaProfile(required)
+
+	for offset := 0; ; offset += apifyDatasetPageSize {
+		items, err := h.fetchDatasetPage(ctx, datasetID, offset)
+		if err != nil {
+			h.reportParseFailures(ctx, parseFailures)
+			if archive != nil {
+				archive.Close()
+				os.Remove(archive.Path())
+			}
+			return nil, fmt.Errorf("offset %d: %w", offset, err)
+		}
+
+		for _, item := range items {
+			schema.Add(item)
+			if archive != nil {
+				if err := archive.Write(item); err != nil {
+					log.Printf("Warning: archive write failed, disabling archive for this dataset: %v", err)
+					archive.Close()
+					os.Remove(archive.Path())
+					archive = nil
+				}
+			}
+
+			listing, err := h.adapter.ParseListing(item)
+			if err != nil {
+				parseFailures++
+				log.Printf("Failed to parse listing: %v", err)
+				continue
+			}
+			listings = append(listings, listing)
+		}
+
+		if len(items) < apifyDatasetPageSize {
+			break
+		}
+	}
+
+	if archive != nil {
+		if err := archive.Close(); err != nil {
+			log.Printf("Warning: failed to close archive %s: %v", archive.Path(), err)
+		} else {
+			log.Printf("Archived %d items from dataset %s to %s", archive.Count(), datasetID, archive.Path())
+		}
+	}
+
+	if parseFailures > 0 {
+		log.Printf("Apify dataset %s: %d items failed to parse", datasetID, parseFailures)
+	}
+	h.reportParseFailures(ctx, parseFailures)
+	reportCoverage(ctx, 0, len(listings))
+	reportSchema(ctx, h.actor, schema)
+	return listings, nil
+}
+
+// createArchive opens an archive file for the region being scraped, or
+// returns nil when archiving is off or there is no run context (replays)
+func (h *ApifyHandler) createArchive(ctx context.Context) *storage.ArchiveWriter {
+	scope := regionScopeFrom(ctx)
+	if h.archive == nil || scope == nil || scope.PgRunID == nil {
+		return nil
+	}
+
+	w, err := h.archive.Create(storage.ArchiveKey{
+		Site:      h.cfg.ID,
+		Region:    scope.RegionID,
+		RunID:     *scope.PgRunID,
+		Timestamp: time.Now(),
+		Adapter:   h.actor,
+	})
+	if err != nil {
+		log.Printf("Warning: failed to create dataset archive: %v", err)
+		return nil
+	}
+	return w
+}
+
+// replay re-reads listings from an archive file or, if source is not a file,
+// from an existing Apify dataset ID
+func (h *ApifyHandler) replay(ctx context.Context, region config.Region, source string) ([]models.RawListing, error) {
+	if _, err := os.Stat(source); err != nil {
+		log.Printf("Replay: %s is not a file, fetching it as an Apify dataset", source)
+		return h.collect(ctx, region, source)
+	}
+
+	adapter, actor := h.adapter, h.actor
+	if key := storage.ParseArchivePath(source); key.Adapter != "" && key.Adapter != h.actor {
+		a, err := GetApifyAdapter(key.Adapter)
+		if err != nil {
+			return nil, err
+		}
+		adapter, actor = a, key.Adapter
+	}
+	if scope := regionScopeFrom(ctx); scope != nil && scope.Report != nil {
+		scope.Report.Adapter = actor
 	}
 
 	var listings []models.RawListing
-	for _, item := range items {
-		listing, err := h.adapter.ParseListing(item)
+	parseFailures := 0
+	err := storage.ReadArchive(source, func(item json.RawMessage) error {
+		listing, err := adapter.ParseListing(item)
 		if err != nil {
+			parseFailures++
 			log.Printf("Failed to parse listing: %v", err)
-			continue
+			return nil
 		}
 		listings = append(listings, listing)
+		return nil
+	})
+	h.reportParseFailures(ctx, parseFailures)
+	if err != nil {
+		return nil, err
 	}
 
-	return listings, nil
+	filtered := adapter.FilterListings(listings, region)
+	log.Printf("Replay: read %d listings from %s, %d after filtering (%d parse failures)",
+		len(listings), source, len(filtered), parseFailures)
+	return filtered, nil
+}
+
+func (h *ApifyHandler) reportParseFailures(ctx context.Context, n int) {
+	if scope := regionScopeFrom(ctx); scope != nil && scope.Report != nil {
+		scope.Report.ParseFailures += n
+	}
+}
+
+func (h *ApifyHandler) fetchDatasetPage(ctx context.Context, datasetID string, offset int) ([]json.RawMessage, error) {
+	url := fmt.Sprintf("%s/datasets/%s/items?token=%s&format=json&offset=%d&limit=%d",
+		apifyAPIBase, datasetID, h.apiKey, offset, apifyDatasetPageSize)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("dataset fetch failed %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var items []json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&items); err != nil {
+		return nil, fmt.Errorf("decode dataset page: %w", err)
+	}
+	return items, nil
 }
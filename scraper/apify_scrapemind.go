@@ -77,6 +77,15 @@ func (a *ScrapemindAdapter) ParseListing(data json.RawMessage) (models.RawListin
 	return listing, nil
 }
 
+// RequiredPaths lists the payload fields ParseListing depends on
+func (a *ScrapemindAdapter) RequiredPaths() []string {
+	return []string{
+		"Id", "MlsNumber",
+		"Property.Price", "Property.Address.AddressText", "Property.Address.Province",
+		"Building.Bedrooms", "Building.BathroomTotal", "Building.SizeInterior",
+	}
+}
+
 // scrapemindListing mirrors the Realtor.ca API response structure
 type scrapemindListing struct {
 	ID            string `json:"Id"`
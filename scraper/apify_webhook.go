@@ -0,0 +1,202 @@
+package scraper
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"tct_scrooper/config"
+)
+
+const (
+	apifyWebhookPath   = "/apify/webhook"
+	apifySecretHeader  = "X-Apify-Webhook-Secret"
+	apifyEarlyEventTTL = time.Hour
+)
+
+// apifyRunEvent is the part of an Apify run we need to continue processing
+type apifyRunEvent struct {
+	RunID     string
+	Status    string
+	DatasetID string
+}
+
+// ApifyWebhookServer receives Apify run-finished callbacks so handlers can
+// wait on a channel instead of polling. Callbacks must carry the shared secret.
+type ApifyWebhookServer struct {
+	cfg    config.ApifyConfig
+	server *http.Server
+
+	mu      sync.Mutex
+	waiters map[string]chan apifyRunEvent
+	early   map[string]earlyEvent // callbacks that arrived before the handler registered
+}
+
+type earlyEvent struct {
+	event      apifyRunEvent
+	receivedAt time.Time
+}
+
+func NewApifyWebhookServer(cfg config.ApifyConfig) *ApifyWebhookServer {
+	s := &ApifyWebhookServer{
+		cfg:     cfg,
+		waiters: make(map[string]chan apifyRunEvent),
+		early:   make(map[string]earlyEvent),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(apifyWebhookPath, s.handleWebhook)
+	s.server = &http.Server{
+		Addr:              cfg.WebhookListen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// Start binds the listener and serves in the background
+func (s *ApifyWebhookServer) Start() error {
+	ln, err := net.Listen("tcp", s.cfg.WebhookListen)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		if err := s.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Apify webhook server stopped: %v", err)
+		}
+	}()
+
+	log.Printf("Apify webhook receiver listening on %s (public: %s%s)", s.cfg.WebhookListen, s.cfg.WebhookURL, apifyWebhookPath)
+	return nil
+}
+
+func (s *ApifyWebhookServer) Stop(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+// WebhooksParam returns the base64-encoded ad-hoc webhook definition passed as
+// the "webhooks" query parameter when starting an actor run
+func (s *ApifyWebhookServer) WebhooksParam() string {
+	headers, _ := json.Marshal(map[string]string{apifySecretHeader: s.cfg.WebhookSecret})
+	webhooks := []map[string]interface{}{
+		{
+			"eventTypes": []string{
+				"ACTOR.RUN.SUCCEEDED",
+				"ACTOR.RUN.FAILED",
+				"ACTOR.RUN.ABORTED",
+				"ACTOR.RUN.TIMED_OUT",
+			},
+			"requestUrl":      strings.TrimRight(s.cfg.WebhookURL, "/") + apifyWebhookPath,
+			"headersTemplate": string(headers),
+		},
+	}
+	data, _ := json.Marshal(webhooks)
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+// Register returns a channel that receives the terminal event for runID.
+// Call Unregister when done waiting.
+func (s *ApifyWebhookServer) Register(runID string) <-chan apifyRunEvent {
+	ch := make(chan apifyRunEvent, 1)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.early[runID]; ok {
+		delete(s.early, runID)
+		ch <- e.event
+		return ch
+	}
+	s.waiters[runID] = ch
+	return ch
+}
+
+func (s *ApifyWebhookServer) Unregister(runID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.waiters, runID)
+}
+
+func (s *ApifyWebhookServer) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	secret := r.Header.Get(apifySecretHeader)
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(s.cfg.WebhookSecret)) != 1 {
+		log.Printf("Apify webhook: rejected callback from %s (bad secret)", r.RemoteAddr)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	var payload struct {
+		EventType string `json:"eventType"`
+		EventData struct {
+			ActorRunID string `json:"actorRunId"`
+		} `json:"eventData"`
+		Resource struct {
+			ID               string `json:"id"`
+			Status           string `json:"status"`
+			DefaultDatasetID string `json:"defaultDatasetId"`
+		} `json:"resource"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		log.Printf("Apify webhook: invalid payload: %v", err)
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	event := apifyRunEvent{
+		RunID:     payload.EventData.ActorRunID,
+		Status:    payload.Resource.Status,
+		DatasetID: payload.Resource.DefaultDatasetID,
+	}
+	if event.RunID == "" {
+		event.RunID = payload.Resource.ID
+	}
+	if event.RunID == "" {
+		http.Error(w, "missing run id", http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("Apify webhook: %s for run %s", payload.EventType, event.RunID)
+	s.dispatch(event)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *ApifyWebhookServer) dispatch(event apifyRunEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ch, ok := s.waiters[event.RunID]; ok {
+		delete(s.waiters, event.RunID)
+		ch <- event
+		return
+	}
+
+	// Nobody waiting yet; keep it briefly in case the handler registers late
+	now := time.Now()
+	for id, e := range s.early {
+		if now.Sub(e.receivedAt) > apifyEarlyEventTTL {
+			delete(s.early, id)
+		}
+	}
+	s.early[event.RunID] = earlyEvent{event: event, receivedAt: now}
+}
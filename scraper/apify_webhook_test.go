@@ -0,0 +1,50 @@
+package scraper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"tct_scrooper/config"
+)
+
+func TestApifyWebhook_SecretAndDispatch(t *testing.T) {
+	s := NewApifyWebhookServer(config.ApifyConfig{WebhookURL: "https://example.com", WebhookSecret: "s3cret"})
+	payload := `{"eventType":"ACTOR.RUN.SUCCEEDED","eventData":{"actorRunId":"run1"},"resource":{"id":"run1","status":"SUCCEEDED","defaultDatasetId":"ds1"}}`
+
+	post := func(secret string) int {
+		req := httptest.NewRequest(http.MethodPost, apifyWebhookPath, strings.NewReader(payload))
+		if secret != "" {
+			req.Header.Set(apifySecretHeader, secret)
+		}
+		rec := httptest.NewRecorder()
+		s.handleWebhook(rec, req)
+		return rec.Code
+	}
+
+	events := s.Register("run1")
+
+	if code := post("wrong"); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for bad secret, got %d", code)
+	}
+	select {
+	case <-events:
+		t.Fatalf("unauthenticated callback was dispatched")
+	default:
+	}
+
+	if code := post("s3cret"); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	event := <-events
+	if event.Status != "SUCCEEDED" || event.DatasetID != "ds1" {
+		t.Fatalf("unexpected event %+v", event)
+	}
+
+	// Callback before Register is held for the late waiter
+	post("s3cret")
+	if event := <-s.Register("run1"); event.DatasetID != "ds1" {
+		t.Fatalf("expected early event, got %+v", event)
+	}
+}
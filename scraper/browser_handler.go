@@ -144,29 +144,39 @@ import (
 	"math/rand"
 	"net/url"
 	"os"
-	"path/filepath"
 	"sync"
 	"time"
 
 	"github.com/playwright-community/playwright-go"
+	"tct_scrooper/browser"
+	"tct_scrooper/challenge"
 	"tct_scrooper/config"
+	"tct_scrooper/httputil"
 	"tct_scrooper/models"
 	"tct_scrooper/storage"
+	"tct_scrooper/vpn"
 )
 
 const (
 	listingsPerPage = 12
 	minPageDelay    = 15 * time.Second
 	maxPageDelay    = 25 * time.Second
+
+	realtorCAHost = "www.realtor.ca"
 )
 
+// realtorCAContentMarkers are on search pages that loaded; a page with them
+// isn't a challenge whatever else it says
+var realtorCAContentMarkers = []string{"listingCard", "ResultsPaginationCon"}
+
 type BrowserHandler struct {
-	cfg         *config.SiteConfig
-	store       *storage.SQLiteStore
-	pw          *playwright.Playwright
-	context     playwright.BrowserContext
-	mu          sync.Mutex
-	initialized bool
+	cfg     *config.SiteConfig
+	store   *storage.SQLiteStore
+	pool    *browser.Pool
+	limiter *httputil.HostLimiter
+	vpn     *vpn.Manager
+	mu      sync.Mutex
+	lease   *browser.Lease // the site's browser profile, held for a scrape
 
 	activePage     playwright.Page
 	currentPageNum int
@@ -174,6 +184,16 @@ type BrowserHandler struct {
 	lastGeoID      string
 	lastGeoName    string
 	warmupListings map[int][]models.RawListing
+
+	schema       *SchemaProfile // payload fingerprint of the region being scraped
+	totalRecords int            // region total from the search responses' Paging
+}
+
+// realtorCARequiredPaths are the PropertySearch result fields the parser uses
+var realtorCARequiredPaths = []string{
+	"Id", "MlsNumber", "PostalCode",
+	"Property.Price", "Property.Address.AddressText",
+	"Building.Bedrooms", "Building.BathroomTotal", "Building.SizeInterior",
 }
 
 func NewBrowserHandler(cfg *config.SiteConfig) *BrowserHandler {
@@ -184,6 +204,24 @@ func (h *BrowserHandler) SetStore(store *storage.SQLiteStore) {
 	h.store = store
 }
 
+// SetBrowserPool gives the handler the shared browser pool, whose profile
+// for the site it leases for each scrape
+func (h *BrowserHandler) SetBrowserPool(pool *browser.Pool) {
+	h.pool = pool
+}
+
+// SetRateLimiter makes the handler take a turn on the shared per-host limiter
+// before each page it loads
+func (h *BrowserHandler) SetRateLimiter(limiter *httputil.HostLimiter) {
+	h.limiter = limiter
+}
+
+// SetVPN makes the handler report bot-protection blocks to the VPN manager, which
+// rotates the tunnel's region after several in a row
+func (h *BrowserHandler) SetVPN(m *vpn.Manager) {
+	h.vpn = m
+}
+
 func (h *BrowserHandler) ID() string {
 	return h.cfg.ID
 }
@@ -196,21 +234,43 @@ func (h *BrowserHandler) Scrape(ctx context.Context, region config.Region) ([]mo
 }
 
 func (h *BrowserHandler) scrapeRealtorCA(ctx context.Context, region config.Region) ([]models.RawListing, error) {
-	if err := h.ensureBrowser(); err != nil {
+	if err := h.ensureBrowser(ctx); err != nil {
 		return nil, err
 	}
+	defer h.Close()
 
-	if err := h.startSession(region); err != nil {
+	// Playwright calls don't take a context; closing the browser is what
+	// interrupts a page in progress when the run is cancelled or shut down
+	stop := context.AfterFunc(ctx, h.interrupt)
+	defer stop()
+
+	h.schema = NewSchemaProfile(realtorCARequiredPaths)
+	h.totalRecords = 0
+	if err := h.limiter.Wait(ctx, realtorCAHost); err != nil {
+		return nil, err
+	}
+	if err := h.startSession(ctx, region); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		h.reportBlock(ctx, err)
 		return nil, err
 	}
-	defer h.Close()
 
 	var allListings []models.RawListing
 
 	for page := 1; ; page++ {
+		if err := h.limiter.Wait(ctx, realtorCAHost); err != nil {
+			return nil, err
+		}
 		listings, err := h.navigateToPage(page)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		if err != nil {
 			log.Printf("Error on page %d: %v", page, err)
+			h.reportBlock(ctx, err)
+			reportPartial(ctx, fmt.Sprintf("stopped at page %d: %v", page, err))
 			break
 		}
 
@@ -230,69 +290,83 @@ func (h *BrowserHandler) scrapeRealtorCA(ctx context.Context, region config.Regi
 		// Human-like delay between pages
 		delay := minPageDelay + time.Duration(rand.Intn(int(maxPageDelay-minPageDelay)))
 		log.Printf("Sleeping %.1fs before next page", delay.Seconds())
-		time.Sleep(delay)
+		if !sleepCtx(ctx, delay) {
+			return nil, ctx.Err()
+		}
 	}
 
+	reportSchema(ctx, "browser:"+h.cfg.ID, h.schema)
+	reportCoverage(ctx, h.totalRecords, len(allListings))
 	return allListings, nil
 }
 
-func (h *BrowserHandler) ensureBrowser() error {
-	h.mu.Lock()
-	defer h.mu.Unlock()
-
-	if h.initialized {
-		return nil
+// ensureBrowser leases the site's browser profile, waiting while the
+// enrichment worker has it
+func (h *BrowserHandler) ensureBrowser(ctx context.Context) error {
+	if h.pool == nil {
+		return fmt.Errorf("no browser pool for %s", h.cfg.ID)
 	}
-
-	var err error
-	h.pw, err = playwright.Run()
-	if err != nil {
-		return fmt.Errorf("failed to start playwright: %w", err)
-	}
-
-	cwd, _ := os.Getwd()
-	userDataDir := filepath.Join(cwd, "browser_data")
-	h.context, err = h.pw.Chromium.LaunchPersistentContext(userDataDir, playwright.BrowserTypeLaunchPersistentContextOptions{
-		Headless: playwright.Bool(false),
-		Args: []string{
-			"--disable-blink-features=AutomationControlled",
-			"--disable-dev-shm-usage",
-			"--no-sandbox",
-		},
-	})
+	lease, err := h.pool.Acquire(ctx, h.cfg.ID, "scraper")
 	if err != nil {
-		return fmt.Errorf("failed to launch browser: %w", err)
+		return err
 	}
-
-	h.initialized = true
+	h.mu.Lock()
+	h.lease = lease
+	h.mu.Unlock()
 	return nil
 }
 
+// Close closes the session's page and hands the profile back to the pool
 func (h *BrowserHandler) Close() {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	if h.activePage != nil {
-		h.activePage.Close()
-		h.activePage = nil
+	if h.lease != nil {
+		h.lease.ClosePage(h.activePage)
+		h.lease.Release()
+		h.lease = nil
 	}
-	if h.context != nil {
-		h.context.Close()
+	h.activePage = nil
+	h.warmedUp = false
+}
+
+// interrupt closes the browser under a scrape in progress, making its pending
+// and later page calls fail; the scrape's own Close cleans up after it
+func (h *BrowserHandler) interrupt() {
+	h.mu.Lock()
+	lease := h.lease
+	h.mu.Unlock()
+	if lease != nil {
+		log.Printf("Closing browser session for %s", h.cfg.ID)
+		lease.Recycle("run cancelled")
 	}
-	if h.pw != nil {
-		h.pw.Stop()
+}
+
+// reportBlock notes a block on the region report and relaunches the
+// profile's context as another fingerprint, since bot protection has flagged
+// its session
+func (h *BrowserHandler) reportBlock(ctx context.Context, err error) {
+	b, ok := challenge.AsBlock(err)
+	if !ok {
+		return
 	}
-	h.initialized = false
-	h.warmedUp = false
+	h.mu.Lock()
+	lease := h.lease
+	h.mu.Unlock()
+	b.Fingerprint = lease.Fingerprint()
+	reportBlock(ctx, err)
+	lease.ReportBlock()
 }
 
-func (h *BrowserHandler) startSession(region config.Region) error {
+func (h *BrowserHandler) startSession(ctx context.Context, region config.Region) error {
 	log.Printf("Starting new browsing session for %s", region.GeoName)
 
-	page, err := h.context.NewPage()
+	page, err := h.lease.NewPage(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to create page: %w", err)
+		return err
 	}
+	log.Printf("Browsing as %s", h.lease.Fingerprint())
+	reportFingerprint(ctx, h.lease.Fingerprint())
 	h.activePage = page
 	h.lastGeoID = region.GeoID
 	h.lastGeoName = region.GeoName
@@ -331,7 +405,9 @@ func (h *BrowserHandler) startSession(region config.Region) error {
 			h.simulateHumanBehavior()
 		}
 
-		h.waitForListings()
+		if err := h.waitForListings(); err != nil {
+			return err
+		}
 		h.currentPageNum = warmupPage
 
 		if listings := h.parseCurrentPage(); listings != nil {
@@ -412,7 +488,9 @@ func (h *BrowserHandler) navigateToPage(targetPage int) ([]models.RawListing, er
 		}
 		h.humanDelay(3000, 5000)
 		h.simulateHumanBehavior()
-		h.waitForListings()
+		if err := h.waitForListings(); err != nil {
+			return nil, err
+		}
 		h.currentPageNum = jumpTo
 	}
 
@@ -428,7 +506,9 @@ func (h *BrowserHandler) navigateToPage(targetPage int) ([]models.RawListing, er
 		}
 	}
 
-	h.waitForListings()
+	if err := h.waitForListings(); err != nil {
+		return nil, err
+	}
 
 	listings := h.parseCurrentPage()
 	if listings == nil {
@@ -494,8 +574,12 @@ func (h *BrowserHandler) clickNextPage() error {
 	return nil
 }
 
-func (h *BrowserHandler) waitForListings() {
+// waitForListings waits for the intercepted search response, solving any
+// bot challenge meanwhile. A page still challenged at the timeout is a block:
+// it is reported to the VPN manager and returned as a *challenge.BlockError.
+func (h *BrowserHandler) waitForListings() error {
 	page := h.activePage
+	var blocked error
 
 	for i := 0; i < 20; i++ {
 		page.WaitForTimeout(500)
@@ -503,16 +587,21 @@ func (h *BrowserHandler) waitForListings() {
 		result, _ := page.Evaluate(`window.__apiResponse`)
 		if result != nil && result.(string) != "" {
 			log.Println("API response received")
-			return
+			h.vpn.ReportSuccess()
+			return nil
 		}
 
-		content, _ := page.Content()
-		if trigger := h.detectIncapsula(content); trigger != "" {
-			log.Printf("Incapsula detected: %s", trigger)
-			h.handleIncapsula(page)
+		if blocked = challenge.CheckPage(page, realtorCAContentMarkers...); blocked != nil {
+			log.Printf("Challenge detected: %v", blocked)
+			challenge.Solve(page, realtorCAContentMarkers...)
 		}
 	}
 	log.Println("Timeout waiting for listings")
+	if blocked = challenge.CheckPage(page, realtorCAContentMarkers...); blocked != nil {
+		h.vpn.ReportBlock()
+		return blocked
+	}
+	return nil
 }
 
 func (h *BrowserHandler) simulateHumanBehavior() {
@@ -532,97 +621,6 @@ func (h *BrowserHandler) humanDelay(minMs, maxMs int) {
 	time.Sleep(time.Duration(delay) * time.Millisecond)
 }
 
-func (h *BrowserHandler) detectIncapsula(content string) string {
-	if contains(content, "listingCard") || contains(content, "ResultsPaginationCon") {
-		return ""
-	}
-
-	triggers := []string{
-		"Request unsuccessful. Incapsula",
-		"Incapsula incident ID",
-		"Access Denied",
-		"This request was blocked",
-	}
-	for _, t := range triggers {
-		if contains(content, t) {
-			return t
-		}
-	}
-	return ""
-}
-
-func (h *BrowserHandler) handleIncapsula(page playwright.Page) {
-	content, _ := page.Content()
-	trigger := h.detectIncapsula(content)
-	if trigger == "" {
-		return
-	}
-
-	log.Printf("Handling Incapsula challenge (trigger: %s)...", trigger)
-
-	page.WaitForTimeout(2000)
-
-	clickSelectors := []string{
-		"iframe#main-iframe",
-		"[id*='checkbox']",
-		"input[type='checkbox']",
-		"button:has-text('Verify')",
-		"button:has-text('Continue')",
-		"a:has-text('Click')",
-		"div[class*='verify']",
-	}
-
-	for _, selector := range clickSelectors {
-		el := page.Locator(selector).First()
-		if visible, _ := el.IsVisible(); visible {
-			log.Printf("Clicking Incapsula element: %s", selector)
-			el.Click()
-			page.WaitForTimeout(3000)
-			break
-		}
-	}
-
-	frames := page.Frames()
-	for _, frame := range frames {
-		if frame == page.MainFrame() {
-			continue
-		}
-
-		log.Println("Checking iframe for clickable elements...")
-
-		iframeSelectors := []string{
-			"[id*='checkbox']",
-			"input[type='checkbox']",
-			"button",
-			"a",
-			"div[role='button']",
-			"span[role='checkbox']",
-		}
-
-		for _, selector := range iframeSelectors {
-			el := frame.Locator(selector).First()
-			if visible, _ := el.IsVisible(); visible {
-				log.Printf("Clicking iframe element: %s", selector)
-				el.Click()
-				page.WaitForTimeout(3000)
-
-				newContent, _ := page.Content()
-				if !contains(newContent, "Incapsula") {
-					log.Println("Incapsula challenge passed!")
-					return
-				}
-			}
-		}
-	}
-
-	iframe := page.Locator("iframe#main-iframe").First()
-	if visible, _ := iframe.IsVisible(); visible {
-		log.Println("Clicking center of Incapsula iframe...")
-		iframe.Click()
-		page.WaitForTimeout(5000)
-	}
-}
-
 func (h *BrowserHandler) handleConsent(page playwright.Page) {
 	consentSelectors := []string{
 		"button:has-text('Consent')",
@@ -652,41 +650,28 @@ func (h *BrowserHandler) handleConsent(page playwright.Page) {
 func (h *BrowserHandler) parseRealtorCAResponse(data []byte) ([]models.RawListing, error) {
 	var rawResp struct {
 		Results []json.RawMessage `json:"Results"`
-		Paging  json.RawMessage   `json:"Paging"`
+		Paging  struct {
+			TotalRecords int `json:"TotalRecords"`
+		} `json:"Paging"`
 	}
 	if err := json.Unmarshal(data, &rawResp); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
+	if rawResp.Paging.TotalRecords > 0 {
+		h.totalRecords = rawResp.Paging.TotalRecords
+	}
 
 	var listings []models.RawListing
 	for _, rawResult := range rawResp.Results {
-		var r realtorCAResult
-		if err := json.Unmarshal(rawResult, &r); err != nil {
-			log.Printf("Failed to parse listing: %v", err)
-			continue
+		if h.schema != nil {
+			h.schema.Add(rawResult)
 		}
 
-		beds, bedsPlus := parseBedsInterface(r.Building.Bedrooms)
-
-		listing := models.RawListing{
-			ID:           fmt.Sprintf("%v", r.ID),
-			MLS:          r.MlsNumber,
-			Address:      r.Property.Address.AddressText,
-			City:         extractCityFromAddress(r.Property.Address.AddressText),
-			PostalCode:   r.PostalCode,
-			Price:        parsePriceString(r.Property.Price),
-			Beds:         beds,
-			BedsPlus:     bedsPlus,
-			Baths:        toInt(r.Building.BathroomTotal),
-			SqFt:         parseSqFtString(r.Building.SizeInterior),
-			PropertyType: r.Property.Type,
-			URL:          "https://www.realtor.ca" + r.RelativeURLEn,
-			Photos:       extractPhotoURLs(r.Property.Photo),
-			Description:  r.PublicRemarks,
-			Realtor:      extractRealtor(r.Individual),
-			Data:         rawResult,
+		listing, err := parseRealtorCAResult(rawResult)
+		if err != nil {
+			log.Printf("Failed to parse listing: %v", err)
+			continue
 		}
-
 		listings = append(listings, listing)
 	}
 
@@ -694,6 +679,35 @@ func (h *BrowserHandler) parseRealtorCAResponse(data []byte) ([]models.RawListin
 	return listings, nil
 }
 
+// parseRealtorCAResult maps one PropertySearch result to a RawListing
+func parseRealtorCAResult(rawResult json.RawMessage) (models.RawListing, error) {
+	var r realtorCAResult
+	if err := json.Unmarshal(rawResult, &r); err != nil {
+		return models.RawListing{}, err
+	}
+
+	beds, bedsPlus := parseBedsInterface(r.Building.Bedrooms)
+
+	return models.RawListing{
+		ID:           fmt.Sprintf("%v", r.ID),
+		MLS:          r.MlsNumber,
+		Address:      r.Property.Address.AddressText,
+		City:         extractCityFromAddress(r.Property.Address.AddressText),
+		PostalCode:   r.PostalCode,
+		Price:        parsePriceString(r.Property.Price),
+		Beds:         beds,
+		BedsPlus:     bedsPlus,
+		Baths:        toInt(r.Building.BathroomTotal),
+		SqFt:         parseSqFtString(r.Building.SizeInterior),
+		PropertyType: r.Property.Type,
+		URL:          "https://www.realtor.ca" + r.RelativeURLEn,
+		Photos:       extractPhotoURLs(r.Property.Photo),
+		Description:  r.PublicRemarks,
+		Realtor:      extractRealtor(r.Individual),
+		Data:         rawResult,
+	}, nil
+}
+
 func extractRealtor(individuals []struct {
 	IndividualID int    `json:"IndividualID"`
 	Name         string `json:"Name"`
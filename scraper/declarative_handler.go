@@ -0,0 +1,252 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"text/template"
+
+	"tct_scrooper/challenge"
+	"tct_scrooper/config"
+	"tct_scrooper/httputil"
+	"tct_scrooper/models"
+)
+
+// DeclarativeHandler scrapes a source described entirely in site YAML
+// (request template, pagination and field mappings). No Go code per site.
+type DeclarativeHandler struct {
+	cfg     *config.SiteConfig
+	client  *http.Client
+	mapper  *ListingMapper
+	url     *template.Template
+	body    *template.Template
+	headers map[string]*template.Template
+	err     error // config error, reported on Scrape
+
+	limiter      *httputil.HostLimiter
+	fingerprints *httputil.Fingerprints
+}
+
+// requestVars is the data passed to request templates
+type requestVars struct {
+	Region   config.Region
+	Page     int
+	Offset   int
+	PageSize int
+}
+
+func NewDeclarativeHandler(cfg *config.SiteConfig) *DeclarativeHandler {
+	h := &DeclarativeHandler{
+		cfg: cfg,
+		client: &http.Client{
+			Transport: httputil.ScrapingRetry.Transport(nil),
+		},
+	}
+	h.err = h.compile()
+	if h.err != nil {
+		log.Printf("Declarative handler %s: invalid config: %v", cfg.ID, h.err)
+	}
+	return h
+}
+
+func (h *DeclarativeHandler) compile() error {
+	d := h.cfg.Declarative
+	mapper, err := NewListingMapper(d)
+	if err != nil {
+		return err
+	}
+	h.mapper = mapper
+
+	if d.Request.URL == "" {
+		return fmt.Errorf("request.url is required")
+	}
+	if h.url, err = template.New("url").Parse(d.Request.URL); err != nil {
+		return fmt.Errorf("request.url: %w", err)
+	}
+	if h.body, err = template.New("body").Parse(d.Request.Body); err != nil {
+		return fmt.Errorf("request.body: %w", err)
+	}
+
+	h.headers = make(map[string]*template.Template)
+	for name, value := range d.Request.Headers {
+		t, err := template.New(name).Parse(value)
+		if err != nil {
+			return fmt.Errorf("request.headers.%s: %w", name, err)
+		}
+		h.headers[name] = t
+	}
+
+	switch d.Pagination.Strategy {
+	case "", "none", "page", "offset":
+	default:
+		return fmt.Errorf("unknown pagination strategy %q", d.Pagination.Strategy)
+	}
+	if d.Pagination.Strategy == "offset" && d.Pagination.PageSize <= 0 {
+		return fmt.Errorf("offset pagination requires page_size")
+	}
+
+	return nil
+}
+
+// SetRateLimiter routes the handler's requests through the shared per-host
+// limiter, which also applies the site's rate_limit_ms between pages. The
+// limiter sits beneath the retries so each attempt waits its turn.
+func (h *DeclarativeHandler) SetRateLimiter(limiter *httputil.HostLimiter) {
+	h.limiter = limiter
+	h.client.Transport = h.transport()
+}
+
+// SetFingerprints sends the handler's requests with the site's fingerprint
+// profile, rotated when the site blocks it. Headers set in the site config
+// take precedence.
+func (h *DeclarativeHandler) SetFingerprints(fingerprints *httputil.Fingerprints) {
+	h.fingerprints = fingerprints
+	h.client.Transport = h.transport()
+}
+
+func (h *DeclarativeHandler) transport() http.RoundTripper {
+	return h.fingerprints.Transport("site:"+h.cfg.ID, httputil.ScrapingRetry.Transport(h.limiter.Transport(nil)))
+}
+
+func (h *DeclarativeHandler) ID() string {
+	return h.cfg.ID
+}
+
+// Mapper exposes the listing mapper (used by validate-site)
+func (h *DeclarativeHandler) Mapper() (*ListingMapper, error) {
+	return h.mapper, h.err
+}
+
+func (h *DeclarativeHandler) Scrape(ctx context.Context, region config.Region) ([]models.RawListing, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+
+	p := h.cfg.Declarative.Pagination
+	var allListings []models.RawListing
+	total := 0
+
+	complete := false
+	for page := 0; p.MaxPages <= 0 || page < p.MaxPages; page++ {
+		vars := requestVars{Region: region, PageSize: p.PageSize}
+		switch p.Strategy {
+		case "offset":
+			vars.Page = page + 1
+			vars.Offset = p.Start + page*p.PageSize
+		default:
+			vars.Page = p.Start + page
+			vars.Offset = page * p.PageSize
+		}
+
+		body, err := h.fetchPage(ctx, vars)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", vars.Page, err)
+		}
+
+		listings, err := h.mapper.Parse(body)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", vars.Page, err)
+		}
+		if page == 0 {
+			total = h.mapper.Total(body)
+		}
+
+		allListings = append(allListings, listings...)
+		log.Printf("Declarative %s: page %d: %d listings (total: %d)", h.cfg.ID, vars.Page, len(listings), len(allListings))
+
+		if p.Strategy == "" || p.Strategy == "none" || len(listings) == 0 {
+			complete = true
+			break
+		}
+		if p.PageSize > 0 && len(listings) < p.PageSize {
+			complete = true
+			break
+		}
+
+	}
+	if !complete {
+		reportPartial(ctx, fmt.Sprintf("stopped at max_pages %d", p.MaxPages))
+	}
+	reportCoverage(ctx, total, len(allListings))
+
+	return allListings, nil
+}
+
+func (h *DeclarativeHandler) fetchPage(ctx context.Context, vars requestVars) ([]byte, error) {
+	url, err := renderTemplate(h.url, vars)
+	if err != nil {
+		return nil, err
+	}
+	reqBody, err := renderTemplate(h.body, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	method := strings.ToUpper(h.cfg.Declarative.Request.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var bodyReader io.Reader
+	if reqBody != "" {
+		bodyReader = strings.NewReader(reqBody)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+	for name, t := range h.headers {
+		value, err := renderTemplate(t, vars)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(name, value)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	fingerprint := httputil.FingerprintFrom(resp.Request.Context())
+	reportFingerprint(ctx, fingerprint)
+	if err := challenge.Check(challenge.Page{
+		Host:        req.URL.Hostname(),
+		Status:      resp.StatusCode,
+		Body:        string(data),
+		Fingerprint: fingerprint,
+	}); err != nil {
+		reportBlock(ctx, err)
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s error %d: %s", h.cfg.ID, resp.StatusCode, truncateBody(data))
+	}
+
+	return data, nil
+}
+
+func renderTemplate(t *template.Template, vars requestVars) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, vars); err != nil {
+		return "", fmt.Errorf("template %s: %w", t.Name(), err)
+	}
+	return buf.String(), nil
+}
+
+func truncateBody(data []byte) string {
+	if len(data) > 200 {
+		return string(data[:200]) + "..."
+	}
+	return string(data)
+}
@@ -0,0 +1,95 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"tct_scrooper/config"
+	"tct_scrooper/httputil"
+)
+
+func TestDeclarativeHandler_ReportsPartial(t *testing.T) {
+	fixture := loadFixture(t, "realtor_ca_basic.json")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "1" {
+			w.Write(fixture)
+			return
+		}
+		w.Write([]byte(`{"Results": []}`))
+	}))
+	defer srv.Close()
+
+	scrape := func(maxPages int) *RegionReport {
+		h := NewDeclarativeHandler(&config.SiteConfig{
+			ID: "test",
+			Declarative: &config.DeclarativeConfig{
+				Request:    config.RequestTemplate{URL: srv.URL + "/?page={{.Page}}"},
+				Pagination: config.PaginationConfig{Strategy: "page", Start: 1, PageSize: 1, MaxPages: maxPages},
+				Items:      "$.Results[*]",
+				Fields:     map[string]config.FieldMapping{"mls": {Path: "MlsNumber"}},
+			},
+		})
+		report := &RegionReport{}
+		ctx := withRegionScope(context.Background(), &regionScope{RegionID: "r", Report: report})
+		listings, err := h.Scrape(ctx, config.Region{})
+		if err != nil {
+			t.Fatalf("scrape: %v", err)
+		}
+		if len(listings) != 1 {
+			t.Fatalf("expected 1 listing, got %d", len(listings))
+		}
+		return report
+	}
+
+	if report := scrape(0); report.Partial != "" {
+		t.Fatalf("paging to the end should be a full result, got partial %q", report.Partial)
+	}
+	if report := scrape(1); report.Partial == "" {
+		t.Fatalf("stopping at max_pages should report a partial result")
+	}
+}
+
+func TestDeclarativeHandler_ReportsFingerprint(t *testing.T) {
+	fixture := loadFixture(t, "realtor_ca_basic.json")
+	var agents []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agents = append(agents, r.UserAgent())
+		if r.URL.Query().Get("page") == "1" {
+			w.Write(fixture)
+			return
+		}
+		w.Write([]byte(`{"Results": []}`))
+	}))
+	defer srv.Close()
+
+	fingerprints := httputil.NewFingerprints([]config.Fingerprint{{Name: "chrome-win", UserAgent: "agent-1"}, {Name: "firefox", UserAgent: "agent-2"}})
+	h := NewDeclarativeHandler(&config.SiteConfig{
+		ID: "test",
+		Declarative: &config.DeclarativeConfig{
+			Request:    config.RequestTemplate{URL: srv.URL + "/?page={{.Page}}"},
+			Pagination: config.PaginationConfig{Strategy: "page", Start: 1, PageSize: 1},
+			Items:      "$.Results[*]",
+			Fields:     map[string]config.FieldMapping{"mls": {Path: "MlsNumber"}},
+		},
+	})
+	h.SetFingerprints(fingerprints)
+
+	scrape := func() []string {
+		report := &RegionReport{}
+		ctx := withRegionScope(context.Background(), &regionScope{RegionID: "r", Report: report})
+		if _, err := h.Scrape(ctx, config.Region{}); err != nil {
+			t.Fatalf("scrape: %v", err)
+		}
+		return report.Fingerprints
+	}
+
+	if got := scrape(); len(got) != 1 || got[0] != "chrome-win" || len(agents) != 2 {
+		t.Fatalf("expected both pages recorded once as chrome-win, got %v over %d requests", got, len(agents))
+	}
+	fingerprints.Rotate("site:test", "chrome-win")
+	if got := scrape(); len(got) != 1 || got[0] != "firefox" {
+		t.Fatalf("expected the rotated profile recorded, got %v", got)
+	}
+}
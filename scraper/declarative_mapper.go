@@ -0,0 +1,404 @@
+package scraper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"tct_scrooper/config"
+	"tct_scrooper/models"
+)
+
+// textTransforms normalize string fields
+var textTransforms = map[string]func(string) string{
+	"trim":                strings.TrimSpace,
+	"lower":               strings.ToLower,
+	"upper":               strings.ToUpper,
+	"province":            normalizeProvince,
+	"postal":              func(s string) string { return strings.ToUpper(normalizePostal(s)) },
+	"postal_from_address": extractPostalFromAddress,
+	"city_from_address":   extractCity,
+}
+
+// numberTransforms parse numeric fields; the second value is the "plus" part
+// (only bedrooms uses it, e.g. "3 + 1")
+var numberTransforms = map[string]func(string) (int, int){
+	"int":      func(s string) (int, int) { return parseIntString(s), 0 },
+	"price":    func(s string) (int, int) { return parsePrice(s), 0 },
+	"sqft":     func(s string) (int, int) { return parseSqFt(s), 0 },
+	"bedrooms": parseBedrooms,
+}
+
+// mappableFields lists RawListing fields a declarative mapping can target,
+// with the default transform for each
+var mappableFields = map[string]string{
+	"id":            "trim",
+	"mls":           "trim",
+	"address":       "trim",
+	"city":          "trim",
+	"province":      "trim",
+	"postal_code":   "postal",
+	"price":         "price",
+	"beds":          "bedrooms",
+	"beds_plus":     "int",
+	"baths":         "int",
+	"sqft":          "sqft",
+	"property_type": "trim",
+	"url":           "trim",
+	"photos":        "trim",
+	"description":   "trim",
+}
+
+func isNumberField(field string) bool {
+	switch field {
+	case "price", "beds", "beds_plus", "baths", "sqft":
+		return true
+	}
+	return false
+}
+
+// ListingMapper turns a response body into RawListings using a DeclarativeConfig
+type ListingMapper struct {
+	cfg    *config.DeclarativeConfig
+	fields []string // sorted for deterministic application (beds before beds_plus)
+}
+
+func NewListingMapper(cfg *config.DeclarativeConfig) (*ListingMapper, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("no declarative config")
+	}
+
+	switch cfg.Format {
+	case "", "json", "html":
+	default:
+		return nil, fmt.Errorf("unknown format %q (want json or html)", cfg.Format)
+	}
+
+	if cfg.Items == "" {
+		return nil, fmt.Errorf("items selector is required")
+	}
+	if cfg.Format != "html" {
+		if _, err := splitJSONPath(cfg.Items); err != nil {
+			return nil, err
+		}
+		if _, err := splitJSONPath(cfg.Total); err != nil {
+			return nil, fmt.Errorf("total: %w", err)
+		}
+	} else if cfg.Total != "" {
+		return nil, fmt.Errorf("total is only supported for json sources")
+	}
+
+	m := &ListingMapper{cfg: cfg}
+	for field, mapping := range cfg.Fields {
+		if _, ok := mappableFields[field]; !ok {
+			return nil, fmt.Errorf("unknown field %q", field)
+		}
+		if mapping.Path == "" && mapping.Each == "" {
+			return nil, fmt.Errorf("field %q: path is required", field)
+		}
+		if mapping.Transform != "" {
+			_, isText := textTransforms[mapping.Transform]
+			_, isNumber := numberTransforms[mapping.Transform]
+			if isNumberField(field) && !isNumber {
+				return nil, fmt.Errorf("field %q: transform %q is not numeric", field, mapping.Transform)
+			}
+			if !isNumberField(field) && !isText {
+				return nil, fmt.Errorf("field %q: unknown transform %q", field, mapping.Transform)
+			}
+		}
+		if cfg.Format != "html" {
+			for _, p := range []string{mapping.Each, mapping.Path, mapping.Fallback} {
+				if _, err := splitJSONPath(p); err != nil {
+					return nil, fmt.Errorf("field %q: %w", field, err)
+				}
+			}
+		}
+		m.fields = append(m.fields, field)
+	}
+	sort.Strings(m.fields)
+
+	if _, ok := cfg.Fields["mls"]; !ok {
+		if _, ok := cfg.Fields["id"]; !ok {
+			return nil, fmt.Errorf("mapping needs at least an mls or id field")
+		}
+	}
+
+	return m, nil
+}
+
+// Parse maps a full response body (one page) into listings
+func (m *ListingMapper) Parse(body []byte) ([]models.RawListing, error) {
+	if m.cfg.Format == "html" {
+		return m.parseHTML(body)
+	}
+	return m.parseJSON(body)
+}
+
+// Total reads the region's total listing count from a response body using
+// the total path; 0 when there is none or it can't be read
+func (m *ListingMapper) Total(body []byte) int {
+	if m.cfg.Total == "" || m.cfg.Format == "html" {
+		return 0
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return 0
+	}
+	if vals := jsonStrings(doc, m.cfg.Total); len(vals) > 0 {
+		return parseIntString(vals[0])
+	}
+	return 0
+}
+
+// ParseItem maps one stored item payload (a RawListing's Data) back into a
+// listing: the item itself for JSON sources, the extracted values for HTML
+func (m *ListingMapper) ParseItem(data json.RawMessage) (models.RawListing, error) {
+	values := make(map[string][]string)
+	if m.cfg.Format == "html" {
+		if err := json.Unmarshal(data, &values); err != nil {
+			return models.RawListing{}, err
+		}
+	} else {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		var item interface{}
+		if err := dec.Decode(&item); err != nil {
+			return models.RawListing{}, err
+		}
+		for _, field := range m.fields {
+			values[field] = m.jsonValues(item, m.cfg.Fields[field])
+		}
+	}
+
+	listing, ok := m.build(values, data)
+	if !ok {
+		return models.RawListing{}, fmt.Errorf("item has no mls or id")
+	}
+	return listing, nil
+}
+
+func (m *ListingMapper) parseJSON(body []byte) ([]models.RawListing, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	items, err := evalJSONPath(doc, m.cfg.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	var listings []models.RawListing
+	for _, item := range items {
+		values := make(map[string][]string)
+		for _, field := range m.fields {
+			values[field] = m.jsonValues(item, m.cfg.Fields[field])
+		}
+
+		data, _ := json.Marshal(item)
+		listing, ok := m.build(values, data)
+		if ok {
+			listings = append(listings, listing)
+		}
+	}
+
+	return listings, nil
+}
+
+func (m *ListingMapper) jsonValues(item interface{}, mapping config.FieldMapping) []string {
+	parents := []interface{}{item}
+	if mapping.Each != "" {
+		parents, _ = evalJSONPath(item, mapping.Each)
+	}
+
+	var out []string
+	for _, parent := range parents {
+		vals := jsonStrings(parent, mapping.Path)
+		if len(vals) == 0 && mapping.Fallback != "" {
+			vals = jsonStrings(parent, mapping.Fallback)
+		}
+		out = append(out, vals...)
+	}
+	return out
+}
+
+// jsonStrings evaluates a path and stringifies non-empty scalar results
+func jsonStrings(node interface{}, path string) []string {
+	nodes, _ := evalJSONPath(node, path)
+
+	var out []string
+	for _, n := range nodes {
+		var s string
+		switch v := n.(type) {
+		case string:
+			s = v
+		case json.Number:
+			s = v.String()
+		case bool:
+			s = strconv.FormatBool(v)
+		default:
+			continue
+		}
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func (m *ListingMapper) parseHTML(body []byte) ([]models.RawListing, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	var listings []models.RawListing
+	doc.Find(m.cfg.Items).Each(func(_ int, item *goquery.Selection) {
+		values := make(map[string][]string)
+		extracted := make(map[string]interface{})
+		for _, field := range m.fields {
+			values[field] = htmlValues(item, m.cfg.Fields[field])
+			extracted[field] = values[field]
+		}
+
+		// No JSON payload for HTML sources; keep what we extracted instead
+		data, _ := json.Marshal(extracted)
+		if listing, ok := m.build(values, data); ok {
+			listings = append(listings, listing)
+		}
+	})
+
+	return listings, nil
+}
+
+func htmlValues(item *goquery.Selection, mapping config.FieldMapping) []string {
+	parents := item
+	if mapping.Each != "" {
+		parents = item.Find(mapping.Each)
+	}
+
+	var out []string
+	parents.Each(func(_ int, parent *goquery.Selection) {
+		vals := htmlStrings(parent, mapping.Path, mapping.Attr)
+		if len(vals) == 0 && mapping.Fallback != "" {
+			vals = htmlStrings(parent, mapping.Fallback, mapping.Attr)
+		}
+		out = append(out, vals...)
+	})
+	return out
+}
+
+func htmlStrings(sel *goquery.Selection, selector, attr string) []string {
+	if selector != "" {
+		sel = sel.Find(selector)
+	}
+
+	var out []string
+	sel.Each(func(_ int, node *goquery.Selection) {
+		var s string
+		if attr != "" {
+			s, _ = node.Attr(attr)
+		} else {
+			s = node.Text()
+		}
+		if s = strings.Join(strings.Fields(s), " "); s != "" {
+			out = append(out, s)
+		}
+	})
+	return out
+}
+
+// build applies transforms and assigns values to a RawListing.
+// Items without an MLS number or ID are skipped.
+func (m *ListingMapper) build(values map[string][]string, data json.RawMessage) (models.RawListing, bool) {
+	listing := models.RawListing{Data: data}
+
+	for _, field := range m.fields {
+		mapping := m.cfg.Fields[field]
+		vals := values[field]
+		if len(vals) == 0 {
+			continue
+		}
+
+		transform := mapping.Transform
+		if transform == "" {
+			transform = mappableFields[field]
+		}
+
+		if isNumberField(field) {
+			n, plus := numberTransforms[transform](vals[0])
+			switch field {
+			case "price":
+				listing.Price = n
+			case "beds":
+				listing.Beds = n
+				listing.BedsPlus = plus
+			case "beds_plus":
+				listing.BedsPlus = n
+			case "baths":
+				listing.Baths = n
+			case "sqft":
+				listing.SqFt = n
+			}
+			continue
+		}
+
+		fn := textTransforms[transform]
+		for i := range vals {
+			vals[i] = fn(vals[i])
+			if vals[i] != "" {
+				vals[i] = mapping.Prefix + vals[i]
+			}
+		}
+
+		if field == "photos" {
+			for _, v := range vals {
+				if v != "" {
+					listing.Photos = append(listing.Photos, v)
+				}
+			}
+			continue
+		}
+
+		v := vals[0]
+		switch field {
+		case "id":
+			listing.ID = v
+		case "mls":
+			listing.MLS = v
+		case "address":
+			listing.Address = v
+		case "city":
+			listing.City = v
+		case "province":
+			listing.Province = v
+		case "postal_code":
+			listing.PostalCode = v
+		case "property_type":
+			listing.PropertyType = v
+		case "url":
+			listing.URL = v
+		case "description":
+			listing.Description = v
+		}
+	}
+
+	if listing.ID == "" {
+		listing.ID = listing.MLS
+	}
+	if listing.MLS == "" && listing.ID == "" {
+		return listing, false
+	}
+	return listing, true
+}
@@ -0,0 +1,143 @@
+package scraper
+
+import (
+	"testing"
+
+	"tct_scrooper/config"
+)
+
+func TestListingMapper_JSON(t *testing.T) {
+	mapper, err := NewListingMapper(&config.DeclarativeConfig{
+		Items: "$.Results[*]",
+		Fields: map[string]config.FieldMapping{
+			"mls":         {Path: "MlsNumber"},
+			"city":        {Path: "Property.Address.AddressText", Transform: "city_from_address"},
+			"postal_code": {Path: "PostalCode"},
+			"price":       {Path: "Property.Price", Transform: "price"},
+			"beds":        {Path: "Building.Bedrooms", Transform: "bedrooms"},
+			"sqft":        {Path: "Building.SizeInterior", Transform: "sqft"},
+			"url":         {Path: "RelativeURLEn", Prefix: "https://www.realtor.ca"},
+			"photos":      {Each: "Property.Photo[*]", Path: "HighResPath", Fallback: "LowResPath"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("mapper: %v", err)
+	}
+
+	listings, err := mapper.Parse(loadFixture(t, "realtor_ca_basic.json"))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(listings) != 1 {
+		t.Fatalf("expected 1 listing, got %d", len(listings))
+	}
+
+	listing := listings[0]
+	if listing.MLS != "26001716" || listing.ID != "26001716" {
+		t.Fatalf("expected MLS/ID 26001716, got %s/%s", listing.MLS, listing.ID)
+	}
+	if listing.City != "Windsor" {
+		t.Fatalf("expected city Windsor, got %s", listing.City)
+	}
+	if listing.PostalCode != "N8P0E6" {
+		t.Fatalf("expected postal N8P0E6, got %s", listing.PostalCode)
+	}
+	if listing.Price != 1149900 {
+		t.Fatalf("expected price 1149900, got %d", listing.Price)
+	}
+	if listing.Beds != 3 || listing.BedsPlus != 1 {
+		t.Fatalf("expected beds 3 + 1, got %d + %d", listing.Beds, listing.BedsPlus)
+	}
+	if listing.SqFt != 2360 {
+		t.Fatalf("expected sqft 2360, got %d", listing.SqFt)
+	}
+	if listing.URL != "https://www.realtor.ca/real-estate/29279012/939-chateau-windsor" {
+		t.Fatalf("unexpected URL %s", listing.URL)
+	}
+	if len(listing.Photos) != 2 || listing.Photos[1] != "https://cdn.realtor.ca/listings/26001716_2.jpg" {
+		t.Fatalf("unexpected photos %v", listing.Photos)
+	}
+	if len(listing.Data) == 0 {
+		t.Fatalf("expected raw item data")
+	}
+}
+
+func TestListingMapper_HTML(t *testing.T) {
+	mapper, err := NewListingMapper(&config.DeclarativeConfig{
+		Format: "html",
+		Items:  "div.listing",
+		Fields: map[string]config.FieldMapping{
+			"mls":    {Path: "[data-mls]", Attr: "data-mls"},
+			"price":  {Path: ".price"},
+			"beds":   {Path: ".beds"},
+			"url":    {Path: "a", Attr: "href", Prefix: "https://example.com"},
+			"photos": {Path: "img", Attr: "src"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("mapper: %v", err)
+	}
+
+	body := []byte(`<html><body>
+		<div class="listing"><span data-mls="A1"></span><span class="price">$350,000</span>
+			<span class="beds">2 + 1</span><a href="/a1">view</a><img src="p1.jpg"><img src="p2.jpg"></div>
+		<div class="listing"><span class="price">$1</span></div>
+	</body></html>`)
+
+	listings, err := mapper.Parse(body)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(listings) != 1 {
+		t.Fatalf("expected 1 listing (second has no MLS), got %d", len(listings))
+	}
+
+	listing := listings[0]
+	if listing.MLS != "A1" || listing.Price != 350000 || listing.Beds != 2 || listing.BedsPlus != 1 {
+		t.Fatalf("unexpected listing %+v", listing)
+	}
+	if listing.URL != "https://example.com/a1" || len(listing.Photos) != 2 {
+		t.Fatalf("unexpected url/photos %s %v", listing.URL, listing.Photos)
+	}
+}
+
+func TestListingMapper_RejectsBadConfig(t *testing.T) {
+	cases := []config.DeclarativeConfig{
+		{Items: "$.Results[*]", Fields: map[string]config.FieldMapping{"mls": {Path: "MlsNumber"}, "colour": {Path: "x"}}},
+		{Items: "$.Results[*]", Fields: map[string]config.FieldMapping{"mls": {Path: "MlsNumber"}, "price": {Path: "P", Transform: "province"}}},
+		{Items: "$.Results[x]", Fields: map[string]config.FieldMapping{"mls": {Path: "MlsNumber"}}},
+		{Items: "$.Results[*]", Fields: map[string]config.FieldMapping{"price": {Path: "P"}}},
+	}
+	for i, c := range cases {
+		c := c
+		if _, err := NewListingMapper(&c); err == nil {
+			t.Fatalf("case %d: expected error", i)
+		}
+	}
+}
+
+func TestListingMapper_Total(t *testing.T) {
+	cases := []struct {
+		name string
+		cfg  config.DeclarativeConfig
+		body string
+		want int
+	}{
+		{"number", config.DeclarativeConfig{Total: "$.Paging.TotalRecords"}, `{"Paging": {"TotalRecords": 712}}`, 712},
+		{"string", config.DeclarativeConfig{Total: "$.Paging.TotalRecords"}, `{"Paging": {"TotalRecords": "1204"}}`, 1204},
+		{"no total path", config.DeclarativeConfig{}, `{"Paging": {"TotalRecords": 712}}`, 0},
+		{"path missing from body", config.DeclarativeConfig{Total: "$.Paging.TotalRecords"}, `{"Results": []}`, 0},
+		{"not json", config.DeclarativeConfig{Total: "$.Paging.TotalRecords"}, `<html></html>`, 0},
+	}
+	for _, c := range cases {
+		c.cfg.Items = "$.Results[*]"
+		c.cfg.Fields = map[string]config.FieldMapping{"mls": {Path: "MlsNumber"}}
+		mapper, err := NewListingMapper(&c.cfg)
+		if err != nil {
+			t.Fatalf("%s: mapper: %v", c.name, err)
+		}
+		if got := mapper.Total([]byte(c.body)); got != c.want {
+			t.Errorf("%s: expected total %d, got %d", c.name, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"tct_scrooper/services"
+)
+
+// DryRun scrapes the given site (all sites when empty) and region (all when
+// empty) and reports what ingesting the results would change, without
+// writing anything: no run records, quarantine rows, schema baselines or
+// archives are created. Actor runs started for Apify sites are still billed.
+func (o *Orchestrator) DryRun(ctx context.Context, siteID, regionID string) (*services.DryRunReport, error) {
+	if o.pgStore == nil {
+		return nil, fmt.Errorf("dry run needs the Postgres store")
+	}
+
+	siteIDs := []string{siteID}
+	if siteID == "" {
+		siteIDs = o.GetSiteIDs()
+		sort.Strings(siteIDs)
+	} else if _, ok := o.cfg.Sites[siteID]; !ok {
+		return nil, fmt.Errorf("unknown site: %s", siteID)
+	}
+	if regionID != "" && siteID == "" {
+		return nil, fmt.Errorf("a region needs a site")
+	}
+
+	// As in runSite: with the kill switch on, the limiter would otherwise
+	// hold every request until the tunnel comes back
+	if err := o.vpn.EnsureConnected(ctx); err != nil {
+		return nil, fmt.Errorf("vpn: %w", err)
+	}
+	if err := o.verifyEgress(ctx, siteID, "Dry run refused"); err != nil {
+		return nil, err
+	}
+
+	report := &services.DryRunReport{StartedAt: time.Now()}
+	planner := services.NewIngestPlanner(o.pgStore, o.matchService)
+
+	for _, id := range siteIDs {
+		siteCfg := o.cfg.Sites[id]
+		regionIDs := sortedRegionIDs(siteCfg)
+		if regionID != "" {
+			if _, ok := siteCfg.Regions[regionID]; !ok {
+				return nil, fmt.Errorf("unknown region %s for site %s", regionID, id)
+			}
+			regionIDs = []string{regionID}
+		}
+
+		allowed, maxListings := o.checkApifyBudget(ctx, id, siteCfg, nil, 0)
+		if !allowed {
+			for _, r := range regionIDs {
+				report.AddRegion(services.DryRunRegion{Site: id, Region: r, Error: "apify budget reached"})
+			}
+			continue
+		}
+
+		for _, r := range regionIDs {
+			if err := ctx.Err(); err != nil {
+				return report, err
+			}
+			report.AddRegion(o.dryRunRegion(ctx, planner, report, id, r, maxListings))
+		}
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+// dryRunRegion scrapes one region and plans its listings into report
+func (o *Orchestrator) dryRunRegion(ctx context.Context, planner *services.IngestPlanner, report *services.DryRunReport,
+	siteID, regionID string, maxListings int) services.DryRunRegion {
+	siteCfg := o.cfg.Sites[siteID]
+	result := services.DryRunRegion{Site: siteID, Region: regionID}
+
+	// No PgRunID in the scope, so handlers record nothing against a run
+	scope := &regionScope{RegionID: regionID, Report: &RegionReport{}, MaxListings: maxListings}
+	log.Printf("Dry run: scraping %s/%s", siteID, regionID)
+	listings, err := o.handlers[siteID].Scrape(withRegionScope(ctx, scope), siteCfg.Regions[regionID])
+	result.ParseFailures = scope.Report.ParseFailures
+	result.ApifyUsageUSD = scope.Report.ApifyUsageUSD
+	result.Expected = scope.Report.ExpectedTotal
+	if err != nil {
+		log.Printf("Dry run: %s/%s failed: %v", siteID, regionID, err)
+		result.Error = err.Error()
+		return result
+	}
+	result.Fetched = len(listings)
+
+	rules := o.validationRules(siteID)
+	for i := range listings {
+		listing := &listings[i]
+		if reasons := services.ValidateListing(listing, rules); len(reasons) > 0 {
+			result.Quarantined++
+			continue
+		}
+
+		plan, ok, err := planner.Plan(ctx, listing, siteID, regionID)
+		if err != nil {
+			log.Printf("Dry run: plan error for %s/%s: %v", siteID, listing.MLS, err)
+			result.Errors++
+			continue
+		}
+		if !ok {
+			result.Duplicates++
+			continue
+		}
+		report.AddPlan(plan)
+	}
+	return result
+}
@@ -0,0 +1,149 @@
+package scraper
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"sync"
+	"time"
+
+	"tct_scrooper/config"
+)
+
+// Engine executes site runs. Every trigger (cron, commands, resume polling)
+// submits into it; a site that is already queued or running has the request
+// merged into its current job. Regions across all sites share a bounded pool
+// of slots, and region starts against the same host are spaced by the
+// configured stagger.
+type Engine struct {
+	o       *Orchestrator
+	slots   chan struct{} // global limit on regions scraping at once
+	stagger time.Duration
+
+	mu       sync.Mutex
+	jobs     map[string]*Job      // queued or running, by site
+	hostNext map[string]time.Time // earliest next region start per host
+}
+
+// Job is one site run in the engine; duplicate submissions share it
+type Job struct {
+	SiteID string
+	done   chan struct{}
+	err    error
+}
+
+// Wait blocks until the run finishes and returns its error
+func (j *Job) Wait() error {
+	<-j.done
+	return j.err
+}
+
+func newEngine(o *Orchestrator, maxConcurrency int, stagger time.Duration) *Engine {
+	if maxConcurrency < 1 {
+		maxConcurrency = 1
+	}
+	return &Engine{
+		o:        o,
+		slots:    make(chan struct{}, maxConcurrency),
+		stagger:  stagger,
+		jobs:     make(map[string]*Job),
+		hostNext: make(map[string]time.Time),
+	}
+}
+
+// Submit queues a run of siteID, or returns the job already queued or
+// running for it
+func (e *Engine) Submit(ctx context.Context, siteID string) *Job {
+	job, queued := e.submit(ctx, siteID, e.o.runSite)
+	if !queued {
+		log.Printf("Site %s already queued or running, merging request into it", siteID)
+	}
+	return job
+}
+
+// submit queues run as siteID's job. If the site already has a job it
+// returns that one and false, and run is not called.
+func (e *Engine) submit(ctx context.Context, siteID string, run func(ctx context.Context, siteID string) error) (*Job, bool) {
+	e.mu.Lock()
+	if job, ok := e.jobs[siteID]; ok {
+		e.mu.Unlock()
+		return job, false
+	}
+	job := &Job{SiteID: siteID, done: make(chan struct{})}
+	e.jobs[siteID] = job
+	e.mu.Unlock()
+
+	go func() {
+		job.err = run(ctx, siteID)
+
+		e.mu.Lock()
+		delete(e.jobs, siteID)
+		e.mu.Unlock()
+		close(job.done)
+	}()
+	return job, true
+}
+
+// acquire takes a global region slot and waits for the host's stagger. The
+// returned func releases the slot.
+func (e *Engine) acquire(ctx context.Context, host string) (func(), error) {
+	select {
+	case e.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	release := func() { <-e.slots }
+
+	if err := e.waitHost(ctx, host); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+// waitHost reserves the host's next start time and sleeps until it
+func (e *Engine) waitHost(ctx context.Context, host string) error {
+	if e.stagger <= 0 {
+		return nil
+	}
+
+	e.mu.Lock()
+	start := e.hostNext[host]
+	if now := time.Now(); start.Before(now) {
+		start = now
+	}
+	e.hostNext[host] = start.Add(e.stagger)
+	e.mu.Unlock()
+
+	if wait := time.Until(start); wait > 0 {
+		log.Printf("Waiting %v before next region on %s", wait.Round(time.Second), host)
+		if !sleepCtx(ctx, wait) {
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// siteHost is the host a site's region scrapes hit, used to space them;
+// falls back to the site ID when no URL is configured
+func siteHost(siteCfg *config.SiteConfig) string {
+	raw := siteCfg.Endpoints["search"]
+	switch {
+	case siteCfg.Handler == "apify":
+		raw = apifyAPIBase
+	case siteCfg.Handler == "declarative" && siteCfg.Declarative != nil:
+		raw = siteCfg.Declarative.Request.URL
+	}
+	if u, err := url.Parse(raw); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return siteCfg.ID
+}
+
+// siteConcurrency is how many of a site's regions may be scraped at once
+func siteConcurrency(siteCfg *config.SiteConfig) int {
+	if siteCfg.Concurrency < 1 || siteCfg.Handler == "browser" {
+		return 1
+	}
+	return siteCfg.Concurrency
+}
@@ -0,0 +1,97 @@
+package scraper
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"tct_scrooper/config"
+)
+
+func TestEngineHostStagger(t *testing.T) {
+	e := newEngine(nil, 2, 50*time.Millisecond)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if err := e.waitHost(ctx, "api.example.com"); err != nil {
+			t.Fatalf("waitHost: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("second start on the same host after %v, want >= 50ms", elapsed)
+	}
+
+	start = time.Now()
+	if err := e.waitHost(ctx, "other.example.com"); err != nil {
+		t.Fatalf("waitHost: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 20*time.Millisecond {
+		t.Fatalf("first start on another host waited %v", elapsed)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	e.waitHost(ctx, "slow.example.com")
+	if err := e.waitHost(cancelled, "slow.example.com"); err == nil {
+		t.Fatalf("expected cancelled wait to fail")
+	}
+}
+
+func TestSiteHost(t *testing.T) {
+	cases := []struct {
+		site config.SiteConfig
+		want string
+	}{
+		{config.SiteConfig{ID: "a", Handler: "apify"}, "api.apify.com"},
+		{config.SiteConfig{ID: "b", Handler: "browser", Endpoints: map[string]string{"search": "https://api37.realtor.ca/Listing.svc/PropertySearch_Post"}}, "api37.realtor.ca"},
+		{config.SiteConfig{ID: "c", Handler: "declarative", Declarative: &config.DeclarativeConfig{
+			Request: config.RequestTemplate{URL: "https://example.com/search?page={{.Page}}"}}}, "example.com"},
+		{config.SiteConfig{ID: "d", Handler: "api"}, "d"},
+	}
+	for _, c := range cases {
+		if got := siteHost(&c.site); got != c.want {
+			t.Errorf("%s: expected host %s, got %s", c.site.ID, c.want, got)
+		}
+	}
+}
+
+func TestEngineMergesIntoRunningJob(t *testing.T) {
+	e := newEngine(nil, 1, 0)
+	ctx := context.Background()
+
+	release := make(chan struct{})
+	calls := 0
+	resume := func(ctx context.Context, siteID string) error {
+		calls++
+		<-release
+		return nil
+	}
+	job, queued := e.submit(ctx, "a", resume)
+	if !queued {
+		t.Fatalf("expected the first job to be queued")
+	}
+
+	// A run requested while the site is resuming joins the resume
+	again, queued := e.submit(ctx, "a", func(context.Context, string) error {
+		t.Errorf("merged run must not execute")
+		return nil
+	})
+	if queued || again != job {
+		t.Fatalf("expected the request to merge into the running job")
+	}
+
+	close(release)
+	if err := job.Wait(); err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected one execution, got %d", calls)
+	}
+
+	next, queued := e.submit(ctx, "a", func(context.Context, string) error { return nil })
+	if !queued || next == job {
+		t.Fatalf("expected a new job once the previous one finished")
+	}
+	next.Wait()
+}
@@ -20,6 +20,8 @@ func NewHandler(siteCfg *config.SiteConfig) Handler {
 		return NewBrowserHandler(siteCfg)
 	case "apify":
 		return NewApifyHandler(siteCfg)
+	case "declarative":
+		return NewDeclarativeHandler(siteCfg)
 	default:
 		return NewAPIHandler(siteCfg)
 	}
@@ -0,0 +1,112 @@
+package scraper
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// evalJSONPath evaluates a small JSONPath subset against a decoded document:
+// dotted keys, [n] indexes and [*] wildcards, with an optional leading "$".
+// e.g. "$.Results[*]", "Property.Address.AddressText", "Property.Photo[0].HighResPath"
+func evalJSONPath(doc interface{}, path string) ([]interface{}, error) {
+	segments, err := splitJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	current := []interface{}{doc}
+	for _, seg := range segments {
+		var next []interface{}
+		for _, node := range current {
+			next = append(next, seg.apply(node)...)
+		}
+		current = next
+	}
+	return current, nil
+}
+
+type jsonPathSegment struct {
+	key      string // "" when segment is a bare index
+	index    int
+	hasIndex bool
+	wildcard bool
+}
+
+func (s jsonPathSegment) apply(node interface{}) []interface{} {
+	if s.key != "" {
+		obj, ok := node.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		node, ok = obj[s.key]
+		if !ok || node == nil {
+			return nil
+		}
+	}
+
+	if !s.hasIndex && !s.wildcard {
+		return []interface{}{node}
+	}
+
+	arr, ok := node.([]interface{})
+	if !ok {
+		return nil
+	}
+	if s.wildcard {
+		return arr
+	}
+	idx := s.index
+	if idx < 0 {
+		idx += len(arr)
+	}
+	if idx < 0 || idx >= len(arr) {
+		return nil
+	}
+	return []interface{}{arr[idx]}
+}
+
+func splitJSONPath(path string) ([]jsonPathSegment, error) {
+	path = strings.TrimSpace(path)
+	path = strings.TrimPrefix(path, "$")
+	path = strings.TrimPrefix(path, ".")
+	if path == "" {
+		return nil, nil
+	}
+
+	var segments []jsonPathSegment
+	for _, part := range strings.Split(path, ".") {
+		if part == "" {
+			return nil, fmt.Errorf("invalid path %q: empty segment", path)
+		}
+
+		key := part
+		brackets := ""
+		if i := strings.Index(part, "["); i >= 0 {
+			key, brackets = part[:i], part[i:]
+		}
+		if key != "" || brackets == "" {
+			segments = append(segments, jsonPathSegment{key: key})
+		}
+
+		for brackets != "" {
+			end := strings.Index(brackets, "]")
+			if !strings.HasPrefix(brackets, "[") || end < 0 {
+				return nil, fmt.Errorf("invalid path %q: bad index in %q", path, part)
+			}
+			inner := brackets[1:end]
+			brackets = brackets[end+1:]
+
+			if inner == "*" {
+				segments = append(segments, jsonPathSegment{wildcard: true})
+				continue
+			}
+			idx, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, fmt.Errorf("invalid path %q: bad index %q", path, inner)
+			}
+			segments = append(segments, jsonPathSegment{index: idx, hasIndex: true})
+		}
+	}
+	return segments, nil
+}
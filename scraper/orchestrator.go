@@ -3,14 +3,22 @@ package scraper
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
+	"tct_scrooper/browser"
+	"tct_scrooper/challenge"
 	"tct_scrooper/config"
+	"tct_scrooper/httputil"
 	"tct_scrooper/models"
 	"tct_scrooper/services"
 	"tct_scrooper/storage"
+	"tct_scrooper/vpn"
 )
 
 type Orchestrator struct {
@@ -18,6 +26,15 @@ type Orchestrator struct {
 	store    *storage.SQLiteStore
 	handlers map[string]Handler
 	paused   bool
+	vpn      *vpn.Manager
+	egress   *httputil.EgressChecker
+
+	challenges   *challenge.Tracker
+	blockedHosts map[string]map[string]bool // site -> hosts its handler was blocked on
+
+	mu     sync.Mutex
+	active map[string]*siteRun // sites with a run in progress (incl. resumed runs)
+	engine *Engine
 
 	// Postgres services
 	pgStore            *storage.PostgresStore
@@ -25,6 +42,7 @@ type Orchestrator struct {
 	matchService       *services.MatchService
 	mediaService       *services.MediaService
 	healthcheckService *services.HealthcheckService
+	quarantineService  *services.QuarantineService
 }
 
 func NewOrchestrator(cfg *config.Config, store *storage.SQLiteStore) *Orchestrator {
@@ -36,15 +54,21 @@ func NewOrchestrator(cfg *config.Config, store *storage.SQLiteStore) *Orchestrat
 		}
 		if ah, ok := handler.(*ApifyHandler); ok {
 			ah.SetStore(store)
+			if !ah.fullRegion() {
+				log.Printf("Site %s: %s results are never a full region, so its listings are not checked for disappearance", id, ah.actor)
+			}
 		}
 		handlers[id] = handler
 	}
 
-	return &Orchestrator{
+	o := &Orchestrator{
 		cfg:      cfg,
 		store:    store,
 		handlers: handlers,
+		active:   make(map[string]*siteRun),
 	}
+	o.engine = newEngine(o, cfg.Scraper.MaxConcurrency, time.Duration(cfg.Scraper.RegionStaggerSecs)*time.Second)
+	return o
 }
 
 // SetServices injects the new Postgres-based services
@@ -69,22 +93,178 @@ func (o *Orchestrator) SetServices(
 	}
 }
 
+// SetApifyWebhooks lets Apify handlers wait on webhook callbacks instead of polling
+func (o *Orchestrator) SetApifyWebhooks(server *ApifyWebhookServer) {
+	for _, handler := range o.handlers {
+		if ah, ok := handler.(*ApifyHandler); ok {
+			ah.SetWebhooks(server)
+		}
+	}
+}
+
+// SetQuarantine enables per-site validation rules; failing listings are
+// quarantined instead of processed
+func (o *Orchestrator) SetQuarantine(q *services.QuarantineService) {
+	o.quarantineService = q
+}
+
+// rateLimited is implemented by handlers that request target sites, which
+// share the daemon's per-host rate limiter
+type rateLimited interface {
+	SetRateLimiter(limiter *httputil.HostLimiter)
+}
+
+// SetRateLimiter makes handlers wait on the shared per-host limiter before
+// each request to a target site
+func (o *Orchestrator) SetRateLimiter(limiter *httputil.HostLimiter) {
+	for _, handler := range o.handlers {
+		if rl, ok := handler.(rateLimited); ok {
+			rl.SetRateLimiter(limiter)
+		}
+	}
+}
+
+// fingerprinted is implemented by handlers whose net/http requests go out
+// with a fingerprint profile
+type fingerprinted interface {
+	SetFingerprints(fingerprints *httputil.Fingerprints)
+}
+
+// SetFingerprints gives each site's requests a fingerprint profile, rotated
+// when the site blocks it
+func (o *Orchestrator) SetFingerprints(fingerprints *httputil.Fingerprints) {
+	for _, handler := range o.handlers {
+		if fh, ok := handler.(fingerprinted); ok {
+			fh.SetFingerprints(fingerprints)
+		}
+	}
+}
+
+// vpnAware is implemented by handlers that load pages over the VPN tunnel
+// and report the bot-protection blocks they hit
+type vpnAware interface {
+	SetVPN(m *vpn.Manager)
+}
+
+// SetVPN makes runs check the VPN tunnel before scraping and lets browser
+// handlers report blocks, which rotate the VPN region
+func (o *Orchestrator) SetVPN(m *vpn.Manager) {
+	o.vpn = m
+	for _, handler := range o.handlers {
+		if va, ok := handler.(vpnAware); ok {
+			va.SetVPN(m)
+		}
+	}
+}
+
+// SetEgressCheck makes runs verify the clients' exit IPs first and refuse to
+// start when one fails the egress policy
+func (o *Orchestrator) SetEgressCheck(c *httputil.EgressChecker) {
+	o.egress = c
+}
+
+// SetChallengeTracker counts the bot-protection blocks handlers report per
+// host. While a site's hosts are backed off (SiteBackoff), its failed regions
+// are not retried and scheduled runs skip it.
+func (o *Orchestrator) SetChallengeTracker(t *challenge.Tracker) {
+	o.challenges = t
+}
+
+// SiteBackoff is how much longer the site's hosts are backed off after
+// repeated blocks; zero when it can be scraped
+func (o *Orchestrator) SiteBackoff(siteID string) time.Duration {
+	if o.challenges == nil {
+		return 0
+	}
+	var hosts []string
+	if siteCfg, ok := o.cfg.Sites[siteID]; ok {
+		hosts = siteCfg.Hosts()
+	}
+	o.mu.Lock()
+	for host := range o.blockedHosts[siteID] {
+		hosts = append(hosts, host)
+	}
+	o.mu.Unlock()
+	return o.challenges.Backoff(hosts...)
+}
+
+// recordBlocks passes the blocks on a region report to the tracker
+func (o *Orchestrator) recordBlocks(siteID string, report *RegionReport) {
+	if o.challenges == nil || len(report.Blocks) == 0 {
+		return
+	}
+	o.mu.Lock()
+	if o.blockedHosts == nil {
+		o.blockedHosts = make(map[string]map[string]bool)
+	}
+	if o.blockedHosts[siteID] == nil {
+		o.blockedHosts[siteID] = make(map[string]bool)
+	}
+	for _, b := range report.Blocks {
+		o.blockedHosts[siteID][b.Host] = true
+	}
+	o.mu.Unlock()
+
+	for _, b := range report.Blocks {
+		o.challenges.Record(b.Host, "", b.Kind)
+	}
+}
+
+// SetBrowserPool gives browser handlers the shared browser pool
+func (o *Orchestrator) SetBrowserPool(pool *browser.Pool) {
+	for _, handler := range o.handlers {
+		if bh, ok := handler.(*BrowserHandler); ok {
+			bh.SetBrowserPool(pool)
+		}
+	}
+}
+
+// SetArchive makes Apify handlers archive each fetched dataset to disk
+func (o *Orchestrator) SetArchive(archive *storage.DatasetArchive) {
+	for _, handler := range o.handlers {
+		if ah, ok := handler.(*ApifyHandler); ok {
+			ah.SetArchive(archive)
+		}
+	}
+}
+
 func (o *Orchestrator) RunAll(ctx context.Context) error {
+	return o.RunSites(ctx, o.GetSiteIDs())
+}
+
+// RunSites queues a run of each site and waits for them all
+func (o *Orchestrator) RunSites(ctx context.Context, siteIDs []string) error {
 	if o.paused {
 		log.Println("Scraper is paused, skipping run")
 		return nil
 	}
 
-	for siteID := range o.cfg.Sites {
-		if err := o.RunSite(ctx, siteID); err != nil {
-			log.Printf("Error running site %s: %v", siteID, err)
+	var jobs []*Job
+	for _, siteID := range siteIDs {
+		jobs = append(jobs, o.engine.Submit(ctx, siteID))
+	}
+	for _, job := range jobs {
+		if err := job.Wait(); err != nil {
+			log.Printf("Error running site %s: %v", job.SiteID, err)
 		}
 	}
 
 	return nil
 }
 
+// RunSite queues a run of the site and waits for it; if the site is already
+// queued or running, it waits for that run instead
 func (o *Orchestrator) RunSite(ctx context.Context, siteID string) error {
+	return o.engine.Submit(ctx, siteID).Wait()
+}
+
+// Enqueue queues a run of the site without waiting for it
+func (o *Orchestrator) Enqueue(ctx context.Context, siteID string) {
+	o.engine.Submit(ctx, siteID)
+}
+
+// runSite executes one run of a site; called by the engine
+func (o *Orchestrator) runSite(ctx context.Context, siteID string) error {
 	siteCfg, ok := o.cfg.Sites[siteID]
 	if !ok {
 		return fmt.Errorf("unknown site: %s", siteID)
@@ -95,6 +275,28 @@ func (o *Orchestrator) RunSite(ctx context.Context, siteID string) error {
 		return fmt.Errorf("no handler for site: %s", siteID)
 	}
 
+	if !o.tryStart(siteID) {
+		log.Printf("Site %s already has a run in progress, skipping", siteID)
+		return nil
+	}
+	defer o.finish(siteID)
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	o.attachRun(siteID, 0, cancel)
+
+	if err := o.vpn.EnsureConnected(ctx); err != nil {
+		return fmt.Errorf("vpn: %w", err)
+	}
+	if err := o.verifyEgress(ctx, siteID, "Run refused"); err != nil {
+		return err
+	}
+
+	// Checked again before each region; this avoids recording an empty run
+	if allowed, _ := o.checkApifyBudget(ctx, siteID, siteCfg, nil, 0); !allowed {
+		return nil
+	}
+
 	// Create run record (SQLite for TUI compatibility)
 	run := &models.ScrapeRun{
 		SiteID:    siteID,
@@ -120,6 +322,7 @@ func (o *Orchestrator) RunSite(ctx context.Context, siteID string) error {
 			log.Printf("Warning: failed to create Postgres run: %v", err)
 		} else {
 			pgRunID = &pgRun.ID
+			o.attachRun(siteID, pgRun.ID, cancel)
 		}
 	}
 
@@ -128,73 +331,888 @@ func (o *Orchestrator) RunSite(ctx context.Context, siteID string) error {
 	// Track stats for new services
 	stats := &services.ProcessStats{}
 
-	defer func() {
-		now := time.Now()
-		run.FinishedAt = &now
-		o.store.UpdateRun(run)
-		o.store.UpdateSiteStats(siteID)
-
-		// Update Postgres run
-		if pgRunID != nil {
-			pgRun := &models.DomainScrapeRun{
-				ID:            *pgRunID,
-				FinishedAt:    &now,
-				Status:        "completed",
-				ListingsFound: stats.ListingsProcessed,
-				ListingsNew:   stats.ListingsNew,
-				PropertiesNew: stats.PropertiesNew,
-				ErrorsCount:   stats.Errors,
-				Metadata:      stats.ToJSON(),
-			}
-			if run.Status == models.RunStatusFailed {
-				pgRun.Status = "failed"
-			}
-			o.pgStore.UpdateScrapeRun(ctx, pgRun)
+	regionIDs := sortedRegionIDs(siteCfg)
+	progress := o.scrapeRegions(ctx, run, siteID, handler, pgRunID, regionIDs, stats)
+	return o.endRun(ctx, run, pgRunID, len(regionIDs), progress, stats)
+}
+
+// regionProgress tallies how the regions of a run ended
+type regionProgress struct {
+	Completed   int
+	Failed      int
+	Skipped     int  // not attempted, e.g. the Apify budget ran out
+	Drifted     bool // a region's payloads drifted from the schema baseline
+	Interrupted bool // the context was cancelled: by shutdown, or by a cancel command
+	LastErr     error
+}
+
+// runStatus derives a run's final status: completed if every region
+// completed cleanly, failed if none did, partial otherwise
+func (p *regionProgress) runStatus(total int) string {
+	switch {
+	case p.Completed == 0 && total > 0:
+		return "failed"
+	case p.Completed == total && !p.Drifted:
+		return "completed"
+	default:
+		return "partial"
+	}
+}
+
+// sortedRegionIDs returns a site's region IDs in a stable order
+func sortedRegionIDs(siteCfg *config.SiteConfig) []string {
+	ids := make([]string, 0, len(siteCfg.Regions))
+	for id := range siteCfg.Regions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// regionRun is the state shared by the concurrently scraped regions of one
+// run; mu guards run, stats and progress
+type regionRun struct {
+	mu       sync.Mutex
+	run      *models.ScrapeRun
+	siteID   string
+	handler  Handler
+	pgRunID  *int64
+	stats    *services.ProcessStats
+	progress regionProgress
+}
+
+// scrapeRegions scrapes and ingests the given regions, up to the site's
+// concurrency at a time within the engine's global limit. A region that
+// keeps failing after its retries is marked failed and the remaining regions
+// still run; each finished region checkpoints the run. Ingestion is
+// serialized so regions never write the same properties concurrently.
+func (o *Orchestrator) scrapeRegions(ctx context.Context, run *models.ScrapeRun, siteID string, handler Handler,
+	pgRunID *int64, regionIDs []string, stats *services.ProcessStats) regionProgress {
+	siteCfg := o.cfg.Sites[siteID]
+	rr := &regionRun{run: run, siteID: siteID, handler: handler, pgRunID: pgRunID, stats: stats}
+	host := siteHost(siteCfg)
+	siteSlots := make(chan struct{}, siteConcurrency(siteCfg))
+
+	var wg sync.WaitGroup
+	for i, regionID := range regionIDs {
+		select {
+		case siteSlots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			rr.mu.Lock()
+			rr.progress.Interrupted = true
+			rr.mu.Unlock()
+			break
 		}
-	}()
 
-	isFirst := true
-	for regionID, region := range siteCfg.Regions {
-		// Stagger between regions (skip first)
-		if !isFirst && o.cfg.Scraper.RegionStaggerSecs > 0 {
-			stagger := time.Duration(o.cfg.Scraper.RegionStaggerSecs) * time.Second
-			o.log(run.ID, models.LogLevelInfo, fmt.Sprintf("Waiting %v before next region", stagger), siteID)
-			time.Sleep(stagger)
+		rr.mu.Lock()
+		allowed, maxListings := o.checkApifyBudget(ctx, siteID, siteCfg, pgRunID, stats.ApifyUsageUSD)
+		if !allowed {
+			o.log(run.ID, models.LogLevelWarn, fmt.Sprintf("Apify budget reached, skipping remaining regions from %s", regionID), siteID)
+			rr.progress.Skipped = len(regionIDs) - i
+		}
+		rr.mu.Unlock()
+		if !allowed {
+			<-siteSlots
+			break
 		}
-		isFirst = false
 
-		o.log(run.ID, models.LogLevelInfo, fmt.Sprintf("Scraping region: %s", regionID), siteID)
+		wg.Add(1)
+		go func(regionID string) {
+			defer wg.Done()
+			defer func() { <-siteSlots }()
+			o.runRegion(ctx, rr, host, regionID, maxListings)
+		}(regionID)
+	}
+	wg.Wait()
+
+	return rr.progress
+}
+
+// runRegion scrapes one region in an engine slot and ingests its listings
+func (o *Orchestrator) runRegion(ctx context.Context, rr *regionRun, host, regionID string, maxListings int) {
+	release, err := o.engine.acquire(ctx, host)
+	if err != nil {
+		rr.mu.Lock()
+		rr.progress.Interrupted = true
+		rr.mu.Unlock()
+		return
+	}
+	defer release()
+
+	o.log(rr.run.ID, models.LogLevelInfo, fmt.Sprintf("Scraping region: %s", regionID), rr.siteID)
+	listings, report, err := o.scrapeRegionWithRetry(ctx, rr, regionID, maxListings)
+
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	if err != nil {
+		if ctx.Err() != nil {
+			rr.progress.Interrupted = true
+		} else {
+			rr.progress.Failed++
+			rr.progress.LastErr = err
+		}
+		return
+	}
+
+	// The listings are already fetched (and paid for); ingest them even if
+	// the run is cancelled meanwhile so they count in its partial stats
+	ingestCtx := context.WithoutCancel(ctx)
+	drifted := o.checkSchemaDrift(ingestCtx, rr.run, rr.siteID, regionID, report, rr.stats)
+	if drifted {
+		rr.progress.Drifted = true
+	}
+	ingested := o.ingestRegion(ingestCtx, rr.run, rr.siteID, regionID, listings, rr.pgRunID, rr.stats)
+	if !drifted {
+		o.detectMissing(ingestCtx, rr.run, rr.siteID, regionID, listings, report, rr.stats)
+	}
+	o.checkCoverage(rr.run, rr.siteID, regionID, report, len(listings), ingested, rr.stats)
+	o.completeRegion(ingestCtx, rr.pgRunID, regionID, report, len(listings), ingested)
+	o.checkpointRun(ingestCtx, rr.pgRunID, rr.stats)
+	rr.progress.Completed++
+}
+
+// scrapeRegionWithRetry scrapes one region, retrying failures with
+// exponential backoff. Each attempt is recorded on the region's row.
+func (o *Orchestrator) scrapeRegionWithRetry(ctx context.Context, rr *regionRun, regionID string, maxListings int) ([]models.RawListing, *RegionReport, error) {
+	region := o.cfg.Sites[rr.siteID].Regions[regionID]
+	attempts := o.cfg.Scraper.RegionRetries + 1
+	backoff := time.Duration(o.cfg.Scraper.RegionRetryBackoffSecs) * time.Second
 
-		listings, err := handler.Scrape(ctx, region)
+	for attempt := 1; ; attempt++ {
+		o.recordRegion(ctx, rr.pgRunID, &models.ScrapeRunRegion{Region: regionID, Status: "running", Attempts: attempt})
+
+		report := &RegionReport{}
+		regionCtx := withRegionScope(ctx, &regionScope{PgRunID: rr.pgRunID, RegionID: regionID, Report: report, MaxListings: maxListings})
+		listings, err := rr.handler.Scrape(regionCtx, region)
+
+		rr.mu.Lock()
+		report.AddTo(rr.stats, regionID)
 		if err != nil {
-			o.log(run.ID, models.LogLevelError, fmt.Sprintf("Scrape error for %s: %v", regionID, err), siteID)
-			run.ErrorsCount++
-			run.Status = models.RunStatusFailed
-			return err
+			rr.run.ErrorsCount++
+		}
+		rr.mu.Unlock()
+		o.recordBlocks(rr.siteID, report)
+		if err == nil {
+			return listings, report, nil
+		}
+
+		o.log(rr.run.ID, models.LogLevelError, fmt.Sprintf("Scrape error for %s (attempt %d/%d): %v", regionID, attempt, attempts, err), rr.siteID)
+		if runCancelled(ctx) {
+			o.recordRegion(ctx, rr.pgRunID, &models.ScrapeRunRegion{Region: regionID, Status: "cancelled"})
+			return nil, nil, err
+		}
+		if ctx.Err() != nil {
+			// Shutting down; leave the region running for the next start
+			return nil, nil, err
+		}
+		fingerprints := strings.Join(report.Fingerprints, ",")
+		if attempt >= attempts {
+			o.recordRegion(ctx, rr.pgRunID, &models.ScrapeRunRegion{Region: regionID, Status: "failed", LastError: err.Error(), Fingerprints: fingerprints})
+			return nil, nil, err
+		}
+		if wait := o.SiteBackoff(rr.siteID); wait > 0 && errors.Is(err, challenge.ErrBlocked) {
+			o.log(rr.run.ID, models.LogLevelWarn, fmt.Sprintf("Not retrying %s: blocked repeatedly, backing off for %v", regionID, wait.Round(time.Minute)), rr.siteID)
+			o.recordRegion(ctx, rr.pgRunID, &models.ScrapeRunRegion{Region: regionID, Status: "failed", LastError: err.Error(), Fingerprints: fingerprints})
+			return nil, nil, err
+		}
+		o.recordRegion(ctx, rr.pgRunID, &models.ScrapeRunRegion{Region: regionID, Status: "running", LastError: err.Error(), Fingerprints: fingerprints})
+
+		wait := backoff << (attempt - 1)
+		o.log(rr.run.ID, models.LogLevelInfo, fmt.Sprintf("Retrying %s in %v", regionID, wait), rr.siteID)
+		if !sleepCtx(ctx, wait) {
+			return nil, nil, ctx.Err()
+		}
+	}
+}
+
+// endRun finalizes a run from its region progress. A cancelled run is
+// finalized as cancelled with the stats it got to; a run interrupted by
+// shutdown is checkpointed but left running in Postgres so the next start
+// resumes it.
+func (o *Orchestrator) endRun(ctx context.Context, run *models.ScrapeRun, pgRunID *int64, total int,
+	progress regionProgress, stats *services.ProcessStats) error {
+	siteID := run.SiteID
+	if progress.Interrupted && runCancelled(ctx) {
+		run.Status = models.RunStatusCancelled
+		o.finishRun(ctx, run, pgRunID, "cancelled", stats)
+		o.log(run.ID, models.LogLevelWarn,
+			fmt.Sprintf("Run cancelled: %d/%d regions completed, %d failed; %d found, %d new properties",
+				progress.Completed, total, progress.Failed, run.ListingsFound, stats.PropertiesNew), siteID)
+		return nil
+	}
+	if progress.Interrupted {
+		run.Status = models.RunStatusFailed
+		o.checkpointRun(ctx, pgRunID, stats)
+		o.finishRun(ctx, run, nil, "", stats)
+		o.log(run.ID, models.LogLevelWarn, "Interrupted, run will resume on next start", siteID)
+		return ctx.Err()
+	}
+
+	pgStatus := progress.runStatus(total)
+	run.Status = models.RunStatusCompleted
+	if pgStatus == "failed" {
+		run.Status = models.RunStatusFailed
+	}
+	o.finishRun(ctx, run, pgRunID, pgStatus, stats)
+
+	o.log(run.ID, models.LogLevelInfo,
+		fmt.Sprintf("Run %s: %d/%d regions completed, %d failed, %d skipped; %d found, %d new properties, %d relisted, %d price changes",
+			pgStatus, progress.Completed, total, progress.Failed, progress.Skipped,
+			run.ListingsFound, stats.PropertiesNew, stats.Relisted, stats.PriceChanges), siteID)
+
+	if pgStatus == "failed" && progress.LastErr != nil {
+		return progress.LastErr
+	}
+	return nil
+}
+
+// checkpointRun saves a running run's stats so a restart can carry them on
+func (o *Orchestrator) checkpointRun(ctx context.Context, pgRunID *int64, stats *services.ProcessStats) {
+	if pgRunID == nil {
+		return
+	}
+	err := o.pgStore.UpdateScrapeRun(context.WithoutCancel(ctx), &models.DomainScrapeRun{
+		ID:            *pgRunID,
+		Status:        "running",
+		ListingsFound: stats.ListingsProcessed,
+		ListingsNew:   stats.ListingsNew,
+		PropertiesNew: stats.PropertiesNew,
+		ErrorsCount:   stats.Errors,
+		Metadata:      stats.ToJSON(),
+	})
+	if err != nil {
+		log.Printf("Warning: failed to checkpoint run %d: %v", *pgRunID, err)
+	}
+}
+
+// sleepCtx waits for d; false if the context was cancelled first
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// checkApifyBudget compares the site's Apify spend this month, with the
+// current run (pgRunID, if any) counted as runSpend rather than what it last
+// checkpointed, against its apify_budget. It reports whether scraping may
+// continue and the listing cap to apply (0 = no override).
+func (o *Orchestrator) checkApifyBudget(ctx context.Context, siteID string, siteCfg *config.SiteConfig, pgRunID *int64, runSpend float64) (bool, int) {
+	budget := siteCfg.ApifyBudget
+	if budget == nil || siteCfg.Handler != "apify" || o.pgStore == nil {
+		return true, 0
+	}
+
+	now := time.Now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	var excludeRunID int64
+	if pgRunID != nil {
+		excludeRunID = *pgRunID
+	}
+	spent, err := o.pgStore.GetApifySpendSince(ctx, siteID, monthStart, excludeRunID)
+	if err != nil {
+		log.Printf("Warning: failed to read Apify spend for %s, not enforcing budget: %v", siteID, err)
+		return true, 0
+	}
+	spent += runSpend
+
+	if spent >= budget.MonthlyUSD {
+		log.Printf("Site %s: Apify spend $%.2f reached monthly budget $%.2f, skipping", siteID, spent, budget.MonthlyUSD)
+		return false, 0
+	}
+	if budget.DownscaleAt > 0 && budget.DownscaleMaxListings > 0 && spent >= budget.DownscaleAt*budget.MonthlyUSD {
+		limit := budget.DownscaleMaxListings
+		if siteCfg.ApifyMaxListings > 0 && siteCfg.ApifyMaxListings < limit {
+			limit = siteCfg.ApifyMaxListings
 		}
+		log.Printf("Site %s: Apify spend $%.2f of $%.2f budget, capping runs at %d listings",
+			siteID, spent, budget.MonthlyUSD, limit)
+		return true, limit
+	}
+	return true, 0
+}
 
-		run.ListingsFound += len(listings)
+// validationRules returns the site's validation rules, or nil (accept
+// everything) when there is nowhere to quarantine failing listings
+func (o *Orchestrator) validationRules(siteID string) *config.ValidationRules {
+	siteCfg, ok := o.cfg.Sites[siteID]
+	if !ok || o.quarantineService == nil {
+		return nil
+	}
+	return siteCfg.Validation
+}
+
+// ingestRegion feeds a region's listings through the services layer,
+// quarantining those that fail the site's validation rules. It returns the
+// number of listings ingested.
+func (o *Orchestrator) ingestRegion(ctx context.Context, run *models.ScrapeRun, siteID, regionID string, listings []models.RawListing, pgRunID *int64, stats *services.ProcessStats) int {
+	run.ListingsFound += len(listings)
+
+	rules := o.validationRules(siteID)
 
-		propsBeforeRegion := stats.PropertiesNew
-		for _, listing := range listings {
-			if err := o.processListing(ctx, run, &listing, siteID, pgRunID, stats); err != nil {
-				o.log(run.ID, models.LogLevelError, fmt.Sprintf("Process error for %s: %v", listing.MLS, err), siteID)
+	propsBeforeRegion := stats.PropertiesNew
+	quarantinedBefore := stats.Quarantined
+	ingested := 0
+	for _, listing := range listings {
+		if reasons := services.ValidateListing(&listing, rules); len(reasons) > 0 {
+			if err := o.quarantineService.Quarantine(ctx, &listing, siteID, regionID, pgRunID, reasons); err != nil {
+				o.log(run.ID, models.LogLevelError, fmt.Sprintf("Quarantine error for %s: %v", listing.MLS, err), siteID)
 				run.ErrorsCount++
 				stats.Errors++
+			} else {
+				stats.Quarantined++
 			}
+			continue
 		}
-		regionNew := stats.PropertiesNew - propsBeforeRegion
-		o.log(run.ID, models.LogLevelInfo, fmt.Sprintf("Region %s: %d listings, %d new", regionID, len(listings), regionNew), siteID)
+
+		if err := o.processListing(ctx, run, &listing, siteID, pgRunID, stats); err != nil {
+			o.log(run.ID, models.LogLevelError, fmt.Sprintf("Process error for %s: %v", listing.MLS, err), siteID)
+			run.ErrorsCount++
+			stats.Errors++
+			continue
+		}
+		ingested++
 	}
+	regionNew := stats.PropertiesNew - propsBeforeRegion
+	msg := fmt.Sprintf("Region %s: %d listings, %d new", regionID, len(listings), regionNew)
+	if n := stats.Quarantined - quarantinedBefore; n > 0 {
+		msg += fmt.Sprintf(", %d quarantined", n)
+	}
+	o.log(run.ID, models.LogLevelInfo, msg, siteID)
+	return ingested
+}
+
+// checkCoverage compares the listings ingested for a region with the total
+// the source reported for it and flags the run when coverage falls below
+// the configured minimum. Partial results are not checked; they fall short
+// by design.
+func (o *Orchestrator) checkCoverage(run *models.ScrapeRun, siteID, regionID string, report *RegionReport,
+	count, ingested int, stats *services.ProcessStats) {
+	pct, low := lowCoverage(report, ingested, o.cfg.Scraper.CoverageMinPct)
+	if !low {
+		return
+	}
+	if stats.LowCoverage == nil {
+		stats.LowCoverage = make(map[string]int)
+	}
+	stats.LowCoverage[regionID] = pct
+	o.log(run.ID, models.LogLevelWarn,
+		fmt.Sprintf("Region %s: low coverage %d%%: source reported %d, fetched %d, %d after filtering, %d ingested",
+			regionID, pct, report.ExpectedTotal, report.Fetched, count, ingested), siteID)
+}
+
+// lowCoverage returns the percentage of the source's reported total that was
+// ingested, and whether it falls below minPct
+func lowCoverage(report *RegionReport, ingested, minPct int) (int, bool) {
+	if report.ExpectedTotal <= 0 || report.Partial != "" {
+		return 0, false
+	}
+	pct := ingested * 100 / report.ExpectedTotal
+	return pct, pct < minPct
+}
+
+// detectMissing runs disappearance detection after a region was ingested.
+// Only a full result is compared: a partial one would mark everything it
+// left out as missing.
+func (o *Orchestrator) detectMissing(ctx context.Context, run *models.ScrapeRun, siteID, regionID string,
+	listings []models.RawListing, report *RegionReport, stats *services.ProcessStats) {
+	if o.listingService == nil {
+		return
+	}
+	if reason := report.partialReason(); reason != "" {
+		log.Printf("Region %s: partial result (%s), skipping disappearance check", regionID, reason)
+		return
+	}
+
+	seen := make([]string, 0, len(listings))
+	for _, l := range listings {
+		if l.MLS != "" {
+			seen = append(seen, l.MLS)
+		}
+	}
+
+	grace := time.Duration(o.cfg.Scraper.MissingGraceHours) * time.Hour
+	result, err := o.listingService.DetectMissing(ctx, siteID, regionID, seen, grace)
+	if err != nil {
+		o.log(run.ID, models.LogLevelError, fmt.Sprintf("Disappearance check for %s failed: %v", regionID, err), siteID)
+		return
+	}
+	if result.Skipped {
+		o.log(run.ID, models.LogLevelWarn, fmt.Sprintf("Region %s: too many listings missing at once, not marking them", regionID), siteID)
+		return
+	}
+
+	stats.Missing += result.Missing
+	stats.MissingDelisted += result.Delisted
+	if result.Missing > 0 || result.Delisted > 0 {
+		o.log(run.ID, models.LogLevelInfo, fmt.Sprintf("Region %s: %d listings missing, %d delisted after grace period",
+			regionID, result.Missing, result.Delisted), siteID)
+	}
+}
+
+// finishRun finalizes the SQLite run and, if present, the Postgres run
+func (o *Orchestrator) finishRun(ctx context.Context, run *models.ScrapeRun, pgRunID *int64, pgStatus string, stats *services.ProcessStats) {
+	now := time.Now()
+	run.FinishedAt = &now
+	o.store.UpdateRun(run)
+	o.store.UpdateSiteStats(run.SiteID)
+
+	if pgRunID != nil {
+		pgRun := &models.DomainScrapeRun{
+			ID:            *pgRunID,
+			FinishedAt:    &now,
+			Status:        pgStatus,
+			ListingsFound: stats.ListingsProcessed,
+			ListingsNew:   stats.ListingsNew,
+			PropertiesNew: stats.PropertiesNew,
+			ErrorsCount:   stats.Errors,
+			Metadata:      stats.ToJSON(),
+		}
+		o.pgStore.UpdateScrapeRun(context.WithoutCancel(ctx), pgRun)
+	}
+}
+
+func (o *Orchestrator) setRegionStatus(ctx context.Context, pgRunID *int64, regionID, status string) {
+	o.recordRegion(ctx, pgRunID, &models.ScrapeRunRegion{Region: regionID, Status: status})
+}
+
+// recordRegion upserts a region's row on the Postgres run, if there is one
+func (o *Orchestrator) recordRegion(ctx context.Context, pgRunID *int64, r *models.ScrapeRunRegion) {
+	if pgRunID == nil {
+		return
+	}
+	r.RunID = *pgRunID
+	if err := o.pgStore.UpsertScrapeRunRegion(context.WithoutCancel(ctx), r); err != nil {
+		log.Printf("Warning: failed to update region %s status: %v", r.Region, err)
+	}
+}
+
+// completeRegion marks a region completed with the number of listings it
+// returned, the history later results are checked against, and its coverage
+// counts
+func (o *Orchestrator) completeRegion(ctx context.Context, pgRunID *int64, regionID string, report *RegionReport, count, ingested int) {
+	if pgRunID == nil {
+		return
+	}
+	var expected, daysBack *int
+	if report.ExpectedTotal > 0 {
+		expected = &report.ExpectedTotal
+	}
+	if report.DaysBack > 0 {
+		daysBack = &report.DaysBack
+	}
+	err := o.pgStore.UpsertScrapeRunRegion(context.WithoutCancel(ctx), &models.ScrapeRunRegion{
+		RunID:         *pgRunID,
+		Region:        regionID,
+		Status:        "completed",
+		ListingsCount: &count,
+		ExpectedTotal: expected,
+		FetchedCount:  &report.Fetched,
+		IngestedCount: &ingested,
+		DaysBack:      daysBack,
+		Fingerprints:  strings.Join(report.Fingerprints, ","),
+	})
+	if err != nil {
+		log.Printf("Warning: failed to update region %s status: %v", regionID, err)
+	}
+}
+
+// Replay re-ingests an archived dataset (file path) or an existing Apify
+// dataset ID through the normal pipeline, recorded as a "replay:<site>" run
+func (o *Orchestrator) Replay(ctx context.Context, siteID, regionID, source string) error {
+	siteCfg, ok := o.cfg.Sites[siteID]
+	if !ok {
+		return fmt.Errorf("unknown site: %s", siteID)
+	}
+	region, ok := siteCfg.Regions[regionID]
+	if !ok {
+		return fmt.Errorf("unknown region %s for site %s", regionID, siteID)
+	}
+	handler, ok := o.handlers[siteID].(*ApifyHandler)
+	if !ok {
+		return fmt.Errorf("site %s does not use the apify handler", siteID)
+	}
+	if o.pgStore == nil {
+		return fmt.Errorf("postgres not configured")
+	}
+
+	// The SQLite run is recorded under replay:<site>, as the Postgres one is,
+	// so its logs can be found without shifting the site's incremental window
+	run := &models.ScrapeRun{SiteID: "replay:" + siteID, StartedAt: time.Now(), Status: models.RunStatusRunning}
+	runID, err := o.store.CreateRun(run)
+	if err != nil {
+		return fmt.Errorf("create run: %w", err)
+	}
+	run.ID = runID
+
+	pgRun := &models.DomainScrapeRun{
+		Source:    "replay:" + siteID,
+		StartedAt: run.StartedAt,
+		Status:    "running",
+	}
+	if err := o.pgStore.CreateScrapeRun(ctx, pgRun); err != nil {
+		run.Status = models.RunStatusFailed
+		o.finishReplay(run)
+		return fmt.Errorf("create run: %w", err)
+	}
+
+	stats := &services.ProcessStats{}
+	report := &RegionReport{}
+	regionCtx := withRegionScope(ctx, &regionScope{RegionID: regionID, Report: report})
+
+	o.log(run.ID, models.LogLevelInfo, fmt.Sprintf("Replaying %s into run %d (%s/%s)", source, pgRun.ID, siteID, regionID), siteID)
+	listings, err := handler.replay(regionCtx, region, source)
+	report.AddTo(stats, regionID)
+
+	now := time.Now()
+	pgRun.FinishedAt = &now
+	if err != nil {
+		pgRun.Status = "failed"
+		pgRun.ErrorMessage = err.Error()
+		pgRun.Metadata = stats.ToJSON()
+		o.pgStore.UpdateScrapeRun(context.WithoutCancel(ctx), pgRun)
+		run.Status = models.RunStatusFailed
+		run.ErrorsCount = stats.Errors
+		o.finishReplay(run)
+		o.log(run.ID, models.LogLevelError, fmt.Sprintf("Replay failed: %v", err), siteID)
+		return err
+	}
+
+	o.ingestRegion(ctx, run, siteID, regionID, listings, &pgRun.ID, stats)
+
+	pgRun.Status = "completed"
+	pgRun.ListingsFound = stats.ListingsProcessed
+	pgRun.ListingsNew = stats.ListingsNew
+	pgRun.PropertiesNew = stats.PropertiesNew
+	pgRun.ErrorsCount = stats.Errors
+	pgRun.Metadata = stats.ToJSON()
+	o.pgStore.UpdateScrapeRun(context.WithoutCancel(ctx), pgRun)
 
 	run.Status = models.RunStatusCompleted
-	o.log(run.ID, models.LogLevelInfo,
-		fmt.Sprintf("Completed: %d found, %d new properties, %d relisted, %d price changes",
-			run.ListingsFound, stats.PropertiesNew, stats.Relisted, stats.PriceChanges), siteID)
+	run.ListingsNew = stats.ListingsNew
+	run.PropertiesNew = stats.PropertiesNew
+	run.ErrorsCount = stats.Errors
+	o.finishReplay(run)
+
+	o.log(run.ID, models.LogLevelInfo, fmt.Sprintf("Replay complete: %d processed, %d new properties, %d new listings, %d price changes, %d errors, %d parse failures",
+		stats.ListingsProcessed, stats.PropertiesNew, stats.ListingsNew, stats.PriceChanges, stats.Errors, stats.ParseFailures), siteID)
+	return nil
+}
+
+// finishReplay finalizes a replay's SQLite run. Unlike finishRun it leaves
+// site stats alone, which would otherwise list replay:<site> as a site.
+func (o *Orchestrator) finishReplay(run *models.ScrapeRun) {
+	now := time.Now()
+	run.FinishedAt = &now
+	o.store.UpdateRun(run)
+}
+
+// siteRun is the run a site has in progress. cancel is nil until the run has
+// started; pgRunID is 0 until it has a Postgres run.
+type siteRun struct {
+	pgRunID int64
+	cancel  context.CancelCauseFunc
+}
+
+// tryStart marks a site as running; false if it already is
+func (o *Orchestrator) tryStart(siteID string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.active[siteID] != nil {
+		return false
+	}
+	o.active[siteID] = &siteRun{}
+	return true
+}
+
+// attachRun registers the run a started site is executing, so cancel
+// commands can reach it
+func (o *Orchestrator) attachRun(siteID string, pgRunID int64, cancel context.CancelCauseFunc) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if r := o.active[siteID]; r != nil {
+		r.pgRunID = pgRunID
+		r.cancel = cancel
+	}
+}
+
+func (o *Orchestrator) finish(siteID string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.active, siteID)
+}
+
+// ResumeRuns picks up scrape runs the previous daemon left running. Apify
+// actor runs that were in flight are reattached and their datasets ingested
+// instead of paying for new runs; regions that never finished are scraped
+// again. Each site's resumes are submitted to the engine before returning,
+// so runs requested meanwhile merge into them; they are then processed in
+// the background.
+func (o *Orchestrator) ResumeRuns(ctx context.Context) error {
+	if o.pgStore == nil {
+		return nil
+	}
+
+	runs, err := o.pgStore.GetRunningScrapeRuns(ctx)
+	if err != nil {
+		return err
+	}
+	if len(runs) == 0 {
+		return nil
+	}
+
+	apifyRegions, err := o.pgStore.GetUnfinishedApifyRegions(ctx)
+	if err != nil {
+		return err
+	}
+	byRun := make(map[int64][]models.ScrapeRunRegion)
+	for _, r := range apifyRegions {
+		byRun[r.RunID] = append(byRun[r.RunID], r)
+	}
+
+	log.Printf("Resuming %d unfinished run(s), %d with Apify runs to reattach", len(runs), len(byRun))
+
+	// One site can have several stale runs; process them in order per site
+	var siteIDs []string
+	bySite := make(map[string][]models.DomainScrapeRun)
+	for _, run := range runs {
+		if _, ok := o.cfg.Sites[run.Source]; !ok {
+			log.Printf("Cannot resume run %d: site %s is not configured", run.ID, run.Source)
+			o.closeStaleRun(ctx, &run, "failed", "resume: site not configured")
+			continue
+		}
+		if _, ok := bySite[run.Source]; !ok {
+			siteIDs = append(siteIDs, run.Source)
+		}
+		bySite[run.Source] = append(bySite[run.Source], run)
+	}
+
+	// Each site's resumes are its engine job, so runs submitted meanwhile
+	// merge into it and its regions share the engine's slots
+	for _, siteID := range siteIDs {
+		runs := bySite[siteID]
+		resume := func(ctx context.Context, siteID string) error {
+			o.resumeSite(ctx, siteID, runs, byRun)
+			return nil
+		}
+		if _, queued := o.engine.submit(ctx, siteID, resume); !queued {
+			log.Printf("Site %s already has a run in progress, not resuming its %d run(s)", siteID, len(runs))
+		}
+	}
+	return nil
+}
 
+// verifyEgress runs the egress check before scraping siteID, logging a
+// failure as what (e.g. "Run refused")
+func (o *Orchestrator) verifyEgress(ctx context.Context, siteID, what string) error {
+	if err := o.egress.Verify(ctx); err != nil {
+		msg := fmt.Sprintf("%s, egress check failed: %v", what, err)
+		log.Printf("[%s] %s: %s", models.LogLevelError, siteID, msg)
+		o.store.Log(nil, models.LogLevelError, msg, siteID)
+		return fmt.Errorf("egress: %w", err)
+	}
 	return nil
 }
 
+// resumeSite resumes a site's unfinished runs in order; called by the engine
+func (o *Orchestrator) resumeSite(ctx context.Context, siteID string, runs []models.DomainScrapeRun, byRun map[int64][]models.ScrapeRunRegion) {
+	if !o.tryStart(siteID) {
+		log.Printf("Site %s already has a run in progress, not resuming its %d run(s)", siteID, len(runs))
+		return
+	}
+	defer o.finish(siteID)
+
+	// The same pre-flight as runSite; runs that fail it are closed, not
+	// resumed through an unchecked exit
+	var refused string
+	if err := o.vpn.EnsureConnected(ctx); err != nil {
+		refused = fmt.Sprintf("resume: vpn: %v", err)
+	} else if err := o.verifyEgress(ctx, siteID, "Resume refused"); err != nil {
+		refused = "resume: " + err.Error()
+	}
+	if refused != "" {
+		for i := range runs {
+			o.abortApifyRuns(ctx, siteID, byRun[runs[i].ID])
+			o.closeStaleRun(ctx, &runs[i], "failed", refused)
+		}
+		return
+	}
+
+	siteCancelled := false
+	for i := range runs {
+		if siteCancelled {
+			o.abortApifyRuns(ctx, siteID, byRun[runs[i].ID])
+			o.closeStaleRun(ctx, &runs[i], "cancelled", "")
+			continue
+		}
+		runCtx, cancel := context.WithCancelCause(ctx)
+		o.attachRun(siteID, runs[i].ID, cancel)
+		o.resumeRun(runCtx, &runs[i], byRun[runs[i].ID])
+		siteCancelled = errors.Is(context.Cause(runCtx), errSiteCancelled)
+		cancel(nil)
+	}
+}
+
+// closeStaleRun finalizes an unfinished run without resuming it, keeping the
+// stats it last checkpointed
+func (o *Orchestrator) closeStaleRun(ctx context.Context, run *models.DomainScrapeRun, status, reason string) {
+	now := time.Now()
+	err := o.pgStore.UpdateScrapeRun(context.WithoutCancel(ctx), &models.DomainScrapeRun{
+		ID: run.ID, FinishedAt: &now, Status: status, ErrorMessage: reason,
+		ListingsFound: run.ListingsFound, ListingsNew: run.ListingsNew, PropertiesNew: run.PropertiesNew,
+		ErrorsCount: run.ErrorsCount, Metadata: run.Metadata,
+	})
+	if err != nil {
+		log.Printf("Warning: failed to close run %d: %v", run.ID, err)
+	}
+}
+
+// abortApifyRuns stops the recorded actor runs of a run that is not resumed
+func (o *Orchestrator) abortApifyRuns(ctx context.Context, siteID string, regions []models.ScrapeRunRegion) {
+	ah, ok := o.handlers[siteID].(*ApifyHandler)
+	if !ok {
+		return
+	}
+	for _, r := range regions {
+		ah.abortRun(ctx, r.ApifyRunID)
+	}
+}
+
+// abortCancelled stops the actor runs of regions a cancelled run won't
+// reattach to, which would otherwise run on (and bill) unseen. On shutdown
+// they are left running for the next start to reattach.
+func (o *Orchestrator) abortCancelled(ctx context.Context, siteID string, regions []models.ScrapeRunRegion) {
+	if runCancelled(ctx) {
+		o.abortApifyRuns(ctx, siteID, regions)
+	}
+}
+
+// resumeRun finishes one unfinished run from its region checkpoints; the
+// caller holds the site
+func (o *Orchestrator) resumeRun(ctx context.Context, pgRun *models.DomainScrapeRun, apifyRegions []models.ScrapeRunRegion) {
+	siteID := pgRun.Source
+	pgRunID := pgRun.ID
+	siteCfg := o.cfg.Sites[siteID]
+	handler := o.handlers[siteID]
+
+	rows, err := o.pgStore.GetScrapeRunRegions(ctx, pgRunID)
+	if err != nil {
+		log.Printf("Cannot resume run %d: %v", pgRunID, err)
+		return
+	}
+
+	run := &models.ScrapeRun{
+		SiteID:    siteID,
+		StartedAt: time.Now(),
+		Status:    models.RunStatusRunning,
+	}
+	runID, err := o.store.CreateRun(run)
+	if err != nil {
+		log.Printf("Cannot resume run %d: %v", pgRunID, err)
+		return
+	}
+	run.ID = runID
+
+	stats := services.RestoreProcessStats(pgRun)
+	regionIDs := sortedRegionIDs(siteCfg)
+
+	// Regions that completed or used up their retries stay as they are
+	var progress regionProgress
+	progress.Drifted = len(stats.SchemaDrift) > 0
+	settled := make(map[string]bool)
+	for _, r := range rows {
+		if _, ok := siteCfg.Regions[r.Region]; !ok {
+			continue
+		}
+		switch r.Status {
+		case "completed":
+			progress.Completed++
+			settled[r.Region] = true
+		case "failed":
+			progress.Failed++
+			settled[r.Region] = true
+		}
+	}
+
+	o.log(run.ID, models.LogLevelInfo, fmt.Sprintf("Resuming run %d: %d/%d regions done, %d Apify run(s) to reattach",
+		pgRunID, progress.Completed+progress.Failed, len(regionIDs), len(apifyRegions)), siteID)
+
+	if ah, ok := handler.(*ApifyHandler); ok {
+		for i, r := range apifyRegions {
+			region, ok := siteCfg.Regions[r.Region]
+			if !ok {
+				o.log(run.ID, models.LogLevelWarn, fmt.Sprintf("Region %s no longer configured, dropping apify run %s", r.Region, r.ApifyRunID), siteID)
+				o.setRegionStatus(ctx, &pgRunID, r.Region, "failed")
+				continue
+			}
+
+			release, err := o.engine.acquire(ctx, siteHost(siteCfg))
+			if err != nil {
+				progress.Interrupted = true
+				o.abortCancelled(ctx, siteID, apifyRegions[i:])
+				break
+			}
+			report := &RegionReport{}
+			regionCtx := withRegionScope(ctx, &regionScope{PgRunID: &pgRunID, RegionID: r.Region, Report: report})
+			listings, err := ah.Resume(regionCtx, region, r.ApifyRunID, r.ApifyActor)
+			release()
+			report.AddTo(stats, r.Region)
+			if err != nil {
+				o.log(run.ID, models.LogLevelError, fmt.Sprintf("Reattach error for %s, scraping it again: %v", r.Region, err), siteID)
+				run.ErrorsCount++
+				if ctx.Err() != nil {
+					// Resume aborted this region's actor run itself
+					progress.Interrupted = true
+					o.abortCancelled(ctx, siteID, apifyRegions[i+1:])
+					break
+				}
+				continue
+			}
+
+			ingestCtx := context.WithoutCancel(ctx)
+			drifted := o.checkSchemaDrift(ingestCtx, run, siteID, r.Region, report, stats)
+			if drifted {
+				progress.Drifted = true
+			}
+			ingested := o.ingestRegion(ingestCtx, run, siteID, r.Region, listings, &pgRunID, stats)
+			if !drifted {
+				o.detectMissing(ingestCtx, run, siteID, r.Region, listings, report, stats)
+			}
+			o.checkCoverage(run, siteID, r.Region, report, len(listings), ingested, stats)
+			o.completeRegion(ingestCtx, &pgRunID, r.Region, report, len(listings), ingested)
+			o.checkpointRun(ingestCtx, &pgRunID, stats)
+			settled[r.Region] = true
+			progress.Completed++
+		}
+	}
+
+	if !progress.Interrupted {
+		var remaining []string
+		for _, id := range regionIDs {
+			if !settled[id] {
+				remaining = append(remaining, id)
+			}
+		}
+		p := o.scrapeRegions(ctx, run, siteID, handler, &pgRunID, remaining, stats)
+		progress.Completed += p.Completed
+		progress.Failed += p.Failed
+		progress.Skipped += p.Skipped
+		progress.Drifted = progress.Drifted || p.Drifted
+		progress.Interrupted = p.Interrupted
+		progress.LastErr = p.LastErr
+	}
+
+	if err := o.endRun(ctx, run, &pgRunID, len(regionIDs), progress, stats); err != nil {
+		log.Printf("Resumed run %d: %v", pgRunID, err)
+	}
+}
+
 func (o *Orchestrator) processListing(ctx context.Context, run *models.ScrapeRun, listing *models.RawListing, siteID string, pgRunID *int64, stats *services.ProcessStats) error {
 	if o.listingService == nil {
 		return fmt.Errorf("listing service not initialized")
@@ -219,33 +1237,98 @@ func (o *Orchestrator) processListing(ctx context.Context, run *models.ScrapeRun
 	return nil
 }
 
-func (o *Orchestrator) HandleCommand(cmd *models.Command) error {
+// HandleCommand applies a queued command. Runs it starts are bound to ctx, so
+// they are interrupted on shutdown like scheduled runs.
+func (o *Orchestrator) HandleCommand(ctx context.Context, cmd *models.Command) error {
 	params, err := o.store.ParseCommandParams(cmd)
 	if err != nil {
 		return err
 	}
 
-	ctx := context.Background()
-
 	switch cmd.Command {
 	case models.CmdScrapeNow:
-		return o.RunAll(ctx)
+		o.enqueueAll(ctx)
 	case models.CmdScrapeSite:
-		if params.Site != "" {
-			return o.RunSite(ctx, params.Site)
+		if params.Site == "" {
+			o.enqueueAll(ctx)
+		} else if _, ok := o.cfg.Sites[params.Site]; !ok {
+			return fmt.Errorf("unknown site: %s", params.Site)
+		} else {
+			o.Enqueue(ctx, params.Site)
 		}
-		return o.RunAll(ctx)
 	case models.CmdPause:
 		o.paused = true
 		log.Println("Scraper paused")
 	case models.CmdResume:
 		o.paused = false
 		log.Println("Scraper resumed")
+	case models.CmdCancelRun:
+		return o.CancelRun(params.RunID)
+	case models.CmdCancelSite:
+		return o.CancelSite(params.Site)
 	}
 
 	return nil
 }
 
+// errRunCancelled is the cancel cause of a run stopped by a cancel command.
+// Any other cancellation is a shutdown, which leaves the run to resume.
+var errRunCancelled = errors.New("run cancelled")
+
+// errSiteCancelled also stops the resumed runs queued behind the current one
+var errSiteCancelled = fmt.Errorf("site %w", errRunCancelled)
+
+// runCancelled reports whether ctx was cancelled by a cancel command
+func runCancelled(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errRunCancelled)
+}
+
+// CancelRun cancels the in-progress run with the given Postgres run ID
+func (o *Orchestrator) CancelRun(pgRunID int64) error {
+	// Runs without a Postgres run are attached as 0; only CancelSite reaches them
+	if pgRunID <= 0 {
+		return fmt.Errorf("invalid run id %d", pgRunID)
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for siteID, r := range o.active {
+		if r.pgRunID == pgRunID && r.cancel != nil {
+			log.Printf("Cancelling run %d (%s)", pgRunID, siteID)
+			r.cancel(errRunCancelled)
+			return nil
+		}
+	}
+	return fmt.Errorf("run %d is not in progress", pgRunID)
+}
+
+// CancelSite cancels the site's in-progress run, including any unfinished
+// runs still queued to resume
+func (o *Orchestrator) CancelSite(siteID string) error {
+	if _, ok := o.cfg.Sites[siteID]; !ok {
+		return fmt.Errorf("unknown site: %s", siteID)
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	r := o.active[siteID]
+	if r == nil || r.cancel == nil {
+		return fmt.Errorf("site %s has no run in progress", siteID)
+	}
+	log.Printf("Cancelling run of %s", siteID)
+	r.cancel(errSiteCancelled)
+	return nil
+}
+
+// enqueueAll queues a run of every site unless paused, without waiting
+func (o *Orchestrator) enqueueAll(ctx context.Context) {
+	if o.paused {
+		log.Println("Scraper is paused, skipping run")
+		return
+	}
+	for siteID := range o.cfg.Sites {
+		o.Enqueue(ctx, siteID)
+	}
+}
+
 func (o *Orchestrator) IsPaused() bool {
 	return o.paused
 }
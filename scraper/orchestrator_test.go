@@ -0,0 +1,173 @@
+package scraper
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"tct_scrooper/config"
+	"tct_scrooper/models"
+	"tct_scrooper/services"
+)
+
+func TestRegionProgressRunStatus(t *testing.T) {
+	cases := []struct {
+		name     string
+		progress regionProgress
+		want     string
+	}{
+		{"all completed", regionProgress{Completed: 3}, "completed"},
+		{"one failed", regionProgress{Completed: 2, Failed: 1}, "partial"},
+		{"budget skipped", regionProgress{Completed: 1, Skipped: 2}, "partial"},
+		{"drifted", regionProgress{Completed: 3, Drifted: true}, "partial"},
+		{"none completed", regionProgress{Failed: 3}, "failed"},
+	}
+	for _, c := range cases {
+		if got := c.progress.runStatus(3); got != c.want {
+			t.Errorf("%s: expected %s, got %s", c.name, c.want, got)
+		}
+	}
+}
+
+func TestCancelRun(t *testing.T) {
+	o := &Orchestrator{
+		cfg:    &config.Config{Sites: map[string]*config.SiteConfig{"a": {ID: "a"}, "b": {ID: "b"}}},
+		active: make(map[string]*siteRun),
+	}
+	if err := o.CancelSite("a"); err == nil {
+		t.Fatalf("expected error cancelling a site with no run")
+	}
+
+	o.tryStart("a")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	o.attachRun("a", 42, cancel)
+
+	if err := o.CancelRun(7); err == nil {
+		t.Fatalf("expected error cancelling an unknown run")
+	}
+
+	// A run with no Postgres run is attached as 0, which a cancel_run
+	// command without run_id must not reach
+	o.tryStart("b")
+	bctx, bcancel := context.WithCancelCause(context.Background())
+	defer bcancel(nil)
+	o.attachRun("b", 0, bcancel)
+	if err := o.CancelRun(0); err == nil {
+		t.Fatalf("expected error cancelling run 0")
+	}
+	if runCancelled(bctx) {
+		t.Fatalf("run 0 must not be cancelled by id")
+	}
+	if runCancelled(ctx) {
+		t.Fatalf("run should not be cancelled yet")
+	}
+	if err := o.CancelRun(42); err != nil {
+		t.Fatalf("cancel run: %v", err)
+	}
+	if !runCancelled(ctx) {
+		t.Fatalf("expected run to be cancelled by command")
+	}
+
+	shutdown, stop := context.WithCancel(context.Background())
+	stop()
+	if runCancelled(shutdown) {
+		t.Fatalf("shutdown must not count as a cancel")
+	}
+}
+
+func TestValidationRulesNeedQuarantine(t *testing.T) {
+	rules := &config.ValidationRules{MinPrice: 10000}
+	o := &Orchestrator{cfg: &config.Config{Sites: map[string]*config.SiteConfig{"a": {ID: "a", Validation: rules}}}}
+
+	// Without a quarantine there is nowhere to route failing listings, so
+	// they are all ingested
+	if got := o.validationRules("a"); got != nil {
+		t.Fatalf("expected no rules without a quarantine, got %+v", got)
+	}
+
+	o.SetQuarantine(&services.QuarantineService{})
+	if got := o.validationRules("a"); got != rules {
+		t.Fatalf("expected the site's rules, got %+v", got)
+	}
+	if got := o.validationRules("unknown"); got != nil {
+		t.Fatalf("expected no rules for an unknown site, got %+v", got)
+	}
+}
+
+func TestPartialReasonSkipsCappedResults(t *testing.T) {
+	cases := []struct {
+		name    string
+		report  RegionReport
+		partial bool
+	}{
+		{"full", RegionReport{ExpectedTotal: 500, Fetched: 500}, false},
+		{"no total reported", RegionReport{Fetched: 120}, false},
+		{"handler reported partial", RegionReport{Partial: "listing cap"}, true},
+		// realtor.ca stops at MaxRecords: 600 of 700 must not mark 100 missing
+		{"capped by the source", RegionReport{ExpectedTotal: 700, Fetched: 600}, true},
+		{"source total grew mid-run", RegionReport{ExpectedTotal: 501, Fetched: 500}, true},
+	}
+	for _, c := range cases {
+		if got := c.report.partialReason() != ""; got != c.partial {
+			t.Errorf("%s: expected partial=%v, got %q", c.name, c.partial, c.report.partialReason())
+		}
+	}
+}
+
+func TestLowCoverage(t *testing.T) {
+	cases := []struct {
+		name     string
+		report   RegionReport
+		ingested int
+		pct      int
+		low      bool
+	}{
+		{"full", RegionReport{ExpectedTotal: 200, Fetched: 200}, 200, 100, false},
+		{"at the minimum", RegionReport{ExpectedTotal: 200, Fetched: 200}, 180, 90, false},
+		{"below the minimum", RegionReport{ExpectedTotal: 200, Fetched: 200}, 179, 89, true},
+		{"lost in filtering", RegionReport{ExpectedTotal: 200, Fetched: 200}, 40, 20, true},
+		{"no total reported", RegionReport{Fetched: 200}, 10, 0, false},
+		{"partial by design", RegionReport{ExpectedTotal: 200, Fetched: 50, Partial: "listing cap"}, 50, 0, false},
+	}
+	for _, c := range cases {
+		pct, low := lowCoverage(&c.report, c.ingested, 90)
+		if pct != c.pct || low != c.low {
+			t.Errorf("%s: expected %d%% low=%v, got %d%% low=%v", c.name, c.pct, c.low, pct, low)
+		}
+	}
+}
+
+// abortRecorder answers Apify abort calls, recording the aborted run IDs
+type abortRecorder struct{ aborted []string }
+
+func (a *abortRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	parts := strings.Split(req.URL.Path, "/")
+	a.aborted = append(a.aborted, parts[len(parts)-2])
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}")), Header: make(http.Header)}, nil
+}
+
+func TestAbortCancelled(t *testing.T) {
+	recorder := &abortRecorder{}
+	h := newApifyHandler(&config.SiteConfig{ID: "a"}, "canadesk")
+	h.client = &http.Client{Transport: recorder}
+	o := &Orchestrator{handlers: map[string]Handler{"a": h}}
+	regions := []models.ScrapeRunRegion{{Region: "r1", ApifyRunID: "run1"}, {Region: "r2", ApifyRunID: "run2"}}
+
+	// Shutdown leaves the actor runs going for the next start to reattach
+	shutdown, stop := context.WithCancel(context.Background())
+	stop()
+	o.abortCancelled(shutdown, "a", regions)
+	if len(recorder.aborted) != 0 {
+		t.Fatalf("expected no aborts on shutdown, got %v", recorder.aborted)
+	}
+
+	cancelled, cancel := context.WithCancelCause(context.Background())
+	cancel(errRunCancelled)
+	o.abortCancelled(cancelled, "a", regions)
+	if strings.Join(recorder.aborted, ",") != "run1,run2" {
+		t.Fatalf("expected the remaining actor runs aborted, got %v", recorder.aborted)
+	}
+}
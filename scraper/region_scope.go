@@ -0,0 +1,159 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+
+	"tct_scrooper/challenge"
+	"tct_scrooper/services"
+)
+
+// regionScope carries the run context of the region being scraped from the
+// orchestrator into handlers, without widening the Handler interface
+type regionScope struct {
+	PgRunID     *int64
+	RegionID    string
+	Report      *RegionReport
+	MaxListings int // overrides the site's listing cap when > 0 (budget downscale)
+}
+
+// RegionReport collects what a handler observed while scraping a region,
+// beyond the listings it returns
+type RegionReport struct {
+	ParseFailures int // source items the adapter could not parse
+
+	ApifyUsageUSD     float64
+	ApifyComputeUnits float64
+
+	Adapter string // Apify adapter that produced the listings (after fallback)
+
+	Schemas map[string]*SchemaProfile // payload fingerprints by source, for drift checks
+
+	// Coverage: the region's listing total as reported by the source (0 when
+	// it doesn't report one) and the listings fetched before region filtering
+	ExpectedTotal int
+	Fetched       int
+
+	// DaysBack is the listing window the actor was asked for; 0 when the
+	// adapter returns the region's full set. Only results over the same
+	// window are comparable.
+	DaysBack int
+
+	// Partial says why the listings are not the region's full set (an
+	// incremental window, a listing cap, pagination cut short); empty when
+	// they are, which is what disappearance detection requires
+	Partial string
+
+	// Blocks are the bot-protection blocks met while scraping the region
+	Blocks []*challenge.BlockError
+
+	// Fingerprints are the fingerprint profiles the region's requests went
+	// out as, in the order first used; none for Apify, whose actors fetch
+	Fingerprints []string
+}
+
+// partialReason says why the listings are not the region's full set, or ""
+// if they are. Besides what the handler reported, a source that caps its
+// results (realtor.ca stops at MaxRecords) shows as fetching fewer listings
+// than it reported for the region.
+func (r *RegionReport) partialReason() string {
+	if r.Partial != "" {
+		return r.Partial
+	}
+	if r.ExpectedTotal > 0 && r.Fetched < r.ExpectedTotal {
+		return fmt.Sprintf("fetched %d of %d", r.Fetched, r.ExpectedTotal)
+	}
+	return ""
+}
+
+// resetAttempt clears what describes a single attempt's result, so an
+// adapter falling back to another doesn't leave its partial result,
+// coverage or payload schemas behind. Costs and blocks accumulate.
+func (r *RegionReport) resetAttempt() {
+	r.Partial = ""
+	r.ExpectedTotal = 0
+	r.Fetched = 0
+	r.DaysBack = 0
+	r.Schemas = nil
+}
+
+// AddTo folds the report for regionID into the run's stats
+func (r *RegionReport) AddTo(stats *services.ProcessStats, regionID string) {
+	if r.Adapter != "" {
+		if stats.Adapters == nil {
+			stats.Adapters = make(map[string]string)
+		}
+		stats.Adapters[regionID] = r.Adapter
+	}
+	stats.ParseFailures += r.ParseFailures
+	stats.ApifyUsageUSD += r.ApifyUsageUSD
+	stats.ApifyComputeUnits += r.ApifyComputeUnits
+}
+
+type regionScopeKey struct{}
+
+func withRegionScope(ctx context.Context, scope *regionScope) context.Context {
+	return context.WithValue(ctx, regionScopeKey{}, scope)
+}
+
+// regionScopeFrom returns the scope set by the orchestrator, or nil when the
+// handler is called directly (tests, validate-site)
+func regionScopeFrom(ctx context.Context) *regionScope {
+	scope, _ := ctx.Value(regionScopeKey{}).(*regionScope)
+	return scope
+}
+
+// reportPartial notes on the region report that the handler returned only
+// part of the region's listings
+func reportPartial(ctx context.Context, reason string) {
+	if scope := regionScopeFrom(ctx); scope != nil && scope.Report != nil && scope.Report.Partial == "" {
+		scope.Report.Partial = reason
+	}
+}
+
+// reportCoverage records the source's reported total for the region and how
+// many listings were fetched from it. It replaces earlier values, so with
+// adapter fallback the adapter whose result is used reports last.
+func reportCoverage(ctx context.Context, expected, fetched int) {
+	if scope := regionScopeFrom(ctx); scope != nil && scope.Report != nil {
+		scope.Report.ExpectedTotal = expected
+		scope.Report.Fetched = fetched
+	}
+}
+
+// reportWindow records the listing window the region was scraped with
+func reportWindow(ctx context.Context, daysBack int) {
+	if scope := regionScopeFrom(ctx); scope != nil && scope.Report != nil {
+		scope.Report.DaysBack = daysBack
+	}
+}
+
+// reportFingerprint records on the region report the fingerprint profile a
+// request for the region went out as
+func reportFingerprint(ctx context.Context, name string) {
+	if name == "" {
+		return
+	}
+	scope := regionScopeFrom(ctx)
+	if scope == nil || scope.Report == nil {
+		return
+	}
+	for _, seen := range scope.Report.Fingerprints {
+		if seen == name {
+			return
+		}
+	}
+	scope.Report.Fingerprints = append(scope.Report.Fingerprints, name)
+}
+
+// reportBlock records on the region report a bot-protection block the
+// handler ran into, for the orchestrator's block tracking
+func reportBlock(ctx context.Context, err error) {
+	b, ok := challenge.AsBlock(err)
+	if !ok {
+		return
+	}
+	if scope := regionScopeFrom(ctx); scope != nil && scope.Report != nil {
+		scope.Report.Blocks = append(scope.Report.Blocks, b)
+	}
+}
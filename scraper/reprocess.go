@@ -0,0 +1,159 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"tct_scrooper/config"
+	"tct_scrooper/models"
+	"tct_scrooper/services"
+	"tct_scrooper/storage"
+)
+
+// reprocessBatchSize is how many listings are read per query
+const reprocessBatchSize = 500
+
+// listingParser re-derives a RawListing from a stored raw_data payload
+type listingParser struct {
+	name  string
+	parse func(data json.RawMessage) (models.RawListing, error)
+}
+
+// reprocessParsers returns the parsers that may have produced a site's
+// stored payloads. Apify sites list every configured adapter, since
+// fallbacks mean a site's listings can come from any of them.
+func reprocessParsers(siteCfg *config.SiteConfig) ([]listingParser, error) {
+	switch siteCfg.Handler {
+	case "apify":
+		actors := siteCfg.ApifyActor
+		if len(actors) == 0 {
+			actors = config.ActorList{"canadesk"}
+		}
+		var parsers []listingParser
+		for _, actor := range actors {
+			adapter, err := GetApifyAdapter(actor)
+			if err != nil {
+				return nil, err
+			}
+			parsers = append(parsers, listingParser{name: actor, parse: adapter.ParseListing})
+		}
+		return parsers, nil
+	case "api", "browser":
+		// Both store realtor.ca PropertySearch results; the api handler's are
+		// the subset of fields it decodes
+		return []listingParser{{name: siteCfg.Handler, parse: parseRealtorCAResult}}, nil
+	case "declarative":
+		mapper, err := NewListingMapper(siteCfg.Declarative)
+		if err != nil {
+			return nil, err
+		}
+		return []listingParser{{name: "declarative", parse: mapper.ParseItem}}, nil
+	default:
+		return nil, fmt.Errorf("handler %q keeps no reprocessable payloads", siteCfg.Handler)
+	}
+}
+
+// parseStored runs a listing's raw_data through the parsers and returns the
+// first result that parses back to the same listing
+func parseStored(parsers []listingParser, listing *models.Listing) (*models.RawListing, bool) {
+	for _, p := range parsers {
+		raw, err := p.parse(listing.RawData)
+		if err != nil {
+			continue
+		}
+		if raw.MLS == listing.ExternalID {
+			return &raw, true
+		}
+	}
+	return nil, false
+}
+
+// Reprocessor streams stored raw_data back through the source adapters and
+// backfills fields the adapters have since learned to derive
+type Reprocessor struct {
+	cfg     *config.Config
+	service *services.ReprocessService
+	parsers map[string][]listingParser
+}
+
+// NewReprocessor creates a Reprocessor for the configured sites
+func NewReprocessor(cfg *config.Config, service *services.ReprocessService) *Reprocessor {
+	return &Reprocessor{
+		cfg:     cfg,
+		service: service,
+		parsers: make(map[string][]listingParser),
+	}
+}
+
+// Run reprocesses the listings matching the filter. onChange, if set, is
+// called for every listing that gains fields. With dryRun nothing is written.
+func (r *Reprocessor) Run(ctx context.Context, filter storage.ReprocessFilter, dryRun bool,
+	onChange func(listing *models.Listing, changes []services.FieldChange)) (*services.ReprocessSummary, error) {
+	if filter.Source != "" {
+		if _, ok := r.cfg.Sites[filter.Source]; !ok {
+			return nil, fmt.Errorf("unknown site: %s", filter.Source)
+		}
+	}
+
+	summary := &services.ReprocessSummary{}
+	after := uuid.Nil
+	for {
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+
+		batch, err := r.service.NextBatch(ctx, filter, after, reprocessBatchSize)
+		if err != nil {
+			return summary, err
+		}
+		if len(batch) == 0 {
+			return summary, nil
+		}
+
+		for i := range batch {
+			listing := &batch[i]
+			summary.Scanned++
+
+			parsers, err := r.parsersFor(listing.Source)
+			if err != nil {
+				summary.Unparsed++
+				continue
+			}
+			raw, ok := parseStored(parsers, listing)
+			if !ok {
+				summary.Unparsed++
+				continue
+			}
+
+			changes, err := r.service.ApplyDerivedFields(ctx, listing, raw, dryRun)
+			if err != nil {
+				summary.Errors++
+				continue
+			}
+			summary.Add(changes)
+			if len(changes) > 0 && onChange != nil {
+				onChange(listing, changes)
+			}
+		}
+		after = batch[len(batch)-1].ID
+	}
+}
+
+// parsersFor returns the cached parsers for a listing source (site ID)
+func (r *Reprocessor) parsersFor(source string) ([]listingParser, error) {
+	if parsers, ok := r.parsers[source]; ok {
+		return parsers, nil
+	}
+	siteCfg, ok := r.cfg.Sites[source]
+	if !ok {
+		return nil, fmt.Errorf("unknown site: %s", source)
+	}
+	parsers, err := reprocessParsers(siteCfg)
+	if err != nil {
+		return nil, err
+	}
+	r.parsers[source] = parsers
+	return parsers, nil
+}
@@ -0,0 +1,74 @@
+package scraper
+
+import (
+	"encoding/json"
+	"testing"
+
+	"tct_scrooper/config"
+	"tct_scrooper/models"
+)
+
+func TestParseStored(t *testing.T) {
+	var resp struct {
+		Results []json.RawMessage `json:"Results"`
+	}
+	if err := json.Unmarshal(loadFixture(t, "realtor_ca_basic.json"), &resp); err != nil {
+		t.Fatalf("fixture: %v", err)
+	}
+
+	parsers, err := reprocessParsers(&config.SiteConfig{Handler: "browser"})
+	if err != nil {
+		t.Fatalf("parsers: %v", err)
+	}
+
+	listing := &models.Listing{ExternalID: "26001716", RawData: resp.Results[0]}
+	raw, ok := parseStored(parsers, listing)
+	if !ok {
+		t.Fatalf("expected stored payload to parse")
+	}
+	if raw.PostalCode != "N8P0E6" {
+		t.Fatalf("expected postal code N8P0E6, got %q", raw.PostalCode)
+	}
+
+	listing.ExternalID = "99999999"
+	if _, ok := parseStored(parsers, listing); ok {
+		t.Fatalf("payload for another MLS should not match")
+	}
+
+	if _, err := reprocessParsers(&config.SiteConfig{Handler: "scrapingbee"}); err == nil {
+		t.Fatalf("unknown handler should not be reprocessable")
+	}
+}
+
+func TestParseStoredAPIPayload(t *testing.T) {
+	// raw_data as the api handler stores it
+	var result realtorCAListing
+	result.ID = 27123456
+	result.MlsNumber = "26001716"
+	result.RelativeURLEn = "/real-estate/27123456/123-main-st"
+	result.Property.Price = "$549,900"
+	result.Property.Type = "Single Family"
+	result.Property.Address.AddressText = "123 Main St|Windsor, Ontario N8P0E6"
+	result.Building.Bedrooms = 3
+	result.Building.BathroomTotal = 2
+	result.Building.SizeInterior = "1450 sqft"
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	parsers, err := reprocessParsers(&config.SiteConfig{Handler: "api"})
+	if err != nil {
+		t.Fatalf("parsers: %v", err)
+	}
+	raw, ok := parseStored(parsers, &models.Listing{ExternalID: "26001716", RawData: data})
+	if !ok {
+		t.Fatalf("expected the api payload to parse")
+	}
+	if raw.Price != 549900 || raw.Beds != 3 || raw.Baths != 2 || raw.SqFt != 1450 || raw.PropertyType != "Single Family" {
+		t.Fatalf("expected the stored fields back, got %+v", raw)
+	}
+	if raw.URL != "https://www.realtor.ca/real-estate/27123456/123-main-st" {
+		t.Fatalf("unexpected url %q", raw.URL)
+	}
+}
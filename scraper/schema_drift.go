@@ -0,0 +1,262 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+
+	"tct_scrooper/models"
+	"tct_scrooper/services"
+)
+
+const (
+	// A required field drifts when the share of items carrying it with its
+	// baseline type drops by more than schemaDriftThreshold
+	schemaDriftThreshold = 0.2
+	// Datasets smaller than this are too noisy to set or check a baseline
+	schemaMinItems = 20
+	// Non-required fields this common in the baseline are worth a warning
+	// when they disappear
+	schemaCommonPresence = 0.95
+)
+
+// schemaRequirer is implemented by adapters that declare the key paths
+// their structs depend on
+type schemaRequirer interface {
+	RequiredPaths() []string
+}
+
+// SchemaProfile fingerprints the JSON key paths and value types of a set of
+// source items. Array elements share a path with a "[]" suffix, e.g.
+// Property.Photo[].HighResPath.
+type SchemaProfile struct {
+	Required []string
+	Items    int
+	Paths    map[string]map[string]int // path -> JSON type -> items having it
+}
+
+func NewSchemaProfile(required []string) *SchemaProfile {
+	return &SchemaProfile{Required: required, Paths: make(map[string]map[string]int)}
+}
+
+// Add fingerprints one item; items that are not valid JSON are ignored
+func (p *SchemaProfile) Add(item json.RawMessage) {
+	var v interface{}
+	if err := json.Unmarshal(item, &v); err != nil {
+		return
+	}
+	p.Items++
+
+	seen := make(map[string]map[string]bool)
+	collectSchemaPaths(v, "", seen)
+	for path, types := range seen {
+		if p.Paths[path] == nil {
+			p.Paths[path] = make(map[string]int)
+		}
+		for t := range types {
+			p.Paths[path][t]++
+		}
+	}
+}
+
+func collectSchemaPaths(v interface{}, path string, seen map[string]map[string]bool) {
+	if path != "" {
+		if seen[path] == nil {
+			seen[path] = make(map[string]bool)
+		}
+		seen[path][jsonTypeName(v)] = true
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			childPath := k
+			if path != "" {
+				childPath = path + "." + k
+			}
+			collectSchemaPaths(child, childPath, seen)
+		}
+	case []interface{}:
+		for _, child := range val {
+			collectSchemaPaths(child, path+"[]", seen)
+		}
+	}
+}
+
+func jsonTypeName(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "bool"
+	default:
+		return "null"
+	}
+}
+
+// presence is the share of items with path as type t
+func (p *SchemaProfile) presence(path, t string) float64 {
+	if p.Items == 0 {
+		return 0
+	}
+	return float64(p.Paths[path][t]) / float64(p.Items)
+}
+
+// Baseline summarizes the profile as each path's dominant type and presence
+func (p *SchemaProfile) Baseline() map[string]models.SchemaField {
+	fields := make(map[string]models.SchemaField, len(p.Paths))
+	for path, types := range p.Paths {
+		best, bestCount := "", 0
+		for t, n := range types {
+			if n > bestCount || (n == bestCount && t < best) {
+				best, bestCount = t, n
+			}
+		}
+		fields[path] = models.SchemaField{Type: best, Presence: p.presence(path, best)}
+	}
+	return fields
+}
+
+// SchemaDrift is the difference between a profile and its baseline
+type SchemaDrift struct {
+	Missing []string // required fields missing or retyped beyond the threshold
+	Changed []string // other common fields that went missing or changed type
+	Added   []string // paths not in the baseline
+}
+
+// Significant reports whether required fields drifted
+func (d *SchemaDrift) Significant() bool {
+	return len(d.Missing) > 0
+}
+
+// CompareSchema checks a profile against the stored baseline fields
+func CompareSchema(baseline map[string]models.SchemaField, p *SchemaProfile) *SchemaDrift {
+	d := &SchemaDrift{}
+
+	required := make(map[string]bool, len(p.Required))
+	for _, path := range p.Required {
+		required[path] = true
+	}
+
+	for path, field := range baseline {
+		if field.Presence == 0 {
+			continue
+		}
+		current := p.presence(path, field.Type)
+		drop := field.Presence - current
+		switch {
+		case required[path] && drop > schemaDriftThreshold:
+			d.Missing = append(d.Missing, describeSchemaDrift(path, field, current, p.Paths[path]))
+		case !required[path] && field.Presence >= schemaCommonPresence && drop > schemaDriftThreshold:
+			d.Changed = append(d.Changed, describeSchemaDrift(path, field, current, p.Paths[path]))
+		}
+	}
+
+	for path := range p.Paths {
+		if _, ok := baseline[path]; !ok {
+			d.Added = append(d.Added, path)
+		}
+	}
+
+	sort.Strings(d.Missing)
+	sort.Strings(d.Changed)
+	sort.Strings(d.Added)
+	return d
+}
+
+func describeSchemaDrift(path string, field models.SchemaField, current float64, types map[string]int) string {
+	desc := fmt.Sprintf("%s: %s in %.0f%% of items (baseline %.0f%%)", path, field.Type, current*100, field.Presence*100)
+
+	var others []string
+	for t := range types {
+		if t != field.Type {
+			others = append(others, t)
+		}
+	}
+	if len(others) > 0 {
+		sort.Strings(others)
+		desc += fmt.Sprintf(", now seen as %v", others)
+	}
+	return desc
+}
+
+// reportSchema attaches a dataset's profile to the region report under key
+// (the adapter, or browser:<site>) for the orchestrator to check
+func reportSchema(ctx context.Context, key string, profile *SchemaProfile) {
+	scope := regionScopeFrom(ctx)
+	if scope == nil || scope.Report == nil || profile == nil || profile.Items == 0 {
+		return
+	}
+	if scope.Report.Schemas == nil {
+		scope.Report.Schemas = make(map[string]*SchemaProfile)
+	}
+	scope.Report.Schemas[key] = profile
+}
+
+// checkSchemaDrift compares the region's source payloads with each source's
+// stored baseline, creating the baseline on first sight. It returns true if
+// required fields drifted, in which case the run should be marked partial.
+func (o *Orchestrator) checkSchemaDrift(ctx context.Context, run *models.ScrapeRun, siteID, regionID string, report *RegionReport, stats *services.ProcessStats) bool {
+	if o.pgStore == nil {
+		return false
+	}
+
+	drifted := false
+	for key, profile := range report.Schemas {
+		if profile.Items < schemaMinItems {
+			continue
+		}
+
+		baseline, err := o.pgStore.GetSchemaBaseline(ctx, key)
+		if err != nil {
+			log.Printf("Warning: failed to load schema baseline for %s: %v", key, err)
+			continue
+		}
+		if baseline == nil {
+			err := o.pgStore.SaveSchemaBaseline(ctx, &models.SchemaBaseline{Source: key, Fields: profile.Baseline(), Items: profile.Items})
+			if err != nil {
+				log.Printf("Warning: failed to save schema baseline for %s: %v", key, err)
+			} else {
+				o.log(run.ID, models.LogLevelInfo, fmt.Sprintf("Recorded schema baseline for %s from %d items", key, profile.Items), siteID)
+			}
+			continue
+		}
+
+		d := CompareSchema(baseline.Fields, profile)
+		for _, c := range d.Changed {
+			o.log(run.ID, models.LogLevelWarn, fmt.Sprintf("Schema change in %s (%s): %s", key, regionID, c), siteID)
+		}
+		if d.Significant() {
+			drifted = true
+			for _, m := range d.Missing {
+				o.log(run.ID, models.LogLevelWarn, fmt.Sprintf("SCHEMA DRIFT in %s (%s): %s", key, regionID, m), siteID)
+			}
+			if stats.SchemaDrift == nil {
+				stats.SchemaDrift = make(map[string][]string)
+			}
+			stats.SchemaDrift[regionID] = append(stats.SchemaDrift[regionID], d.Missing...)
+			continue
+		}
+
+		// New optional fields are benign; fold them into the baseline so they
+		// are tracked from now on. Existing fields are never relaxed.
+		if len(d.Added) > 0 {
+			current := profile.Baseline()
+			for _, path := range d.Added {
+				baseline.Fields[path] = current[path]
+			}
+			if err := o.pgStore.SaveSchemaBaseline(ctx, baseline); err != nil {
+				log.Printf("Warning: failed to update schema baseline for %s: %v", key, err)
+			}
+		}
+	}
+	return drifted
+}
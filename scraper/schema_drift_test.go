@@ -0,0 +1,68 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func schemaItem(i int, bedrooms interface{}, sizeKey string) json.RawMessage {
+	item := map[string]interface{}{
+		"Id":        fmt.Sprint(i),
+		"MlsNumber": fmt.Sprintf("X%d", i),
+		"Building": map[string]interface{}{
+			"Bedrooms": bedrooms,
+			sizeKey:    "1200 sqft",
+		},
+		"Property": map[string]interface{}{
+			"Photo": []interface{}{map[string]interface{}{"HighResPath": "a.jpg"}},
+		},
+	}
+	data, _ := json.Marshal(item)
+	return data
+}
+
+func TestSchemaDrift(t *testing.T) {
+	required := []string{"MlsNumber", "Building.Bedrooms", "Building.SizeInterior"}
+
+	base := NewSchemaProfile(required)
+	for i := 0; i < 30; i++ {
+		base.Add(schemaItem(i, "3", "SizeInterior"))
+	}
+	baseline := base.Baseline()
+	if f := baseline["Property.Photo[].HighResPath"]; f.Type != "string" || f.Presence != 1 {
+		t.Fatalf("unexpected array element field %+v", f)
+	}
+
+	same := NewSchemaProfile(required)
+	for i := 0; i < 30; i++ {
+		same.Add(schemaItem(i, "2 + 1", "SizeInterior"))
+	}
+	if d := CompareSchema(baseline, same); d.Significant() || len(d.Added) != 0 {
+		t.Fatalf("unexpected drift %+v", d)
+	}
+
+	// Bedrooms becomes a number and SizeInterior is renamed in most items
+	drifted := NewSchemaProfile(required)
+	for i := 0; i < 30; i++ {
+		sizeKey := "SizeInterior"
+		if i >= 10 {
+			sizeKey = "InteriorSize"
+		}
+		drifted.Add(schemaItem(i, 3, sizeKey))
+	}
+	d := CompareSchema(baseline, drifted)
+	if !d.Significant() || len(d.Missing) != 2 {
+		t.Fatalf("expected 2 drifted required fields, got %+v", d)
+	}
+	if !strings.HasPrefix(d.Missing[0], "Building.Bedrooms") || !strings.Contains(d.Missing[0], "[number]") {
+		t.Fatalf("unexpected bedrooms drift %q", d.Missing[0])
+	}
+	if !strings.HasPrefix(d.Missing[1], "Building.SizeInterior") {
+		t.Fatalf("unexpected size drift %q", d.Missing[1])
+	}
+	if len(d.Added) != 1 || d.Added[0] != "Building.InteriorSize" {
+		t.Fatalf("expected InteriorSize as added path, got %v", d.Added)
+	}
+}
@@ -0,0 +1,94 @@
+package scraper
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"tct_scrooper/models"
+)
+
+// ShadowComparison compares the primary and shadow adapter results of one
+// region in shadow mode
+type ShadowComparison struct {
+	Region        string
+	Primary       *models.ShadowResult
+	Shadow        *models.ShadowResult
+	Overlap       int      // MLS numbers returned by both adapters
+	OnlyPrimary   []string // MLS numbers only the primary adapter returned
+	OnlyShadow    []string // MLS numbers only the shadow adapter returned
+	Disagreements []FieldDisagreement
+}
+
+// FieldDisagreement is a compared field that differs between the adapters
+// for the same MLS number
+type FieldDisagreement struct {
+	MLS     string
+	Field   string // price, beds, postal_code
+	Primary string
+	Shadow  string
+}
+
+// CompareShadow matches listings of the two results by MLS number and
+// reports overlap and field-level disagreements
+func CompareShadow(primary, shadow *models.ShadowResult) *ShadowComparison {
+	c := &ShadowComparison{Region: primary.Region, Primary: primary, Shadow: shadow}
+
+	shadowByMLS := make(map[string]models.ShadowListing, len(shadow.Listings))
+	for _, l := range shadow.Listings {
+		shadowByMLS[l.MLS] = l
+	}
+
+	seen := make(map[string]bool, len(primary.Listings))
+	for _, p := range primary.Listings {
+		seen[p.MLS] = true
+		s, ok := shadowByMLS[p.MLS]
+		if !ok {
+			c.OnlyPrimary = append(c.OnlyPrimary, p.MLS)
+			continue
+		}
+		c.Overlap++
+
+		if p.Price != s.Price {
+			c.Disagreements = append(c.Disagreements, FieldDisagreement{p.MLS, "price", strconv.Itoa(p.Price), strconv.Itoa(s.Price)})
+		}
+		if p.Beds != s.Beds {
+			c.Disagreements = append(c.Disagreements, FieldDisagreement{p.MLS, "beds", strconv.Itoa(p.Beds), strconv.Itoa(s.Beds)})
+		}
+		if normalizePostalForCompare(p.PostalCode) != normalizePostalForCompare(s.PostalCode) {
+			c.Disagreements = append(c.Disagreements, FieldDisagreement{p.MLS, "postal_code", p.PostalCode, s.PostalCode})
+		}
+	}
+
+	for _, s := range shadow.Listings {
+		if !seen[s.MLS] {
+			c.OnlyShadow = append(c.OnlyShadow, s.MLS)
+		}
+	}
+
+	sort.Strings(c.OnlyPrimary)
+	sort.Strings(c.OnlyShadow)
+	return c
+}
+
+// DisagreementCounts returns the number of disagreements per field
+func (c *ShadowComparison) DisagreementCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, d := range c.Disagreements {
+		counts[d.Field]++
+	}
+	return counts
+}
+
+// CostPerListing is the adapter's usage divided by the listings it
+// returned, or 0 when it returned none
+func CostPerListing(r *models.ShadowResult) float64 {
+	if len(r.Listings) == 0 {
+		return 0
+	}
+	return r.UsageUSD / float64(len(r.Listings))
+}
+
+func normalizePostalForCompare(s string) string {
+	return strings.ToUpper(strings.ReplaceAll(s, " ", ""))
+}
@@ -0,0 +1,51 @@
+package scraper
+
+import (
+	"testing"
+
+	"tct_scrooper/models"
+)
+
+func TestCompareShadow(t *testing.T) {
+	primary := &models.ShadowResult{
+		Region:   "windsor-on",
+		Adapter:  "canadesk",
+		UsageUSD: 1.0,
+		Listings: []models.ShadowListing{
+			{MLS: "A1", Price: 500000, Beds: 3, PostalCode: "N9A 1A1"},
+			{MLS: "A2", Price: 400000, Beds: 2, PostalCode: "N9A 2B2"},
+			{MLS: "A3", Price: 300000, Beds: 1},
+			{MLS: "A4", Price: 200000, Beds: 1},
+		},
+	}
+	shadow := &models.ShadowResult{
+		Region:   "windsor-on",
+		Adapter:  "scrapemind",
+		UsageUSD: 0.75,
+		Listings: []models.ShadowListing{
+			{MLS: "A1", Price: 500000, Beds: 3, PostalCode: "n9a1a1"},
+			{MLS: "A2", Price: 410000, Beds: 3, PostalCode: "N9A 2B2"},
+			{MLS: "B1", Price: 100000},
+		},
+	}
+
+	c := CompareShadow(primary, shadow)
+	if c.Overlap != 2 {
+		t.Fatalf("expected overlap 2, got %d", c.Overlap)
+	}
+	if len(c.OnlyPrimary) != 2 || c.OnlyPrimary[0] != "A3" || c.OnlyPrimary[1] != "A4" {
+		t.Fatalf("unexpected primary-only %v", c.OnlyPrimary)
+	}
+	if len(c.OnlyShadow) != 1 || c.OnlyShadow[0] != "B1" {
+		t.Fatalf("unexpected shadow-only %v", c.OnlyShadow)
+	}
+
+	counts := c.DisagreementCounts()
+	if counts["price"] != 1 || counts["beds"] != 1 || counts["postal_code"] != 0 {
+		t.Fatalf("unexpected disagreements %v", c.Disagreements)
+	}
+
+	if got := CostPerListing(shadow); got != 0.25 {
+		t.Fatalf("expected $0.25 per listing, got %v", got)
+	}
+}
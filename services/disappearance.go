@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"tct_scrooper/storage"
+)
+
+const (
+	// A full region scrape that would newly mark more than missingMaxRatio of
+	// the region's listings missing is treated as a bad result rather than a
+	// mass disappearance. Regions under missingMinListings are exempt.
+	missingMaxRatio    = 0.3
+	missingMinListings = 20
+)
+
+// MissingResult tallies a disappearance check of one region
+type MissingResult struct {
+	Missing  int  // active listings newly marked missing
+	Delisted int  // listings still missing after their grace period
+	Skipped  bool // too many listings went missing at once to trust the scrape
+}
+
+// DetectMissing compares the listings a full (non-incremental) scrape of a
+// region returned against the listings stored for it. Active listings it
+// did not return are marked missing, which queues them for a priority
+// healthcheck; listings missing for longer than grace that it still did not
+// return are delisted. seen holds the external IDs the scrape returned.
+func (s *ListingService) DetectMissing(ctx context.Context, source, region string, seen []string, grace time.Duration) (*MissingResult, error) {
+	if err := s.store.TagListingsRegion(ctx, source, region, seen); err != nil {
+		return nil, fmt.Errorf("tag region listings: %w", err)
+	}
+
+	unseen, total, err := s.store.GetUnseenRegionListings(ctx, source, region, seen)
+	if err != nil {
+		return nil, fmt.Errorf("get unseen listings: %w", err)
+	}
+
+	now := time.Now()
+	plan := planMissing(unseen, total, now, grace)
+
+	result := &MissingResult{}
+	if plan.skipped {
+		result.Skipped = true
+		return result, nil
+	}
+
+	if len(plan.newlyMissing) > 0 {
+		if err := s.store.MarkListingsMissing(ctx, plan.newlyMissing, now); err != nil {
+			return nil, fmt.Errorf("mark missing: %w", err)
+		}
+		result.Missing = len(plan.newlyMissing)
+	}
+
+	for _, id := range plan.expired {
+		if err := s.MarkDelisted(ctx, id); err != nil {
+			log.Printf("Warning: failed to delist missing listing %s: %v", id, err)
+			continue
+		}
+		result.Delisted++
+	}
+	return result, nil
+}
+
+// missingPlan is what a disappearance check does with a region's unseen
+// listings
+type missingPlan struct {
+	newlyMissing []uuid.UUID // active listings to mark missing
+	expired      []uuid.UUID // missing listings past their grace period, to delist
+	skipped      bool        // too many newly missing to trust the scrape
+}
+
+// planMissing sorts the listings a scrape did not return, out of the
+// region's total active and missing listings
+func planMissing(unseen []storage.RegionListing, total int, now time.Time, grace time.Duration) missingPlan {
+	var plan missingPlan
+	for _, l := range unseen {
+		switch {
+		case l.MissingSince == nil:
+			plan.newlyMissing = append(plan.newlyMissing, l.ID)
+		case now.Sub(*l.MissingSince) >= grace:
+			plan.expired = append(plan.expired, l.ID)
+		}
+	}
+	if total >= missingMinListings && float64(len(plan.newlyMissing)) > missingMaxRatio*float64(total) {
+		return missingPlan{skipped: true}
+	}
+	return plan
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"tct_scrooper/models"
+	"tct_scrooper/storage"
+)
+
+func TestPlanMissing(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	grace := 48 * time.Hour
+	since := func(ago time.Duration) *time.Time {
+		at := now.Add(-ago)
+		return &at
+	}
+	listings := func(n int, missingSince *time.Time) []storage.RegionListing {
+		out := make([]storage.RegionListing, n)
+		for i := range out {
+			out[i] = storage.RegionListing{ID: uuid.New(), MissingSince: missingSince}
+		}
+		return out
+	}
+
+	cases := []struct {
+		name         string
+		unseen       []storage.RegionListing
+		total        int
+		newlyMissing int
+		expired      int
+		skipped      bool
+	}{
+		{"nothing unseen", nil, 100, 0, 0, false},
+		{"a few newly missing", listings(5, nil), 100, 5, 0, false},
+		{"at the ratio", listings(30, nil), 100, 30, 0, false},
+		{"over the ratio", listings(31, nil), 100, 0, 0, true},
+		{"small region exempt from ratio", listings(10, nil), missingMinListings - 1, 10, 0, false},
+		{"smallest checked region", listings(7, nil), missingMinListings, 0, 0, true},
+		{"within grace", listings(3, since(47*time.Hour)), 100, 0, 0, false},
+		{"past grace", listings(3, since(48*time.Hour)), 100, 0, 3, false},
+		{"already missing don't count toward ratio", listings(50, since(time.Hour)), 100, 0, 0, false},
+		{"mixed", append(listings(2, nil), append(listings(1, since(time.Hour)), listings(4, since(72*time.Hour))...)...), 100, 2, 4, false},
+	}
+	for _, c := range cases {
+		plan := planMissing(c.unseen, c.total, now, grace)
+		if plan.skipped != c.skipped || len(plan.newlyMissing) != c.newlyMissing || len(plan.expired) != c.expired {
+			t.Errorf("%s: expected skipped=%v missing=%d expired=%d, got skipped=%v missing=%d expired=%d",
+				c.name, c.skipped, c.newlyMissing, c.expired, plan.skipped, len(plan.newlyMissing), len(plan.expired))
+		}
+	}
+}
+
+func TestDelistedEvent(t *testing.T) {
+	price := 450000.0
+	listing := &models.Listing{ID: uuid.New(), PropertyID: uuid.New(), Price: &price}
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	event := delistedEvent(listing, at)
+	if event.PropertyID != listing.PropertyID || event.EventType != models.EventTypeDelisted {
+		t.Fatalf("expected a delisted event for the property, got %+v", event)
+	}
+	if !event.EventDate.Equal(at) || event.Price == nil || *event.Price != price {
+		t.Fatalf("expected the event at %v with the last price, got %+v", at, event)
+	}
+}
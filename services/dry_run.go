@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"tct_scrooper/identity"
+	"tct_scrooper/models"
+	"tct_scrooper/storage"
+)
+
+// ListingPlan is what ProcessListing would do with a listing
+type ListingPlan struct {
+	Source        string           `json:"source"`
+	Region        string           `json:"region,omitempty"`
+	MLS           string           `json:"mls"`
+	Address       string           `json:"address,omitempty"`
+	City          string           `json:"city,omitempty"`
+	NewProperty   bool             `json:"new_property,omitempty"`
+	NewListing    bool             `json:"new_listing,omitempty"`
+	Relisted      bool             `json:"relisted,omitempty"`
+	RelistedFrom  string           `json:"relisted_from,omitempty"` // MLS of the active listing it replaces
+	PriceChanged  bool             `json:"price_changed,omitempty"`
+	PreviousPrice *float64         `json:"previous_price,omitempty"`
+	Price         *float64         `json:"price,omitempty"`
+	Matches       []MatchCandidate `json:"match_candidates,omitempty"`
+	MediaToQueue  int              `json:"media_to_queue,omitempty"`
+}
+
+// Changed reports whether ingesting the listing would change anything beyond
+// refreshing it
+func (p *ListingPlan) Changed() bool {
+	return p.NewProperty || p.NewListing || p.Relisted || p.PriceChanged || len(p.Matches) > 0 || p.MediaToQueue > 0
+}
+
+// IngestPlanner computes ListingPlans without writing anything. It remembers
+// what earlier listings of the same dry run would have created, so a listing
+// returned twice (e.g. by overlapping regions) is only counted once.
+type IngestPlanner struct {
+	store *storage.PostgresStore
+	match *MatchService
+
+	properties map[string]string // fingerprint -> MLS of its planned active listing
+	listings   map[string]bool   // source/MLS of planned listings
+	media      map[string]bool   // photo URLs planned for queueing
+}
+
+// NewIngestPlanner creates an IngestPlanner; match may be nil
+func NewIngestPlanner(store *storage.PostgresStore, match *MatchService) *IngestPlanner {
+	return &IngestPlanner{
+		store:      store,
+		match:      match,
+		properties: make(map[string]string),
+		listings:   make(map[string]bool),
+		media:      make(map[string]bool),
+	}
+}
+
+// Plan mirrors ProcessListing's decisions for a raw listing using reads only.
+// The second return is false for a listing already planned in this dry run.
+func (p *IngestPlanner) Plan(ctx context.Context, raw *models.RawListing, source, region string) (*ListingPlan, bool, error) {
+	listingKey := source + "/" + raw.MLS
+	if p.listings[listingKey] {
+		return nil, false, nil
+	}
+	p.listings[listingKey] = true
+
+	plan := &ListingPlan{
+		Source:  source,
+		Region:  region,
+		MLS:     raw.MLS,
+		Address: raw.Address,
+		City:    raw.City,
+		Price:   float64Ptr(float64(raw.Price)),
+	}
+
+	// 1. Property
+	fingerprint := identity.Fingerprint(raw)
+	existingProp, err := p.store.GetPropertyByFingerprint(ctx, fingerprint)
+	if err != nil {
+		return nil, false, fmt.Errorf("get property: %w", err)
+	}
+	plannedActive, plannedProp := p.properties[fingerprint]
+	if existingProp == nil && !plannedProp {
+		plan.NewProperty = true
+		if p.match != nil {
+			incoming := &models.DomainProperty{
+				Fingerprint:  fingerprint,
+				City:         raw.City,
+				PostalCode:   raw.PostalCode,
+				AddressFull:  raw.Address,
+				PropertyType: raw.PropertyType,
+				Beds:         intPtr(raw.Beds),
+				Baths:        intPtr(raw.Baths),
+				SqFt:         intPtr(raw.SqFt),
+			}
+			if plan.Matches, err = p.match.FindPotentialMatches(ctx, incoming); err != nil {
+				return nil, false, fmt.Errorf("find matches: %w", err)
+			}
+		}
+	}
+
+	// 2. Listing
+	existingListing, err := p.store.GetListingBySourceAndExternalID(ctx, source, raw.MLS)
+	if err != nil {
+		return nil, false, fmt.Errorf("get listing: %w", err)
+	}
+	if existingListing == nil {
+		plan.NewListing = true
+		switch {
+		case plannedProp:
+			if plannedActive != raw.MLS {
+				plan.Relisted = true
+				plan.RelistedFrom = plannedActive
+			}
+		case existingProp != nil:
+			prev, _ := p.store.GetActiveListingForProperty(ctx, existingProp.ID)
+			if prev != nil && prev.ExternalID != raw.MLS {
+				plan.Relisted = true
+				plan.RelistedFrom = prev.ExternalID
+			}
+		}
+	} else if existingListing.Price != nil && plan.Price != nil && *existingListing.Price != *plan.Price {
+		plan.PriceChanged = true
+		plan.PreviousPrice = existingListing.Price
+	}
+	p.properties[fingerprint] = raw.MLS
+
+	// 3. Media (listing photos not already known)
+	for _, url := range raw.Photos {
+		if p.media[url] {
+			continue
+		}
+		existing, err := p.store.GetMediaByOriginalURL(ctx, url)
+		if err != nil {
+			return nil, false, fmt.Errorf("get media: %w", err)
+		}
+		if existing == nil {
+			p.media[url] = true
+			plan.MediaToQueue++
+		}
+	}
+
+	return plan, true, nil
+}
+
+// DryRunRegion is the outcome of scraping one region in a dry run
+type DryRunRegion struct {
+	Site          string  `json:"site"`
+	Region        string  `json:"region"`
+	Fetched       int     `json:"fetched"`
+	Expected      int     `json:"expected,omitempty"` // total the source reported for the region
+	Quarantined   int     `json:"quarantined,omitempty"`
+	Duplicates    int     `json:"duplicates,omitempty"`
+	ParseFailures int     `json:"parse_failures,omitempty"`
+	Errors        int     `json:"errors,omitempty"`
+	ApifyUsageUSD float64 `json:"apify_usage_usd,omitempty"`
+	Error         string  `json:"error,omitempty"` // the region could not be scraped
+}
+
+// DryRunTotals sums what a dry run would have written
+type DryRunTotals struct {
+	Fetched         int     `json:"fetched"`
+	NewProperties   int     `json:"new_properties"`
+	NewListings     int     `json:"new_listings"`
+	Relisted        int     `json:"relisted"`
+	PriceChanges    int     `json:"price_changes"`
+	MatchCandidates int     `json:"match_candidates"`
+	MediaToQueue    int     `json:"media_to_queue"`
+	Unchanged       int     `json:"unchanged"`
+	Duplicates      int     `json:"duplicates"`
+	Quarantined     int     `json:"quarantined"`
+	Errors          int     `json:"errors"`
+	FailedRegions   int     `json:"failed_regions"`
+	ApifyUsageUSD   float64 `json:"apify_usage_usd"`
+}
+
+// DryRunReport is the ingestion diff of a dry-run scrape. Listings only holds
+// the listings that would change something.
+type DryRunReport struct {
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt time.Time      `json:"finished_at"`
+	Totals     DryRunTotals   `json:"totals"`
+	Regions    []DryRunRegion `json:"regions"`
+	Listings   []ListingPlan  `json:"listings"`
+}
+
+// AddRegion folds a scraped region into the totals
+func (r *DryRunReport) AddRegion(region DryRunRegion) {
+	r.Regions = append(r.Regions, region)
+	r.Totals.Fetched += region.Fetched
+	r.Totals.Quarantined += region.Quarantined
+	r.Totals.Duplicates += region.Duplicates
+	r.Totals.Errors += region.Errors
+	r.Totals.ApifyUsageUSD += region.ApifyUsageUSD
+	if region.Error != "" {
+		r.Totals.FailedRegions++
+	}
+}
+
+// AddPlan records a planned listing
+func (r *DryRunReport) AddPlan(plan *ListingPlan) {
+	if !plan.Changed() {
+		r.Totals.Unchanged++
+		return
+	}
+	if plan.NewProperty {
+		r.Totals.NewProperties++
+	}
+	if plan.NewListing {
+		r.Totals.NewListings++
+	}
+	if plan.Relisted {
+		r.Totals.Relisted++
+	}
+	if plan.PriceChanged {
+		r.Totals.PriceChanges++
+	}
+	r.Totals.MatchCandidates += len(plan.Matches)
+	r.Totals.MediaToQueue += plan.MediaToQueue
+	r.Listings = append(r.Listings, *plan)
+}
@@ -435,20 +435,25 @@ func (s *ListingService) MarkDelisted(ctx context.Context, listingID uuid.UUID)
 	}
 
 	// Create delisted event
-	event := &models.PropertyEvent{
+	if err := s.store.CreatePropertyEvent(ctx, delistedEvent(listing, now)); err != nil {
+		log.Printf("Warning: failed to create delisted event: %v", err)
+	}
+
+	return nil
+}
+
+// delistedEvent is the property event recording that listing came off the
+// market at the given time, at its last price
+func delistedEvent(listing *models.Listing, at time.Time) *models.PropertyEvent {
+	return &models.PropertyEvent{
 		PropertyID: listing.PropertyID,
 		EventType:  models.EventTypeDelisted,
-		EventDate:  now,
+		EventDate:  at,
 		Price:      listing.Price,
 		SourceType: "listing",
 		Source:     "scraper",
-		CreatedAt:  now,
+		CreatedAt:  at,
 	}
-	if err := s.store.CreatePropertyEvent(ctx, event); err != nil {
-		log.Printf("Warning: failed to create delisted event: %v", err)
-	}
-
-	return nil
 }
 
 // Helper functions
@@ -474,6 +479,64 @@ type ProcessStats struct {
 	Relisted          int
 	PriceChanges      int
 	Errors            int
+	ParseFailures     int // source items the handler could not parse
+	Quarantined       int // listings held back by validation rules
+	Missing           int // active listings a full region scrape no longer returned
+	MissingDelisted   int // missing listings delisted after their grace period
+
+	ApifyUsageUSD     float64 // Apify platform usage of the run's actor runs
+	ApifyComputeUnits float64
+	Adapters          map[string]string   // region -> Apify adapter that produced its data
+	SchemaDrift       map[string][]string // region -> required fields that drifted
+	LowCoverage       map[string]int      // region -> ingested % of the source's total, when below the minimum
+}
+
+// RestoreProcessStats rebuilds the stats checkpointed on an unfinished run,
+// so a resumed run reports totals across both daemons
+func RestoreProcessStats(run *models.DomainScrapeRun) *ProcessStats {
+	s := &ProcessStats{}
+	if len(run.Metadata) == 0 {
+		return s
+	}
+
+	var meta struct {
+		ListingsProcessed int                 `json:"listings_processed"`
+		PropertiesNew     int                 `json:"properties_new"`
+		ListingsNew       int                 `json:"listings_new"`
+		Relisted          int                 `json:"relisted"`
+		PriceChanges      int                 `json:"price_changes"`
+		Errors            int                 `json:"errors"`
+		ParseFailures     int                 `json:"parse_failures"`
+		Quarantined       int                 `json:"quarantined"`
+		Missing           int                 `json:"missing"`
+		MissingDelisted   int                 `json:"missing_delisted"`
+		ApifyUsageUSD     float64             `json:"apify_usage_usd"`
+		ApifyComputeUnits float64             `json:"apify_compute_units"`
+		Adapters          map[string]string   `json:"adapters"`
+		SchemaDrift       map[string][]string `json:"schema_drift"`
+		LowCoverage       map[string]int      `json:"low_coverage"`
+	}
+	if err := json.Unmarshal(run.Metadata, &meta); err != nil {
+		log.Printf("Warning: failed to restore stats of run %d: %v", run.ID, err)
+		return s
+	}
+
+	s.ListingsProcessed = meta.ListingsProcessed
+	s.PropertiesNew = meta.PropertiesNew
+	s.ListingsNew = meta.ListingsNew
+	s.Relisted = meta.Relisted
+	s.PriceChanges = meta.PriceChanges
+	s.Errors = meta.Errors
+	s.ParseFailures = meta.ParseFailures
+	s.Quarantined = meta.Quarantined
+	s.Missing = meta.Missing
+	s.MissingDelisted = meta.MissingDelisted
+	s.ApifyUsageUSD = meta.ApifyUsageUSD
+	s.ApifyComputeUnits = meta.ApifyComputeUnits
+	s.Adapters = meta.Adapters
+	s.SchemaDrift = meta.SchemaDrift
+	s.LowCoverage = meta.LowCoverage
+	return s
 }
 
 // Aggregate adds a ProcessResult to the stats
@@ -495,13 +558,31 @@ func (s *ProcessStats) Aggregate(r *ProcessResult) {
 
 // ToJSON returns JSON-serializable metadata
 func (s *ProcessStats) ToJSON() json.RawMessage {
-	data, _ := json.Marshal(map[string]int{
+	meta := map[string]interface{}{
 		"listings_processed": s.ListingsProcessed,
 		"properties_new":     s.PropertiesNew,
 		"listings_new":       s.ListingsNew,
 		"relisted":           s.Relisted,
 		"price_changes":      s.PriceChanges,
 		"errors":             s.Errors,
-	})
+		"parse_failures":     s.ParseFailures,
+		"quarantined":        s.Quarantined,
+		"missing":            s.Missing,
+		"missing_delisted":   s.MissingDelisted,
+	}
+	if s.ApifyUsageUSD > 0 || s.ApifyComputeUnits > 0 {
+		meta["apify_usage_usd"] = s.ApifyUsageUSD
+		meta["apify_compute_units"] = s.ApifyComputeUnits
+	}
+	if len(s.Adapters) > 0 {
+		meta["adapters"] = s.Adapters
+	}
+	if len(s.SchemaDrift) > 0 {
+		meta["schema_drift"] = s.SchemaDrift
+	}
+	if len(s.LowCoverage) > 0 {
+		meta["low_coverage"] = s.LowCoverage
+	}
+	data, _ := json.Marshal(meta)
 	return data
 }
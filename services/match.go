@@ -35,16 +35,54 @@ type propertyMatchCandidate struct {
 	PropertyType string
 }
 
+// MatchCandidate is an existing property an incoming one may duplicate
+type MatchCandidate struct {
+	PropertyID uuid.UUID `json:"property_id"`
+	Address    string    `json:"address"`
+	Confidence float64   `json:"confidence"`
+	Reasons    []string  `json:"reasons"`
+}
+
 // InsertPotentialMatches finds and inserts potential duplicate properties
 func (s *MatchService) InsertPotentialMatches(ctx context.Context, incoming *models.DomainProperty) (int, error) {
+	candidates, err := s.FindPotentialMatches(ctx, incoming)
+	if err != nil {
+		return 0, err
+	}
+
+	inserted := 0
+	now := time.Now()
+	for _, c := range candidates {
+		reasonsJSON, _ := json.Marshal(c.Reasons)
+		match := &models.PropertyMatch{
+			MatchedID:    c.PropertyID,
+			IncomingID:   incoming.ID,
+			Confidence:   float32(c.Confidence),
+			MatchReasons: reasonsJSON,
+			Status:       "pending",
+			CreatedAt:    now,
+		}
+
+		if err := s.store.InsertPropertyMatch(ctx, match); err != nil {
+			return inserted, err
+		}
+		inserted++
+	}
+
+	return inserted, nil
+}
+
+// FindPotentialMatches returns the existing properties that may be
+// duplicates of incoming, without recording them
+func (s *MatchService) FindPotentialMatches(ctx context.Context, incoming *models.DomainProperty) ([]MatchCandidate, error) {
 	if incoming == nil || incoming.AddressFull == "" {
-		return 0, nil
+		return nil, nil
 	}
 
 	normalized := strings.TrimSpace(strings.ToLower(incoming.AddressFull))
 	prefix := addressPrefix(normalized, 2)
 	if incoming.PostalCode == "" && prefix == "" {
-		return 0, nil
+		return nil, nil
 	}
 
 	// Build query to find potential matches
@@ -73,13 +111,12 @@ func (s *MatchService) InsertPotentialMatches(ctx context.Context, incoming *mod
 
 	rows, err := s.store.Pool().Query(ctx, query, args...)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 	defer rows.Close()
 
 	baseIncoming := baseAddress(normalized)
-	inserted := 0
-	now := time.Now()
+	var matches []MatchCandidate
 
 	for rows.Next() {
 		var candidate propertyMatchCandidate
@@ -88,31 +125,22 @@ func (s *MatchService) InsertPotentialMatches(ctx context.Context, incoming *mod
 			&candidate.City, &candidate.PostalCode, &candidate.Beds,
 			&candidate.Baths, &candidate.SqFt, &candidate.PropertyType,
 		); err != nil {
-			return inserted, err
+			return nil, err
 		}
 
 		confidence, reasons, ok := scorePotentialMatch(incoming, &candidate, baseIncoming)
 		if !ok {
 			continue
 		}
-
-		reasonsJSON, _ := json.Marshal(reasons)
-		match := &models.PropertyMatch{
-			MatchedID:    candidate.ID,
-			IncomingID:   incoming.ID,
-			Confidence:   float32(confidence),
-			MatchReasons: reasonsJSON,
-			Status:       "pending",
-			CreatedAt:    now,
-		}
-
-		if err := s.store.InsertPropertyMatch(ctx, match); err != nil {
-			return inserted, err
-		}
-		inserted++
+		matches = append(matches, MatchCandidate{
+			PropertyID: candidate.ID,
+			Address:    candidate.AddressFull,
+			Confidence: confidence,
+			Reasons:    reasons,
+		})
 	}
 
-	return inserted, rows.Err()
+	return matches, rows.Err()
 }
 
 // scorePotentialMatch calculates a confidence score for a potential match
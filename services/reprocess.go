@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"tct_scrooper/models"
+	"tct_scrooper/storage"
+)
+
+// FieldChange is one field a reprocessed payload fills in
+type FieldChange struct {
+	Table string // properties or listings
+	Field string
+	Value string
+}
+
+// ReprocessSummary tallies a reprocess pass over stored raw_data
+type ReprocessSummary struct {
+	Scanned     int
+	Unparsed    int // no adapter could parse the payload into this listing
+	Unchanged   int
+	Changed     int
+	Errors      int
+	FieldCounts map[string]int // table.field -> listings it was filled for
+}
+
+// Add records the changes derived for one listing
+func (s *ReprocessSummary) Add(changes []FieldChange) {
+	if len(changes) == 0 {
+		s.Unchanged++
+		return
+	}
+	s.Changed++
+	if s.FieldCounts == nil {
+		s.FieldCounts = make(map[string]int)
+	}
+	for _, c := range changes {
+		s.FieldCounts[c.Table+"."+c.Field]++
+	}
+}
+
+// ReprocessService re-derives listing fields from stored raw_data and
+// backfills the ones that are still empty
+type ReprocessService struct {
+	store *storage.PostgresStore
+}
+
+// NewReprocessService creates a new ReprocessService
+func NewReprocessService(store *storage.PostgresStore) *ReprocessService {
+	return &ReprocessService{store: store}
+}
+
+// NextBatch returns the listings after the given ID that match the filter
+func (s *ReprocessService) NextBatch(ctx context.Context, f storage.ReprocessFilter, after uuid.UUID, limit int) ([]models.Listing, error) {
+	return s.store.ListListingsForReprocess(ctx, f, after, limit)
+}
+
+// ApplyDerivedFields fills the listing's and its property's empty fields
+// from a freshly parsed listing. Fields that already have a value, and
+// scrape-time state like price, status and last_seen, are left alone.
+// With dryRun the changes are only reported.
+func (s *ReprocessService) ApplyDerivedFields(ctx context.Context, listing *models.Listing, raw *models.RawListing, dryRun bool) ([]FieldChange, error) {
+	property, err := s.store.GetPropertyByID(ctx, listing.PropertyID)
+	if err != nil {
+		return nil, fmt.Errorf("get property: %w", err)
+	}
+	if property == nil {
+		return nil, fmt.Errorf("property not found: %s", listing.PropertyID)
+	}
+
+	var propChanges, listingChanges []FieldChange
+	fillString := func(changes *[]FieldChange, table, field string, dst *string, v string) {
+		if *dst == "" && v != "" {
+			*dst = v
+			*changes = append(*changes, FieldChange{Table: table, Field: field, Value: v})
+		}
+	}
+	fillInt := func(changes *[]FieldChange, table, field string, dst **int, v int) {
+		if *dst == nil && v > 0 {
+			*dst = intPtr(v)
+			*changes = append(*changes, FieldChange{Table: table, Field: field, Value: strconv.Itoa(v)})
+		}
+	}
+
+	fillString(&propChanges, "properties", "province", &property.Province, raw.Province)
+	fillString(&propChanges, "properties", "city", &property.City, raw.City)
+	fillString(&propChanges, "properties", "postal_code", &property.PostalCode, raw.PostalCode)
+	fillString(&propChanges, "properties", "address_full", &property.AddressFull, raw.Address)
+	fillString(&propChanges, "properties", "property_type", &property.PropertyType, raw.PropertyType)
+	fillInt(&propChanges, "properties", "beds", &property.Beds, raw.Beds)
+	fillInt(&propChanges, "properties", "baths", &property.Baths, raw.Baths)
+	fillInt(&propChanges, "properties", "sqft", &property.SqFt, raw.SqFt)
+
+	fillString(&listingChanges, "listings", "url", &listing.URL, raw.URL)
+	fillString(&listingChanges, "listings", "property_type", &listing.PropertyType, raw.PropertyType)
+	fillString(&listingChanges, "listings", "description", &listing.Description, raw.Description)
+	fillInt(&listingChanges, "listings", "beds", &listing.Beds, raw.Beds)
+	fillInt(&listingChanges, "listings", "baths", &listing.Baths, raw.Baths)
+	fillInt(&listingChanges, "listings", "sqft", &listing.SqFt, raw.SqFt)
+
+	changes := append(propChanges, listingChanges...)
+	if dryRun || len(changes) == 0 {
+		return changes, nil
+	}
+
+	now := time.Now()
+	if len(propChanges) > 0 {
+		property.UpdatedAt = now
+		if err := s.store.UpsertProperty(ctx, property); err != nil {
+			return nil, fmt.Errorf("update property: %w", err)
+		}
+	}
+	if len(listingChanges) > 0 {
+		listing.UpdatedAt = now
+		if err := s.store.UpsertListing(ctx, listing); err != nil {
+			return nil, fmt.Errorf("update listing: %w", err)
+		}
+	}
+	return changes, nil
+}
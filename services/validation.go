@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"tct_scrooper/config"
+	"tct_scrooper/models"
+	"tct_scrooper/storage"
+)
+
+// postalProvinces maps the first letter of a Canadian postal code to the
+// provinces it is used in
+var postalProvinces = map[byte][]string{
+	'A': {"NL"},
+	'B': {"NS"},
+	'C': {"PE"},
+	'E': {"NB"},
+	'G': {"QC"}, 'H': {"QC"}, 'J': {"QC"},
+	'K': {"ON"}, 'L': {"ON"}, 'M': {"ON"}, 'N': {"ON"}, 'P': {"ON"},
+	'R': {"MB"},
+	'S': {"SK"},
+	'T': {"AB"},
+	'V': {"BC"},
+	'X': {"NT", "NU"},
+	'Y': {"YT"},
+}
+
+// ValidateListing returns the reasons a listing breaks the rules, or nil if
+// it passes. A nil rules set accepts everything.
+func ValidateListing(raw *models.RawListing, rules *config.ValidationRules) []string {
+	if rules == nil {
+		return nil
+	}
+
+	var reasons []string
+	for _, field := range rules.Require {
+		if listingFieldEmpty(raw, field) {
+			reasons = append(reasons, "missing "+field)
+		}
+	}
+
+	if raw.Price > 0 || !containsField(rules.Require, "price") {
+		if rules.MinPrice > 0 && raw.Price < rules.MinPrice {
+			reasons = append(reasons, fmt.Sprintf("price %d below min_price %d", raw.Price, rules.MinPrice))
+		}
+		if rules.MaxPrice > 0 && raw.Price > rules.MaxPrice {
+			reasons = append(reasons, fmt.Sprintf("price %d above max_price %d", raw.Price, rules.MaxPrice))
+		}
+	}
+
+	if rules.MinBeds > 0 && raw.Beds < rules.MinBeds && !containsFold(rules.BedsExemptTypes, raw.PropertyType) {
+		reasons = append(reasons, fmt.Sprintf("beds %d below min_beds %d (type %q)", raw.Beds, rules.MinBeds, raw.PropertyType))
+	}
+
+	postal := strings.ToUpper(strings.TrimSpace(raw.PostalCode))
+	if rules.PostalMatchesProvince && postal != "" && raw.Province != "" {
+		if provinces, ok := postalProvinces[postal[0]]; !ok {
+			reasons = append(reasons, fmt.Sprintf("postal code %s is not Canadian", raw.PostalCode))
+		} else if !containsFold(provinces, raw.Province) {
+			reasons = append(reasons, fmt.Sprintf("postal code %s is not in province %s", raw.PostalCode, raw.Province))
+		}
+	}
+
+	return reasons
+}
+
+func listingFieldEmpty(raw *models.RawListing, field string) bool {
+	switch field {
+	case "mls":
+		return strings.TrimSpace(raw.MLS) == ""
+	case "address":
+		return strings.TrimSpace(raw.Address) == ""
+	case "city":
+		return strings.TrimSpace(raw.City) == ""
+	case "province":
+		return strings.TrimSpace(raw.Province) == ""
+	case "postal_code":
+		return strings.TrimSpace(raw.PostalCode) == ""
+	case "price":
+		return raw.Price <= 0
+	case "beds":
+		return raw.Beds <= 0
+	}
+	return false
+}
+
+func containsField(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyListingFixes sets RawListing fields by their JSON names, e.g.
+// {"price": "450000", "postal_code": "N9A 1A1"}. Numeric fields are parsed.
+func ApplyListingFixes(raw *models.RawListing, fixes map[string]string) error {
+	data := raw.Data
+	raw.Data = nil
+	defer func() { raw.Data = data }()
+
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return err
+	}
+
+	for name, value := range fixes {
+		current, ok := fields[name]
+		if !ok || name == "data" {
+			return fmt.Errorf("unknown listing field %q", name)
+		}
+		switch current.(type) {
+		case string:
+			fields[name] = value
+		case float64:
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("%s: %q is not a number", name, value)
+			}
+			fields[name] = n
+		default:
+			return fmt.Errorf("field %q can't be set from the command line", name)
+		}
+	}
+
+	encoded, err = json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	var fixed models.RawListing
+	if err := json.Unmarshal(encoded, &fixed); err != nil {
+		return err
+	}
+	*raw = fixed
+	return nil
+}
+
+// QuarantineService holds listings that fail validation until they are
+// fixed and released into the normal pipeline, or discarded
+type QuarantineService struct {
+	store   *storage.PostgresStore
+	listing *ListingService
+}
+
+// NewQuarantineService creates a new QuarantineService
+func NewQuarantineService(store *storage.PostgresStore, listing *ListingService) *QuarantineService {
+	return &QuarantineService{
+		store:   store,
+		listing: listing,
+	}
+}
+
+// Quarantine stores a listing with the reasons it failed validation
+func (s *QuarantineService) Quarantine(ctx context.Context, raw *models.RawListing, source, region string, runID *int64, reasons []string) error {
+	key := raw.MLS
+	if key == "" {
+		key = raw.ID
+	}
+	if key == "" {
+		key = raw.Address
+	}
+
+	return s.store.QuarantineListing(ctx, &models.QuarantinedListing{
+		RunID:      runID,
+		Source:     source,
+		Region:     region,
+		ListingKey: key,
+		Reasons:    reasons,
+		Listing:    *raw,
+	})
+}
+
+// List returns pending quarantined listings, optionally for one source
+func (s *QuarantineService) List(ctx context.Context, source string, limit int) ([]models.QuarantinedListing, error) {
+	return s.store.ListQuarantinedListings(ctx, source, "pending", limit)
+}
+
+// Get returns a quarantined listing by ID
+func (s *QuarantineService) Get(ctx context.Context, id int64) (*models.QuarantinedListing, error) {
+	q, err := s.store.GetQuarantinedListing(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if q == nil {
+		return nil, fmt.Errorf("no quarantined listing %d", id)
+	}
+	return q, nil
+}
+
+// Release applies fixes, re-validates against rules and, if the listing now
+// passes (or force is set), processes it like a freshly scraped listing
+func (s *QuarantineService) Release(ctx context.Context, id int64, fixes map[string]string, rules *config.ValidationRules, force bool) (*ProcessResult, error) {
+	q, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if q.Status != "pending" {
+		return nil, fmt.Errorf("quarantined listing %d is already %s", id, q.Status)
+	}
+
+	if err := ApplyListingFixes(&q.Listing, fixes); err != nil {
+		return nil, err
+	}
+	if reasons := ValidateListing(&q.Listing, rules); len(reasons) > 0 && !force {
+		return nil, fmt.Errorf("still invalid: %s", strings.Join(reasons, "; "))
+	}
+
+	result, err := s.listing.ProcessListing(ctx, &q.Listing, q.Source, q.RunID)
+	if err != nil {
+		return nil, fmt.Errorf("process: %w", err)
+	}
+	if err := s.store.ResolveQuarantinedListing(ctx, q, "released"); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Discard drops a quarantined listing without processing it
+func (s *QuarantineService) Discard(ctx context.Context, id int64) error {
+	q, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.store.ResolveQuarantinedListing(ctx, q, "discarded")
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"tct_scrooper/config"
+	"tct_scrooper/models"
+)
+
+func TestValidateListing(t *testing.T) {
+	rules := &config.ValidationRules{
+		Require:               []string{"mls", "price"},
+		MinPrice:              10000,
+		MaxPrice:              50000000,
+		MinBeds:               1,
+		BedsExemptTypes:       []string{"Vacant Land"},
+		PostalMatchesProvince: true,
+	}
+	valid := models.RawListing{MLS: "X1", Price: 450000, Beds: 3, Province: "ON", PostalCode: "N9A 1A1"}
+
+	cases := []struct {
+		name  string
+		edit  func(*models.RawListing)
+		rules *config.ValidationRules
+		want  []string // substrings of the expected reasons, in order
+	}{
+		{"valid", func(*models.RawListing) {}, rules, nil},
+		{"nil rules", func(l *models.RawListing) { *l = models.RawListing{} }, nil, nil},
+		{"missing mls", func(l *models.RawListing) { l.MLS = " " }, rules, []string{"missing mls"}},
+		{"missing price skips range", func(l *models.RawListing) { l.Price = 0 }, rules, []string{"missing price"}},
+		{"below min price", func(l *models.RawListing) { l.Price = 500 }, rules, []string{"below min_price"}},
+		{"above max price", func(l *models.RawListing) { l.Price = 60000000 }, rules, []string{"above max_price"}},
+		{"no beds", func(l *models.RawListing) { l.Beds = 0 }, rules, []string{"below min_beds"}},
+		{"land exempt from beds", func(l *models.RawListing) { l.Beds, l.PropertyType = 0, "vacant land" }, rules, nil},
+		{"postal in other province", func(l *models.RawListing) { l.PostalCode = "V6B 1A1" }, rules, []string{"not in province ON"}},
+		{"foreign postal", func(l *models.RawListing) { l.PostalCode = "90210" }, rules, []string{"not Canadian"}},
+		{"territories share a letter", func(l *models.RawListing) { l.PostalCode, l.Province = "X0A 0H0", "NU" }, rules, nil},
+		{"several reasons", func(l *models.RawListing) { l.MLS, l.Beds = "", 0 }, rules, []string{"missing mls", "below min_beds"}},
+	}
+	for _, c := range cases {
+		listing := valid
+		c.edit(&listing)
+		got := ValidateListing(&listing, c.rules)
+		if len(got) != len(c.want) {
+			t.Errorf("%s: expected %d reasons %v, got %v", c.name, len(c.want), c.want, got)
+			continue
+		}
+		for i, want := range c.want {
+			if !strings.Contains(got[i], want) {
+				t.Errorf("%s: expected reason %q, got %q", c.name, want, got[i])
+			}
+		}
+	}
+}
+
+func TestApplyListingFixes(t *testing.T) {
+	cases := []struct {
+		name    string
+		fixes   map[string]string
+		wantErr string
+		check   func(*models.RawListing) bool
+	}{
+		{"string and number", map[string]string{"price": "450000", "postal_code": "N9A 1A1"}, "",
+			func(l *models.RawListing) bool { return l.Price == 450000 && l.PostalCode == "N9A 1A1" }},
+		{"not a number", map[string]string{"beds": "three"}, "not a number", nil},
+		{"unknown field", map[string]string{"colour": "red"}, "unknown listing field", nil},
+		{"raw data", map[string]string{"data": "{}"}, "unknown listing field", nil},
+		{"nested field", map[string]string{"realtor": "x"}, "can't be set", nil},
+	}
+	for _, c := range cases {
+		listing := models.RawListing{MLS: "X1", Price: 1, Beds: 2, Data: []byte(`{"k":1}`)}
+		err := ApplyListingFixes(&listing, c.fixes)
+		if c.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+				t.Errorf("%s: expected error %q, got %v", c.name, c.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if !c.check(&listing) {
+			t.Errorf("%s: fixes not applied: %+v", c.name, listing)
+		}
+		if listing.MLS != "X1" || listing.Beds != 2 || string(listing.Data) != `{"k":1}` {
+			t.Errorf("%s: other fields changed: %+v", c.name, listing)
+		}
+	}
+}
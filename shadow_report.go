@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"tct_scrooper/models"
+	"tct_scrooper/scraper"
+	"tct_scrooper/storage"
+)
+
+// maxReportedDisagreements caps the per-region disagreement listing
+const maxReportedDisagreements = 20
+
+// runShadowReport prints the primary vs shadow adapter comparison for a run,
+// defaulting to the latest run of site that has shadow results.
+func runShadowReport(ctx context.Context, pgStore *storage.PostgresStore, site string, runID int64) error {
+	if runID == 0 {
+		if site == "" {
+			return fmt.Errorf("-site or -run is required")
+		}
+		latest, err := pgStore.GetLatestShadowRunID(ctx, site)
+		if err != nil {
+			return err
+		}
+		if latest == 0 {
+			return fmt.Errorf("no shadow results for site %s (set apify_shadow_actor)", site)
+		}
+		runID = latest
+	}
+
+	results, err := pgStore.GetShadowResults(ctx, runID)
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		return fmt.Errorf("run %d has no shadow results", runID)
+	}
+
+	byRegion := make(map[string]map[string]*models.ShadowResult)
+	var regions []string
+	for i := range results {
+		r := &results[i]
+		if byRegion[r.Region] == nil {
+			byRegion[r.Region] = make(map[string]*models.ShadowResult)
+			regions = append(regions, r.Region)
+		}
+		byRegion[r.Region][r.Role] = r
+	}
+	sort.Strings(regions)
+
+	fmt.Printf("Shadow comparison for run %d\n", runID)
+
+	totals := map[string]*models.ShadowResult{"primary": {}, "shadow": {}}
+	totalOverlap := 0
+	totalDisagreements := make(map[string]int)
+
+	for _, region := range regions {
+		primary, shadow := byRegion[region]["primary"], byRegion[region]["shadow"]
+		fmt.Printf("\n== %s ==\n", region)
+		if primary == nil || shadow == nil {
+			for role, r := range byRegion[region] {
+				fmt.Printf("   only %s result (%s, %d listings); the other adapter failed\n", role, r.Adapter, r.ListingsCount)
+			}
+			continue
+		}
+
+		c := scraper.CompareShadow(primary, shadow)
+		printShadowSide("primary", primary)
+		printShadowSide("shadow", shadow)
+		fmt.Printf("   overlap:          %d MLS\n", c.Overlap)
+		fmt.Printf("   only primary:     %d %s\n", len(c.OnlyPrimary), mlsSample(c.OnlyPrimary))
+		fmt.Printf("   only shadow:      %d %s\n", len(c.OnlyShadow), mlsSample(c.OnlyShadow))
+
+		counts := c.DisagreementCounts()
+		fmt.Printf("   disagreements:    price %d, beds %d, postal_code %d\n",
+			counts["price"], counts["beds"], counts["postal_code"])
+		for i, d := range c.Disagreements {
+			if i == maxReportedDisagreements {
+				fmt.Printf("     ... %d more\n", len(c.Disagreements)-i)
+				break
+			}
+			fmt.Printf("     %s %-11s %q vs %q\n", d.MLS, d.Field, d.Primary, d.Shadow)
+		}
+
+		totals["primary"].Adapter = primary.Adapter
+		totals["primary"].UsageUSD += primary.UsageUSD
+		totals["primary"].Listings = append(totals["primary"].Listings, primary.Listings...)
+		totals["shadow"].Adapter = shadow.Adapter
+		totals["shadow"].UsageUSD += shadow.UsageUSD
+		totals["shadow"].Listings = append(totals["shadow"].Listings, shadow.Listings...)
+		totalOverlap += c.Overlap
+		for field, n := range counts {
+			totalDisagreements[field] += n
+		}
+	}
+
+	fmt.Printf("\n== total ==\n")
+	printShadowSide("primary", totals["primary"])
+	printShadowSide("shadow", totals["shadow"])
+	fmt.Printf("   overlap:          %d MLS\n", totalOverlap)
+	fmt.Printf("   disagreements:    price %d, beds %d, postal_code %d\n",
+		totalDisagreements["price"], totalDisagreements["beds"], totalDisagreements["postal_code"])
+	return nil
+}
+
+func printShadowSide(role string, r *models.ShadowResult) {
+	fmt.Printf("   %-8s %-12s %5d listings  $%.4f  ($%.5f/listing)\n",
+		role+":", r.Adapter, len(r.Listings), r.UsageUSD, scraper.CostPerListing(r))
+}
+
+// mlsSample formats the first few MLS numbers of a list for display
+func mlsSample(mls []string) string {
+	if len(mls) == 0 {
+		return ""
+	}
+	if len(mls) > 5 {
+		return "(" + strings.Join(mls[:5], ", ") + ", ...)"
+	}
+	return "(" + strings.Join(mls, ", ") + ")"
+}
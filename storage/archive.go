@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DatasetArchive keeps raw source datasets on disk as gzipped JSONL so they
+// can be replayed without paying for a new actor run. Layout:
+//
+//	<dir>/<site>/<region>/run<runID>_<timestamp>_<adapter>.jsonl.gz
+type DatasetArchive struct {
+	dir string
+}
+
+func NewDatasetArchive(dir string) *DatasetArchive {
+	return &DatasetArchive{dir: dir}
+}
+
+// ArchiveKey identifies one archived dataset
+type ArchiveKey struct {
+	Site      string
+	Region    string
+	RunID     int64
+	Timestamp time.Time
+	Adapter   string
+}
+
+// Create opens a new archive file for writing
+func (a *DatasetArchive) Create(key ArchiveKey) (*ArchiveWriter, error) {
+	dir := filepath.Join(a.dir, key.Site, key.Region)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("run%d_%s_%s.jsonl.gz", key.RunID, key.Timestamp.UTC().Format("20060102T150405Z"), key.Adapter)
+	path := filepath.Join(dir, name)
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ArchiveWriter{
+		path: path,
+		f:    f,
+		gz:   gzip.NewWriter(f),
+	}, nil
+}
+
+// ArchiveWriter appends items to a gzipped JSONL file
+type ArchiveWriter struct {
+	path  string
+	f     *os.File
+	gz    *gzip.Writer
+	count int
+}
+
+// Write appends one item as a single compact JSON line
+func (w *ArchiveWriter) Write(item json.RawMessage) error {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, item); err != nil {
+		return err
+	}
+	buf.WriteByte('\n')
+	if _, err := w.gz.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	w.count++
+	return nil
+}
+
+func (w *ArchiveWriter) Close() error {
+	gzErr := w.gz.Close()
+	fErr := w.f.Close()
+	if gzErr != nil {
+		return gzErr
+	}
+	return fErr
+}
+
+func (w *ArchiveWriter) Path() string {
+	return w.path
+}
+
+func (w *ArchiveWriter) Count() int {
+	return w.count
+}
+
+// ReadArchive streams items from an archive file to fn
+func ReadArchive(path string, fn func(item json.RawMessage) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	defer gz.Close()
+
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		item := make(json.RawMessage, len(line))
+		copy(item, line)
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// ParseArchivePath recovers the key from an archive path laid out by Create.
+// Fields that can't be recovered are left empty.
+func ParseArchivePath(path string) ArchiveKey {
+	var key ArchiveKey
+	if !strings.HasSuffix(path, ".jsonl.gz") {
+		return key
+	}
+
+	region := filepath.Dir(path)
+	key.Region = filepath.Base(region)
+	key.Site = filepath.Base(filepath.Dir(region))
+
+	name := strings.TrimSuffix(filepath.Base(path), ".jsonl.gz")
+	parts := strings.SplitN(name, "_", 3)
+	if len(parts) == 3 {
+		fmt.Sscanf(parts[0], "run%d", &key.RunID)
+		key.Timestamp, _ = time.Parse("20060102T150405Z", parts[1])
+		key.Adapter = parts[2]
+	}
+	return key
+}
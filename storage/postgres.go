@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -16,11 +17,41 @@ type PostgresStore struct {
 }
 
 func NewPostgresStore(ctx context.Context, connString string) (*PostgresStore, error) {
+	return newPostgresStore(ctx, connString, "")
+}
+
+// NewPostgresStoreInSchema connects with search_path set to schema, e.g. a
+// scratch copy of the domain tables for replays. The schema must already
+// contain the tables (schema_v2.sql + migrations).
+func NewPostgresStoreInSchema(ctx context.Context, connString, schema string) (*PostgresStore, error) {
+	store, err := newPostgresStore(ctx, connString, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	var found *string
+	if err := store.pool.QueryRow(ctx, `SELECT to_regclass($1)::text`, schema+".properties").Scan(&found); err != nil {
+		store.Close()
+		return nil, fmt.Errorf("check schema %s: %w", schema, err)
+	}
+	if found == nil {
+		store.Close()
+		return nil, fmt.Errorf("schema %s has no domain tables (load schema_v2.sql and migrations into it first)", schema)
+	}
+
+	return store, nil
+}
+
+func newPostgresStore(ctx context.Context, connString, schema string) (*PostgresStore, error) {
 	config, err := pgxpool.ParseConfig(connString)
 	if err != nil {
 		return nil, fmt.Errorf("parse config: %w", err)
 	}
 
+	if schema != "" {
+		config.ConnConfig.RuntimeParams["search_path"] = schema
+	}
+
 	config.MaxConns = 10
 	config.MinConns = 2
 	config.MaxConnLifetime = 30 * time.Minute
@@ -239,13 +270,16 @@ func (s *PostgresStore) GetListingByID(ctx context.Context, id uuid.UUID) (*mode
 	return &l, nil
 }
 
+// GetActiveListingForProperty returns the property's listing that is still
+// on the market: active, or missing but not yet delisted
 func (s *PostgresStore) GetActiveListingForProperty(ctx context.Context, propertyID uuid.UUID) (*models.Listing, error) {
 	query := `
 		SELECT id, property_id, source, external_id, url, type, status, price, currency,
 			fees, property_type, beds, baths, sqft, sqft_lot, floor, stories,
 			description, features, raw_data, last_seen, listed_at, delisted_at,
 			enrichment_attempts, created_at, updated_at
-		FROM listings WHERE property_id = $1 AND status = 'active'
+		FROM listings WHERE property_id = $1 AND status IN ('active', 'missing')
+		ORDER BY status = 'active' DESC
 		LIMIT 1`
 
 	var l models.Listing
@@ -641,6 +675,34 @@ func (s *PostgresStore) UpdateScrapeRun(ctx context.Context, run *models.DomainS
 	return err
 }
 
+// GetRunningScrapeRuns returns runs still marked running, oldest first; at
+// startup these are runs the previous daemon never finished
+func (s *PostgresStore) GetRunningScrapeRuns(ctx context.Context) ([]models.DomainScrapeRun, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, source, started_at, finished_at, status, listings_found, listings_new,
+			properties_new, errors_count, COALESCE(error_message, ''), metadata
+		FROM scrape_runs
+		WHERE status = 'running'
+		ORDER BY started_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []models.DomainScrapeRun
+	for rows.Next() {
+		var r models.DomainScrapeRun
+		if err := rows.Scan(
+			&r.ID, &r.Source, &r.StartedAt, &r.FinishedAt, &r.Status, &r.ListingsFound, &r.ListingsNew,
+			&r.PropertiesNew, &r.ErrorsCount, &r.ErrorMessage, &r.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
+
 func (s *PostgresStore) GetLastScrapeRunTime(ctx context.Context) (time.Time, error) {
 	var lastRun time.Time
 	err := s.pool.QueryRow(ctx, `
@@ -655,6 +717,450 @@ func (s *PostgresStore) GetLastScrapeRunTime(ctx context.Context) (time.Time, er
 	return lastRun, nil
 }
 
+// GetApifySpendSince sums the Apify usage recorded in the metadata of a
+// source's runs started at or after since, excluding run excludeRunID
+func (s *PostgresStore) GetApifySpendSince(ctx context.Context, source string, since time.Time, excludeRunID int64) (float64, error) {
+	var spend float64
+	err := s.pool.QueryRow(ctx, `
+		SELECT COALESCE(SUM((metadata->>'apify_usage_usd')::numeric), 0)::float8
+		FROM scrape_runs
+		WHERE source = $1 AND started_at >= $2 AND id <> $3 AND metadata ? 'apify_usage_usd'
+	`, source, since, excludeRunID).Scan(&spend)
+	return spend, err
+}
+
+// =============================================================================
+// Scrape Run Regions
+// =============================================================================
+
+// UpsertScrapeRunRegion records a region's progress; empty Apify fields and
+// last_error keep their previous values so status updates don't clobber them
+func (s *PostgresStore) UpsertScrapeRunRegion(ctx context.Context, r *models.ScrapeRunRegion) error {
+	query := `
+		INSERT INTO scrape_run_regions (run_id, region, status, apify_run_id, apify_actor, apify_status, apify_dataset_id, listings_count,
+			expected_total, fetched_count, ingested_count, days_back, fingerprints, attempts, last_error)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), $8, $9, $10, $11, $12, NULLIF($13, ''), $14, NULLIF($15, ''))
+		ON CONFLICT (run_id, region) DO UPDATE SET
+			status = EXCLUDED.status,
+			apify_run_id = COALESCE(EXCLUDED.apify_run_id, scrape_run_regions.apify_run_id),
+			apify_actor = COALESCE(EXCLUDED.apify_actor, scrape_run_regions.apify_actor),
+			apify_status = COALESCE(EXCLUDED.apify_status, scrape_run_regions.apify_status),
+			apify_dataset_id = COALESCE(EXCLUDED.apify_dataset_id, scrape_run_regions.apify_dataset_id),
+			listings_count = COALESCE(EXCLUDED.listings_count, scrape_run_regions.listings_count),
+			expected_total = COALESCE(EXCLUDED.expected_total, scrape_run_regions.expected_total),
+			fetched_count = COALESCE(EXCLUDED.fetched_count, scrape_run_regions.fetched_count),
+			ingested_count = COALESCE(EXCLUDED.ingested_count, scrape_run_regions.ingested_count),
+			days_back = COALESCE(EXCLUDED.days_back, scrape_run_regions.days_back),
+			fingerprints = COALESCE(EXCLUDED.fingerprints, scrape_run_regions.fingerprints),
+			attempts = GREATEST(EXCLUDED.attempts, scrape_run_regions.attempts),
+			last_error = COALESCE(EXCLUDED.last_error, scrape_run_regions.last_error),
+			updated_at = NOW()`
+
+	_, err := s.pool.Exec(ctx, query,
+		r.RunID, r.Region, r.Status, r.ApifyRunID, r.ApifyActor, r.ApifyStatus, r.ApifyDatasetID, r.ListingsCount,
+		r.ExpectedTotal, r.FetchedCount, r.IngestedCount, r.DaysBack, r.Fingerprints, r.Attempts, r.LastError,
+	)
+	return err
+}
+
+// GetScrapeRunRegions returns all region rows recorded for a run
+func (s *PostgresStore) GetScrapeRunRegions(ctx context.Context, runID int64) ([]models.ScrapeRunRegion, error) {
+	query := `
+		SELECT r.run_id, sr.source, r.region, r.status, COALESCE(r.apify_run_id, ''), COALESCE(r.apify_actor, ''),
+			COALESCE(r.apify_status, ''), COALESCE(r.apify_dataset_id, ''), r.listings_count,
+			r.expected_total, r.fetched_count, r.ingested_count, r.attempts,
+			COALESCE(r.last_error, ''), r.started_at, r.updated_at
+		FROM scrape_run_regions r
+		JOIN scrape_runs sr ON sr.id = r.run_id
+		WHERE r.run_id = $1
+		ORDER BY r.started_at`
+
+	return s.queryScrapeRunRegions(ctx, query, runID)
+}
+
+// GetUnfinishedApifyRegions returns regions of still-running scrape runs whose
+// Apify actor run was started but never ingested (e.g. the daemon restarted)
+func (s *PostgresStore) GetUnfinishedApifyRegions(ctx context.Context) ([]models.ScrapeRunRegion, error) {
+	query := `
+		SELECT r.run_id, sr.source, r.region, r.status, r.apify_run_id, COALESCE(r.apify_actor, ''),
+			COALESCE(r.apify_status, ''), COALESCE(r.apify_dataset_id, ''), r.listings_count,
+			r.expected_total, r.fetched_count, r.ingested_count, r.attempts,
+			COALESCE(r.last_error, ''), r.started_at, r.updated_at
+		FROM scrape_run_regions r
+		JOIN scrape_runs sr ON sr.id = r.run_id
+		WHERE sr.status = 'running' AND r.status = 'running' AND r.apify_run_id IS NOT NULL
+		ORDER BY r.run_id, r.started_at`
+
+	return s.queryScrapeRunRegions(ctx, query)
+}
+
+// GetRecentRegionCounts returns the listing counts of a region's last
+// completed runs for source whose listings came from actor over a daysBack
+// window (nil for unwindowed), newest first, excluding run excludeRunID
+func (s *PostgresStore) GetRecentRegionCounts(ctx context.Context, source, region, actor string, daysBack *int, excludeRunID int64, limit int) ([]int, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT r.listings_count
+		FROM scrape_run_regions r
+		JOIN scrape_runs sr ON sr.id = r.run_id
+		WHERE sr.source = $1 AND r.region = $2 AND r.apify_actor = $3
+			AND r.days_back IS NOT DISTINCT FROM $4 AND r.run_id <> $5
+			AND r.status = 'completed' AND r.listings_count IS NOT NULL
+		ORDER BY r.started_at DESC
+		LIMIT $6`, source, region, actor, daysBack, excludeRunID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []int
+	for rows.Next() {
+		var n int
+		if err := rows.Scan(&n); err != nil {
+			return nil, err
+		}
+		counts = append(counts, n)
+	}
+	return counts, rows.Err()
+}
+
+func (s *PostgresStore) queryScrapeRunRegions(ctx context.Context, query string, args ...interface{}) ([]models.ScrapeRunRegion, error) {
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var regions []models.ScrapeRunRegion
+	for rows.Next() {
+		var r models.ScrapeRunRegion
+		if err := rows.Scan(
+			&r.RunID, &r.Source, &r.Region, &r.Status, &r.ApifyRunID, &r.ApifyActor,
+			&r.ApifyStatus, &r.ApifyDatasetID, &r.ListingsCount, &r.ExpectedTotal, &r.FetchedCount, &r.IngestedCount,
+			&r.Attempts, &r.LastError, &r.StartedAt, &r.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		regions = append(regions, r)
+	}
+	return regions, rows.Err()
+}
+
+// =============================================================================
+// Shadow Results
+// =============================================================================
+
+// SaveShadowResult stores an adapter's result for a region, replacing any
+// earlier result for the same run, region and role
+func (s *PostgresStore) SaveShadowResult(ctx context.Context, r *models.ShadowResult) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO shadow_results (run_id, region, adapter, role, listings_count, usage_usd)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (run_id, region, role) DO UPDATE SET
+			adapter = EXCLUDED.adapter,
+			listings_count = EXCLUDED.listings_count,
+			usage_usd = EXCLUDED.usage_usd,
+			created_at = NOW()
+		RETURNING id, created_at`,
+		r.RunID, r.Region, r.Adapter, r.Role, len(r.Listings), r.UsageUSD,
+	).Scan(&r.ID, &r.CreatedAt)
+	if err != nil {
+		return err
+	}
+	r.ListingsCount = len(r.Listings)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM shadow_listings WHERE result_id = $1`, r.ID); err != nil {
+		return err
+	}
+
+	batch := &pgx.Batch{}
+	for _, l := range r.Listings {
+		batch.Queue(`
+			INSERT INTO shadow_listings (result_id, mls, address, postal_code, price, beds)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (result_id, mls) DO NOTHING`,
+			r.ID, l.MLS, l.Address, l.PostalCode, l.Price, l.Beds)
+	}
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetLatestShadowRunID returns the most recent run of source with shadow
+// results, or 0 if there is none
+func (s *PostgresStore) GetLatestShadowRunID(ctx context.Context, source string) (int64, error) {
+	var runID int64
+	err := s.pool.QueryRow(ctx, `
+		SELECT sr.id FROM scrape_runs sr
+		WHERE sr.source = $1 AND EXISTS (SELECT 1 FROM shadow_results r WHERE r.run_id = sr.id)
+		ORDER BY sr.started_at DESC
+		LIMIT 1
+	`, source).Scan(&runID)
+	if err == pgx.ErrNoRows {
+		return 0, nil
+	}
+	return runID, err
+}
+
+// GetShadowResults returns all shadow results of a run with their listings
+func (s *PostgresStore) GetShadowResults(ctx context.Context, runID int64) ([]models.ShadowResult, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, run_id, region, adapter, role, listings_count, usage_usd::float8, created_at
+		FROM shadow_results
+		WHERE run_id = $1
+		ORDER BY region, role`, runID)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []models.ShadowResult
+	for rows.Next() {
+		var r models.ShadowResult
+		if err := rows.Scan(&r.ID, &r.RunID, &r.Region, &r.Adapter, &r.Role, &r.ListingsCount, &r.UsageUSD, &r.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range results {
+		listings, err := s.pool.Query(ctx, `
+			SELECT mls, COALESCE(address, ''), COALESCE(postal_code, ''), COALESCE(price, 0), COALESCE(beds, 0)
+			FROM shadow_listings
+			WHERE result_id = $1
+			ORDER BY mls`, results[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		for listings.Next() {
+			var l models.ShadowListing
+			if err := listings.Scan(&l.MLS, &l.Address, &l.PostalCode, &l.Price, &l.Beds); err != nil {
+				listings.Close()
+				return nil, err
+			}
+			results[i].Listings = append(results[i].Listings, l)
+		}
+		listings.Close()
+		if err := listings.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// =============================================================================
+// Schema Baselines
+// =============================================================================
+
+// GetSchemaBaseline returns the baseline for source, or nil if none exists
+func (s *PostgresStore) GetSchemaBaseline(ctx context.Context, source string) (*models.SchemaBaseline, error) {
+	var b models.SchemaBaseline
+	var fields []byte
+	err := s.pool.QueryRow(ctx, `
+		SELECT source, fields, items, created_at, updated_at
+		FROM schema_baselines WHERE source = $1`, source,
+	).Scan(&b.Source, &fields, &b.Items, &b.CreatedAt, &b.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(fields, &b.Fields); err != nil {
+		return nil, fmt.Errorf("decode schema baseline %s: %w", source, err)
+	}
+	return &b, nil
+}
+
+// SaveSchemaBaseline creates or replaces the baseline for b.Source
+func (s *PostgresStore) SaveSchemaBaseline(ctx context.Context, b *models.SchemaBaseline) error {
+	fields, err := json.Marshal(b.Fields)
+	if err != nil {
+		return err
+	}
+	_, err = s.pool.Exec(ctx, `
+		INSERT INTO schema_baselines (source, fields, items)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (source) DO UPDATE SET
+			fields = EXCLUDED.fields,
+			items = EXCLUDED.items,
+			updated_at = NOW()`,
+		b.Source, fields, b.Items)
+	return err
+}
+
+// DeleteSchemaBaseline drops the baseline for source so the next dataset
+// becomes the new one. It reports whether a baseline existed.
+func (s *PostgresStore) DeleteSchemaBaseline(ctx context.Context, source string) (bool, error) {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM schema_baselines WHERE source = $1`, source)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// =============================================================================
+// Proxy Stats
+// =============================================================================
+
+// SaveProxyStats replaces the stored proxy stats with the pool's current ones,
+// dropping proxies no longer in the pool
+func (s *PostgresStore) SaveProxyStats(ctx context.Context, stats []models.ProxyStat) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	labels := make([]string, 0, len(stats))
+	for _, st := range stats {
+		labels = append(labels, st.Proxy)
+		_, err := tx.Exec(ctx, `
+			INSERT INTO proxy_stats (proxy, requests, successes, blocks, failures, avg_latency_ms, score, cooldown_until)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			ON CONFLICT (proxy) DO UPDATE SET
+				requests = EXCLUDED.requests,
+				successes = EXCLUDED.successes,
+				blocks = EXCLUDED.blocks,
+				failures = EXCLUDED.failures,
+				avg_latency_ms = EXCLUDED.avg_latency_ms,
+				score = EXCLUDED.score,
+				cooldown_until = EXCLUDED.cooldown_until,
+				updated_at = NOW()`,
+			st.Proxy, st.Requests, st.Successes, st.Blocks, st.Failures, st.AvgLatencyMS, st.Score, st.CooldownUntil)
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM proxy_stats WHERE proxy <> ALL($1)`, labels); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// =============================================================================
+// Quarantine
+// =============================================================================
+
+// QuarantineListing stores a listing that failed validation. A listing that
+// is already pending for the same source and key is updated in place.
+func (s *PostgresStore) QuarantineListing(ctx context.Context, q *models.QuarantinedListing) error {
+	listing := q.Listing
+	listing.Data = nil
+	listingJSON, err := json.Marshal(listing)
+	if err != nil {
+		return err
+	}
+	var rawData []byte
+	if len(q.Listing.Data) > 0 {
+		rawData = q.Listing.Data
+	}
+
+	return s.pool.QueryRow(ctx, `
+		INSERT INTO quarantined_listings (run_id, source, region, listing_key, reasons, listing, raw_data)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7)
+		ON CONFLICT (source, listing_key) WHERE status = 'pending' DO UPDATE SET
+			run_id = EXCLUDED.run_id,
+			region = EXCLUDED.region,
+			reasons = EXCLUDED.reasons,
+			listing = EXCLUDED.listing,
+			raw_data = EXCLUDED.raw_data,
+			updated_at = NOW()
+		RETURNING id, status, created_at, updated_at`,
+		q.RunID, q.Source, q.Region, q.ListingKey, q.Reasons, listingJSON, rawData,
+	).Scan(&q.ID, &q.Status, &q.CreatedAt, &q.UpdatedAt)
+}
+
+const quarantineColumns = `id, run_id, source, COALESCE(region, ''), listing_key, reasons, listing, raw_data,
+	status, created_at, updated_at, resolved_at`
+
+// ListQuarantinedListings returns quarantined listings with the given status,
+// optionally for one source, newest first
+func (s *PostgresStore) ListQuarantinedListings(ctx context.Context, source, status string, limit int) ([]models.QuarantinedListing, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+quarantineColumns+`
+		FROM quarantined_listings
+		WHERE status = $1 AND ($2 = '' OR source = $2)
+		ORDER BY updated_at DESC
+		LIMIT $3`, status, source, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.QuarantinedListing
+	for rows.Next() {
+		q, err := scanQuarantinedListing(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *q)
+	}
+	return items, rows.Err()
+}
+
+// GetQuarantinedListing returns a quarantined listing by ID, or nil
+func (s *PostgresStore) GetQuarantinedListing(ctx context.Context, id int64) (*models.QuarantinedListing, error) {
+	row := s.pool.QueryRow(ctx, `SELECT `+quarantineColumns+` FROM quarantined_listings WHERE id = $1`, id)
+	q, err := scanQuarantinedListing(row)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return q, err
+}
+
+// ResolveQuarantinedListing marks a pending listing released or discarded,
+// storing the listing as it was released
+func (s *PostgresStore) ResolveQuarantinedListing(ctx context.Context, q *models.QuarantinedListing, status string) error {
+	listing := q.Listing
+	listing.Data = nil
+	listingJSON, err := json.Marshal(listing)
+	if err != nil {
+		return err
+	}
+
+	tag, err := s.pool.Exec(ctx, `
+		UPDATE quarantined_listings
+		SET status = $2, listing = $3, resolved_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'pending'`, q.ID, status, listingJSON)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("quarantined listing %d is not pending", q.ID)
+	}
+	q.Status = status
+	return nil
+}
+
+func scanQuarantinedListing(row pgx.Row) (*models.QuarantinedListing, error) {
+	var q models.QuarantinedListing
+	var listingJSON, rawData []byte
+	err := row.Scan(&q.ID, &q.RunID, &q.Source, &q.Region, &q.ListingKey, &q.Reasons, &listingJSON, &rawData,
+		&q.Status, &q.CreatedAt, &q.UpdatedAt, &q.ResolvedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(listingJSON, &q.Listing); err != nil {
+		return nil, fmt.Errorf("decode quarantined listing %d: %w", q.ID, err)
+	}
+	if len(rawData) > 0 {
+		q.Listing.Data = rawData
+	}
+	return &q, nil
+}
+
 // =============================================================================
 // Scrape Logs
 // =============================================================================
@@ -708,6 +1214,184 @@ func (s *PostgresStore) GetStaleActiveListings(ctx context.Context, staleDuratio
 	return listings, rows.Err()
 }
 
+// =============================================================================
+// Disappearance detection
+// =============================================================================
+
+// RegionListing is a listing of a region that a full scrape did not return
+type RegionListing struct {
+	ID           uuid.UUID
+	ExternalID   string
+	Status       string // active or missing
+	MissingSince *time.Time
+}
+
+// TagListingsRegion records region as the region that last returned the
+// given listings and clears any missing mark they had
+func (s *PostgresStore) TagListingsRegion(ctx context.Context, source, region string, externalIDs []string) error {
+	_, err := s.pool.Exec(ctx, `
+		UPDATE listings SET region = $2, missing_since = NULL, missing_checked_at = NULL
+		WHERE source = $1 AND external_id = ANY($3)`,
+		source, region, externalIDs)
+	return err
+}
+
+// GetUnseenRegionListings returns the active and missing listings of a
+// region that are not among seen, and how many active or missing listings
+// the region has in total
+func (s *PostgresStore) GetUnseenRegionListings(ctx context.Context, source, region string, seen []string) ([]RegionListing, int, error) {
+	var total int
+	err := s.pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM listings
+		WHERE source = $1 AND region = $2 AND status IN ('active', 'missing')`,
+		source, region).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, external_id, status, missing_since
+		FROM listings
+		WHERE source = $1 AND region = $2 AND status IN ('active', 'missing')
+			AND NOT (external_id = ANY($3))
+		ORDER BY external_id`,
+		source, region, seen)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var listings []RegionListing
+	for rows.Next() {
+		var l RegionListing
+		if err := rows.Scan(&l.ID, &l.ExternalID, &l.Status, &l.MissingSince); err != nil {
+			return nil, 0, err
+		}
+		listings = append(listings, l)
+	}
+	return listings, total, rows.Err()
+}
+
+// MarkListingsMissing flags active listings as missing since at, which
+// queues them for a priority healthcheck
+func (s *PostgresStore) MarkListingsMissing(ctx context.Context, ids []uuid.UUID, at time.Time) error {
+	_, err := s.pool.Exec(ctx, `
+		UPDATE listings SET status = 'missing', missing_since = $2, missing_checked_at = NULL, updated_at = $2
+		WHERE id = ANY($1) AND status = 'active'`,
+		ids, at)
+	return err
+}
+
+// GetMissingListingsToCheck returns missing listings not yet healthchecked
+// since they went missing, oldest first
+func (s *PostgresStore) GetMissingListingsToCheck(ctx context.Context, limit int) ([]models.Listing, error) {
+	query := `
+		SELECT id, property_id, source, external_id, url, type, status, price, currency,
+			fees, property_type, beds, baths, sqft, sqft_lot, floor, stories,
+			description, features, raw_data, last_seen, listed_at, delisted_at,
+			enrichment_attempts, created_at, updated_at
+		FROM listings
+		WHERE status = 'missing' AND missing_checked_at IS NULL
+		ORDER BY missing_since
+		LIMIT $1`
+
+	rows, err := s.pool.Query(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var listings []models.Listing
+	for rows.Next() {
+		var l models.Listing
+		if err := rows.Scan(
+			&l.ID, &l.PropertyID, &l.Source, &l.ExternalID, &l.URL, &l.Type, &l.Status, &l.Price, &l.Currency,
+			&l.Fees, &l.PropertyType, &l.Beds, &l.Baths, &l.SqFt, &l.SqFtLot, &l.Floor, &l.Stories,
+			&l.Description, &l.Features, &l.RawData, &l.LastSeen, &l.ListedAt, &l.DelistedAt,
+			&l.EnrichmentAttempts, &l.CreatedAt, &l.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		listings = append(listings, l)
+	}
+	return listings, rows.Err()
+}
+
+// MarkMissingChecked records that a missing listing was healthchecked
+func (s *PostgresStore) MarkMissingChecked(ctx context.Context, id uuid.UUID) error {
+	_, err := s.pool.Exec(ctx, `UPDATE listings SET missing_checked_at = NOW() WHERE id = $1`, id)
+	return err
+}
+
+// RestoreMissingListing makes a missing listing active again
+func (s *PostgresStore) RestoreMissingListing(ctx context.Context, id uuid.UUID) error {
+	_, err := s.pool.Exec(ctx, `
+		UPDATE listings SET status = 'active', missing_since = NULL, missing_checked_at = NULL,
+			last_seen = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'missing'`, id)
+	return err
+}
+
+// =============================================================================
+// Reprocessing
+// =============================================================================
+
+// ReprocessFilter narrows the listings whose raw_data is reprocessed. Zero
+// values match everything; From/To bound last_seen, when raw_data was stored.
+type ReprocessFilter struct {
+	Source string
+	City   string
+	From   time.Time
+	To     time.Time
+}
+
+// ListListingsForReprocess returns the next batch of listings with raw_data
+// matching the filter, ordered by ID after the given one (uuid.Nil to start)
+func (s *PostgresStore) ListListingsForReprocess(ctx context.Context, f ReprocessFilter, after uuid.UUID, limit int) ([]models.Listing, error) {
+	query := `
+		SELECT l.id, l.property_id, l.source, l.external_id, l.url, l.type, l.status, l.price, l.currency,
+			l.fees, l.property_type, l.beds, l.baths, l.sqft, l.sqft_lot, l.floor, l.stories,
+			l.description, l.features, l.raw_data, l.last_seen, l.listed_at, l.delisted_at,
+			l.enrichment_attempts, l.created_at, l.updated_at
+		FROM listings l
+		JOIN properties p ON p.id = l.property_id
+		WHERE l.raw_data IS NOT NULL AND l.id > $1
+			AND ($2 = '' OR l.source = $2)
+			AND ($3 = '' OR p.city ILIKE $3)
+			AND ($4::timestamptz IS NULL OR l.last_seen >= $4)
+			AND ($5::timestamptz IS NULL OR l.last_seen < $5)
+		ORDER BY l.id
+		LIMIT $6`
+
+	rows, err := s.pool.Query(ctx, query, after, f.Source, f.City, nullTime(f.From), nullTime(f.To), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var listings []models.Listing
+	for rows.Next() {
+		var l models.Listing
+		if err := rows.Scan(
+			&l.ID, &l.PropertyID, &l.Source, &l.ExternalID, &l.URL, &l.Type, &l.Status, &l.Price, &l.Currency,
+			&l.Fees, &l.PropertyType, &l.Beds, &l.Baths, &l.SqFt, &l.SqFtLot, &l.Floor, &l.Stories,
+			&l.Description, &l.Features, &l.RawData, &l.LastSeen, &l.ListedAt, &l.DelistedAt,
+			&l.EnrichmentAttempts, &l.CreatedAt, &l.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		listings = append(listings, l)
+	}
+	return listings, rows.Err()
+}
+
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
 // =============================================================================
 // Media Bridge Tables (Records, Assessments, Intel)
 // =============================================================================
@@ -818,6 +1502,11 @@ func (s *PostgresStore) ResetAllData(ctx context.Context) error {
 		"media",
 		"agents",
 		"brokerages",
+		"quarantined_listings",
+		"schema_baselines",
+		"shadow_listings",
+		"shadow_results",
+		"scrape_run_regions",
 		"scrape_runs",
 	}
 
@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -23,6 +24,7 @@ type SiteStats struct {
 	TotalListings    int
 	SuccessRate      float64
 	AvgRunDuration   int
+	MonthSpendUSD    float64 // Apify usage of runs started this month
 }
 
 type ScrapeRun struct {
@@ -111,6 +113,30 @@ type CityStats struct {
 	MedianPrice    int64
 }
 
+// CoverageTrend is a region's coverage over its recent completed runs:
+// listings ingested as a share of the total the source reported
+type CoverageTrend struct {
+	Source   string
+	Region   string
+	Points   []int // coverage percentage per run, oldest first
+	Expected int   // latest run: total the source reported
+	Ingested int   // latest run: listings ingested
+	Low      bool  // latest run flagged the region for low coverage
+}
+
+// ProxyStat is one proxy's outcomes in the daemon's pool since it started
+type ProxyStat struct {
+	Proxy         string // user@host:port
+	Requests      int64
+	Successes     int64
+	Blocks        int64
+	Failures      int64
+	AvgLatencyMS  int
+	Score         float64
+	CooldownUntil *time.Time // set while rotated out after blocks
+	UpdatedAt     time.Time
+}
+
 func New(postgresURL, sqlitePath string) (*Client, error) {
 	ctx := context.Background()
 
@@ -158,6 +184,13 @@ func (c *Client) GetSiteStats() ([]SiteStats, error) {
 			FROM scrape_runs
 			WHERE finished_at IS NOT NULL
 			GROUP BY source
+		),
+		month_spend AS (
+			SELECT source, SUM((metadata->>'apify_usage_usd')::numeric)::float8 as spend
+			FROM scrape_runs
+			WHERE started_at >= date_trunc('month', NOW())
+				AND metadata ? 'apify_usage_usd'
+			GROUP BY source
 		)
 		SELECT
 			lr.source,
@@ -166,9 +199,11 @@ func (c *Client) GetSiteStats() ([]SiteStats, error) {
 			COALESCE((SELECT COUNT(*) FROM properties), 0)::int as total_properties,
 			COALESCE((SELECT COUNT(*) FROM listings WHERE source = lr.source), 0)::int as total_listings,
 			COALESCE(rs.successful_runs::float / NULLIF(rs.total_runs, 0), 0) as success_rate,
-			COALESCE(rs.avg_duration, 0) as avg_duration
+			COALESCE(rs.avg_duration, 0) as avg_duration,
+			COALESCE(ms.spend, 0) as month_spend
 		FROM latest_runs lr
 		LEFT JOIN run_stats rs ON lr.source = rs.source
+		LEFT JOIN month_spend ms ON lr.source = ms.source
 		ORDER BY lr.source
 	`)
 	if err != nil {
@@ -182,7 +217,7 @@ func (c *Client) GetSiteStats() ([]SiteStats, error) {
 		var lastRunAt *time.Time
 		var status *string
 		err := rows.Scan(&s.SiteID, &lastRunAt, &status,
-			&s.TotalProperties, &s.TotalListings, &s.SuccessRate, &s.AvgRunDuration)
+			&s.TotalProperties, &s.TotalListings, &s.SuccessRate, &s.AvgRunDuration, &s.MonthSpendUSD)
 		if err != nil {
 			return nil, err
 		}
@@ -246,7 +281,7 @@ func (c *Client) GetProperties(limit, offset int, activeOnly bool) ([]Property,
 		FROM properties p
 	`
 	if activeOnly {
-		query += ` WHERE EXISTS (SELECT 1 FROM listings l WHERE l.property_id = p.id AND l.status = 'active')`
+		query += ` WHERE EXISTS (SELECT 1 FROM listings l WHERE l.property_id = p.id AND l.status IN ('active', 'missing'))`
 	}
 	query += ` ORDER BY p.updated_at DESC LIMIT $1 OFFSET $2`
 
@@ -284,7 +319,7 @@ func (c *Client) GetListingCount() (int, error) {
 
 func (c *Client) GetActiveListingCount() (int, error) {
 	var count int
-	err := c.pg.QueryRow(c.ctx, "SELECT COUNT(*) FROM listings WHERE status = 'active'").Scan(&count)
+	err := c.pg.QueryRow(c.ctx, "SELECT COUNT(*) FROM listings WHERE status IN ('active', 'missing')").Scan(&count)
 	return count, err
 }
 
@@ -310,8 +345,8 @@ func (c *Client) GetCityStats() ([]CityStats, error) {
 			COALESCE(p.province, '') as province,
 			COUNT(DISTINCT p.id)::int as property_count,
 			COUNT(l.id)::int as listing_count,
-			COUNT(l.id) FILTER (WHERE l.status = 'active')::int as active_count,
-			COALESCE(AVG(l.price) FILTER (WHERE l.status = 'active'), 0)::bigint as avg_price
+			COUNT(l.id) FILTER (WHERE l.status IN ('active', 'missing'))::int as active_count,
+			COALESCE(AVG(l.price) FILTER (WHERE l.status IN ('active', 'missing')), 0)::bigint as avg_price
 		FROM properties p
 		LEFT JOIN listings l ON l.property_id = p.id
 		GROUP BY p.city, p.province
@@ -335,6 +370,78 @@ func (c *Client) GetCityStats() ([]CityStats, error) {
 	return stats, nil
 }
 
+// GetCoverageTrend returns the coverage of each region over its last
+// `runs` completed runs that had a reported total
+func (c *Client) GetCoverageTrend(runs int) ([]CoverageTrend, error) {
+	rows, err := c.pg.Query(c.ctx, `
+		SELECT source, region, expected_total, ingested, low
+		FROM (
+			SELECT
+				sr.source,
+				r.region,
+				r.expected_total,
+				COALESCE(r.ingested_count, 0) as ingested,
+				COALESCE(sr.metadata->'low_coverage' ? r.region, false) as low,
+				r.started_at,
+				ROW_NUMBER() OVER (PARTITION BY sr.source, r.region ORDER BY r.started_at DESC) as rn
+			FROM scrape_run_regions r
+			JOIN scrape_runs sr ON sr.id = r.run_id
+			WHERE r.status = 'completed' AND r.expected_total > 0
+		) recent
+		WHERE rn <= $1
+		ORDER BY source, region, started_at
+	`, runs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var trends []CoverageTrend
+	for rows.Next() {
+		var source, region string
+		var expected, ingested int
+		var low bool
+		if err := rows.Scan(&source, &region, &expected, &ingested, &low); err != nil {
+			return nil, err
+		}
+		if n := len(trends); n == 0 || trends[n-1].Source != source || trends[n-1].Region != region {
+			trends = append(trends, CoverageTrend{Source: source, Region: region})
+		}
+		t := &trends[len(trends)-1]
+		t.Points = append(t.Points, ingested*100/expected)
+		t.Expected = expected
+		t.Ingested = ingested
+		t.Low = low
+	}
+	return trends, rows.Err()
+}
+
+// GetProxyStats returns the proxy pool stats last written by the daemon,
+// best scoring first
+func (c *Client) GetProxyStats() ([]ProxyStat, error) {
+	rows, err := c.pg.Query(c.ctx, `
+		SELECT proxy, requests, successes, blocks, failures,
+			COALESCE(avg_latency_ms, 0), COALESCE(score, 0), cooldown_until, updated_at
+		FROM proxy_stats
+		ORDER BY score DESC NULLS LAST, proxy
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []ProxyStat
+	for rows.Next() {
+		var s ProxyStat
+		if err := rows.Scan(&s.Proxy, &s.Requests, &s.Successes, &s.Blocks, &s.Failures,
+			&s.AvgLatencyMS, &s.Score, &s.CooldownUntil, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
 func (c *Client) GetListingsForProperty(propertyID string) ([]Listing, error) {
 	rows, err := c.pg.Query(c.ctx, `
 		SELECT * FROM (
@@ -473,10 +580,17 @@ func (c *Client) GetRecentLogs(limit int, level *string) ([]ScrapeLog, error) {
 
 // Commands still go through SQLite (daemon reads from there)
 func (c *Client) SendCommand(command string, params map[string]interface{}) error {
+	encoded := []byte("{}")
+	if params != nil {
+		var err error
+		if encoded, err = json.Marshal(params); err != nil {
+			return err
+		}
+	}
 	_, err := c.sqlite.Exec(`
 		INSERT INTO commands (command, params, created_at)
-		VALUES (?, '{}', datetime('now'))
-	`, command)
+		VALUES (?, ?, datetime('now'))
+	`, command, string(encoded))
 	return err
 }
 
@@ -496,6 +610,14 @@ func (c *Client) RunHealthcheck() error {
 	return c.SendCommand("run_healthcheck", nil)
 }
 
+func (c *Client) CancelRun(runID int64) error {
+	return c.SendCommand("cancel_run", map[string]interface{}{"run_id": runID})
+}
+
+func (c *Client) CancelSite(siteID string) error {
+	return c.SendCommand("cancel_site", map[string]interface{}{"site": siteID})
+}
+
 func deref(s *string) string {
 	if s == nil {
 		return ""
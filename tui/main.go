@@ -110,6 +110,14 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.notification = "Healthcheck worker triggered!"
 				m.notifyUntil = time.Now().Add(2 * time.Second)
 			}
+		case "x":
+			if run, ok := m.dashboard.RunningRun(); !ok {
+				m.notification = "No running scrape"
+				m.notifyUntil = time.Now().Add(2 * time.Second)
+			} else if err := m.db.CancelRun(run.ID); err == nil {
+				m.notification = fmt.Sprintf("Cancel sent for %s run %d", run.SiteID, run.ID)
+				m.notifyUntil = time.Now().Add(2 * time.Second)
+			}
 		case "c":
 			if url := m.data.GetSelectedURL(); url != "" {
 				m.notification = "URL copied!"
@@ -217,7 +225,7 @@ func (m model) renderContent() string {
 }
 
 func (m model) renderStatusBar() string {
-	left := "d Dash  p Data  l Log  r Refresh  s Scrape  m Media  e Enrich  h Health  q Quit"
+	left := "d Dash  p Data  l Log  r Refresh  s Scrape  x Cancel  m Media  e Enrich  h Health  q Quit"
 	right := ""
 	if time.Now().Before(m.notifyUntil) {
 		right = styles.Notification.Render(m.notification)
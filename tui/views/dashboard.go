@@ -19,6 +19,8 @@ type dashboardDataMsg struct {
 	stats           []db.SiteStats
 	runs            []db.ScrapeRun
 	cityStats       []db.CityStats
+	coverage        []db.CoverageTrend
+	proxies         []db.ProxyStat
 	propCount       int
 	listingCount    int
 	activeCount     int
@@ -38,6 +40,8 @@ type Dashboard struct {
 	stats           []db.SiteStats
 	runs            []db.ScrapeRun
 	cityStats       []db.CityStats
+	coverage        []db.CoverageTrend
+	proxies         []db.ProxyStat
 	propCount       int
 	listingCount    int
 	activeCount     int
@@ -76,12 +80,14 @@ func (d Dashboard) Refresh() tea.Cmd {
 		stats, _ := d.db.GetSiteStats()
 		runs, _ := d.db.GetRecentRuns(10)
 		cityStats, _ := d.db.GetCityStats()
+		coverage, _ := d.db.GetCoverageTrend(coverageTrendRuns)
+		proxies, _ := d.db.GetProxyStats()
 		propCount, _ := d.db.GetPropertyCount()
 		listingCount, _ := d.db.GetListingCount()
 		activeCount, _ := d.db.GetActiveListingCount()
 		mediaQueue, _ := d.db.GetPendingMediaCount()
 		enrichmentQueue, _ := d.db.GetPendingEnrichmentCount()
-		return dashboardDataMsg{stats, runs, cityStats, propCount, listingCount, activeCount, mediaQueue, enrichmentQueue}
+		return dashboardDataMsg{stats, runs, cityStats, coverage, proxies, propCount, listingCount, activeCount, mediaQueue, enrichmentQueue}
 	}
 }
 
@@ -159,6 +165,8 @@ func (d Dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		d.stats = msg.stats
 		d.runs = msg.runs
 		d.cityStats = msg.cityStats
+		d.coverage = msg.coverage
+		d.proxies = msg.proxies
 		d.propCount = msg.propCount
 		d.listingCount = msg.listingCount
 		d.activeCount = msg.activeCount
@@ -215,10 +223,22 @@ func (d Dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return d, nil
 }
 
+// RunningRun returns the most recently started run that is still running
+func (d Dashboard) RunningRun() (db.ScrapeRun, bool) {
+	for _, r := range d.runs {
+		if r.Status == "running" {
+			return r, true
+		}
+	}
+	return db.ScrapeRun{}, false
+}
+
 func (d Dashboard) View() string {
 	statCards := d.renderStatCards()
 	siteCards := d.renderSiteCards()
 	cityCards := d.renderCityCards()
+	coverageCards := d.renderCoverageCards()
+	proxyCards := d.renderProxyCards()
 	runsTable := d.renderRunsTable()
 	logTail := d.renderLogTail()
 
@@ -230,6 +250,10 @@ func (d Dashboard) View() string {
 		"",
 		cityCards,
 		"",
+		coverageCards,
+		"",
+		proxyCards,
+		"",
 		styles.Title.Render("Recent Runs"),
 		runsTable,
 		"",
@@ -434,6 +458,115 @@ func (d Dashboard) renderCityCard(c db.CityStats, width int) string {
 	return styles.CityCardBorder.Width(width).Render(content)
 }
 
+// coverageTrendRuns is how many recent runs the coverage sparklines show
+const coverageTrendRuns = 10
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+func (d Dashboard) renderCoverageCards() string {
+	if len(d.coverage) == 0 {
+		return ""
+	}
+
+	cardWidth := d.cityCardWidth()
+	cardsPerRow := d.width / (cardWidth + 2)
+	if cardsPerRow < 1 {
+		cardsPerRow = 1
+	}
+
+	var rows []string
+	var currentRow []string
+	for i, c := range d.coverage {
+		currentRow = append(currentRow, d.renderCoverageCard(c, cardWidth))
+		if (i+1)%cardsPerRow == 0 || i == len(d.coverage)-1 {
+			rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, currentRow...))
+			currentRow = nil
+		}
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, append([]string{styles.Title.Render("Coverage")}, rows...)...)
+}
+
+func (d Dashboard) renderCoverageCard(c db.CoverageTrend, width int) string {
+	latest := c.Points[len(c.Points)-1]
+	valueStyle := styles.StatusSuccess
+	if c.Low {
+		valueStyle = styles.StatusError
+	}
+
+	content := lipgloss.JoinVertical(lipgloss.Left,
+		styles.StatValue.Render(truncate(c.Source+"/"+c.Region, width-2)),
+		valueStyle.Render(fmt.Sprintf("%d%%", latest)),
+		styles.LogInfo.Render(sparkline(c.Points)),
+		styles.StatLabel.Render(fmt.Sprintf("%d/%d ingested", c.Ingested, c.Expected)),
+	)
+	return styles.CityCardBorder.Width(width).Render(content)
+}
+
+// sparkline renders percentages (capped at 100) as block characters
+func sparkline(points []int) string {
+	var b strings.Builder
+	for _, p := range points {
+		if p > 100 {
+			p = 100
+		}
+		if p < 0 {
+			p = 0
+		}
+		b.WriteRune(sparkBlocks[p*(len(sparkBlocks)-1)/100])
+	}
+	return b.String()
+}
+
+func (d Dashboard) renderProxyCards() string {
+	if len(d.proxies) == 0 {
+		return ""
+	}
+
+	cardWidth := d.cityCardWidth()
+	cardsPerRow := d.width / (cardWidth + 2)
+	if cardsPerRow < 1 {
+		cardsPerRow = 1
+	}
+
+	var rows []string
+	var currentRow []string
+	for i, p := range d.proxies {
+		currentRow = append(currentRow, d.renderProxyCard(p, cardWidth))
+		if (i+1)%cardsPerRow == 0 || i == len(d.proxies)-1 {
+			rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, currentRow...))
+			currentRow = nil
+		}
+	}
+	title := styles.Title.Render("Proxies")
+	if updated := d.proxies[0].UpdatedAt; !updated.IsZero() {
+		title += styles.Muted.Render(" updated " + relativeTime(updated))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, append([]string{title}, rows...)...)
+}
+
+func (d Dashboard) renderProxyCard(p db.ProxyStat, width int) string {
+	status := styles.StatusSuccess.Render(fmt.Sprintf("score %.2f", p.Score))
+	if p.CooldownUntil != nil && p.CooldownUntil.After(time.Now()) {
+		status = styles.StatusError.Render("cooling " + time.Until(*p.CooldownUntil).Round(time.Minute).String())
+	} else if p.Blocks > 0 && p.Blocks*10 >= p.Requests {
+		status = styles.StatusPending.Render(fmt.Sprintf("score %.2f", p.Score))
+	}
+
+	successPct := 0
+	if p.Requests > 0 {
+		successPct = int(p.Successes * 100 / p.Requests)
+	}
+
+	content := lipgloss.JoinVertical(lipgloss.Left,
+		styles.StatValue.Render(truncate(p.Proxy, width-2)),
+		status,
+		styles.StatLabel.Render(fmt.Sprintf("%d req, %d%% ok", p.Requests, successPct)),
+		styles.StatLabel.Render(fmt.Sprintf("%d blocked, %d failed", p.Blocks, p.Failures)),
+		styles.StatLabel.Render(fmt.Sprintf("Latency: %dms", p.AvgLatencyMS)),
+	)
+	return styles.CityCardBorder.Width(width).Render(content)
+}
+
 func (d Dashboard) renderSiteCard(s db.SiteStats, width int) string {
 	status := "○ never run"
 	statusStyle := styles.StatusPending
@@ -448,6 +581,9 @@ func (d Dashboard) renderSiteCard(s db.SiteStats, width int) string {
 		case "running":
 			status = "◐ running"
 			statusStyle = styles.StatusPending
+		case "cancelled":
+			status = "⊘ cancelled"
+			statusStyle = styles.StatusError
 		}
 	}
 
@@ -456,14 +592,19 @@ func (d Dashboard) renderSiteCard(s db.SiteStats, width int) string {
 		lastRun = relativeTime(*s.LastRunAt)
 	}
 
-	content := lipgloss.JoinVertical(lipgloss.Left,
+	lines := []string{
 		styles.StatValue.Render(s.SiteID),
 		statusStyle.Render(status),
 		styles.StatLabel.Render(fmt.Sprintf("Last: %s", lastRun)),
 		styles.StatLabel.Render(fmt.Sprintf("Props: %d", s.TotalProperties)),
 		styles.StatLabel.Render(fmt.Sprintf("Listings: %d", s.TotalListings)),
 		styles.StatLabel.Render(fmt.Sprintf("Rate: %.0f%%", s.SuccessRate*100)),
-	)
+	}
+	if s.MonthSpendUSD > 0 {
+		lines = append(lines, styles.StatLabel.Render(fmt.Sprintf("Spend: $%.2f this month", s.MonthSpendUSD)))
+	}
+
+	content := lipgloss.JoinVertical(lipgloss.Left, lines...)
 	return styles.SiteCardBorder.Width(width).Render(content)
 }
 
@@ -496,7 +637,7 @@ func (d Dashboard) renderRunsTable() string {
 			switch r.Status {
 			case "completed":
 				statusStyle = styles.StatusSuccess
-			case "failed":
+			case "failed", "cancelled":
 				statusStyle = styles.StatusError
 			}
 
@@ -528,7 +669,7 @@ func (d Dashboard) renderRunsTable() string {
 		switch r.Status {
 		case "completed":
 			statusStyle = styles.StatusSuccess
-		case "failed":
+		case "failed", "cancelled":
 			statusStyle = styles.StatusError
 		}
 
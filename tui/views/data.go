@@ -348,6 +348,8 @@ func (d Data) renderPriceHistory() string {
 		statusStyle := styles.Muted
 		if l.Status == "active" {
 			statusStyle = styles.StatusSuccess
+		} else if l.Status == "missing" {
+			statusStyle = styles.StatusPending
 		} else if l.Status == "delisted" {
 			statusStyle = styles.StatusError
 		}
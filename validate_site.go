@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"tct_scrooper/config"
+	"tct_scrooper/scraper"
+)
+
+// runValidateSite runs a declarative site mapping against a fixture file and
+// prints the parsed listings, so a new source can be checked without scraping.
+func runValidateSite(site, fixture string) error {
+	if fixture == "" {
+		return fmt.Errorf("-fixture is required")
+	}
+
+	siteCfg, err := loadSiteForValidation(site)
+	if err != nil {
+		return err
+	}
+	if siteCfg.Declarative == nil {
+		return fmt.Errorf("site %s has no declarative block", siteCfg.ID)
+	}
+
+	mapper, err := scraper.NewListingMapper(siteCfg.Declarative)
+	if err != nil {
+		return fmt.Errorf("invalid mapping: %w", err)
+	}
+
+	body, err := os.ReadFile(fixture)
+	if err != nil {
+		return err
+	}
+
+	listings, err := mapper.Parse(body)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s: %d listings from %s\n\n", siteCfg.ID, len(listings), fixture)
+
+	empty := make(map[string]int)
+	for i, l := range listings {
+		fmt.Printf("#%d MLS %s (id %s)\n", i+1, l.MLS, l.ID)
+		fmt.Printf("   address:  %s\n", l.Address)
+		fmt.Printf("   location: %s, %s %s\n", l.City, l.Province, l.PostalCode)
+		fmt.Printf("   price:    %d\n", l.Price)
+		fmt.Printf("   beds:     %d + %d, baths %d, sqft %d\n", l.Beds, l.BedsPlus, l.Baths, l.SqFt)
+		fmt.Printf("   type:     %s\n", l.PropertyType)
+		fmt.Printf("   url:      %s\n", l.URL)
+		fmt.Printf("   photos:   %d\n", len(l.Photos))
+		if l.Description != "" {
+			fmt.Printf("   desc:     %s\n", truncateLine(l.Description, 80))
+		}
+
+		for field, isEmpty := range map[string]bool{
+			"address": l.Address == "", "city": l.City == "", "postal_code": l.PostalCode == "",
+			"price": l.Price == 0, "beds": l.Beds == 0, "url": l.URL == "", "photos": len(l.Photos) == 0,
+		} {
+			if isEmpty {
+				empty[field]++
+			}
+		}
+	}
+
+	if len(empty) > 0 {
+		fields := make([]string, 0, len(empty))
+		for field := range empty {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+
+		fmt.Println("\nEmpty fields:")
+		for _, field := range fields {
+			fmt.Printf("  %-12s %d/%d\n", field, empty[field], len(listings))
+		}
+	}
+
+	return nil
+}
+
+// loadSiteForValidation accepts either a YAML path or a site ID from config/sites
+func loadSiteForValidation(site string) (*config.SiteConfig, error) {
+	if strings.HasSuffix(site, ".yaml") || strings.HasSuffix(site, ".yml") {
+		return config.LoadSiteFile(site)
+	}
+
+	sites, err := config.LoadSites("config/sites")
+	if err != nil {
+		return nil, err
+	}
+	siteCfg, ok := sites[site]
+	if !ok {
+		return nil, fmt.Errorf("unknown site: %s", site)
+	}
+	return siteCfg, nil
+}
+
+func truncateLine(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) > n {
+		return s[:n] + "..."
+	}
+	return s
+}
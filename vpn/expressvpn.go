@@ -1,84 +1,41 @@
 package vpn
 
 import (
-	"errors"
-	"os/exec"
+	"context"
 	"strings"
-	"time"
 )
 
-var (
-	ErrVPNNotConnected = errors.New("VPN not connected")
-	ErrVPNConnectFail  = errors.New("failed to connect VPN")
-)
-
-type Config struct {
-	ActivationCode string
-	AutoConnect    bool
-	Region         string
+// ExpressVPN drives expressvpnctl; an empty region means "smart"
+type ExpressVPN struct {
+	run Runner
 }
 
-type ExpressVPN struct {
-	cfg *Config
+func NewExpressVPN(run Runner) *ExpressVPN {
+	return &ExpressVPN{run: run}
 }
 
-func NewExpressVPN(cfg *Config) *ExpressVPN {
-	return &ExpressVPN{cfg: cfg}
+func (v *ExpressVPN) Name() string {
+	return "expressvpn"
 }
 
-func (v *ExpressVPN) IsConnected() bool {
-	out, err := exec.Command("expressvpnctl", "status").Output()
+func (v *ExpressVPN) Connected(ctx context.Context) (bool, error) {
+	out, err := v.run.Run(ctx, "expressvpnctl", "status")
 	if err != nil {
-		return false
+		return false, err
 	}
 	status := strings.ToLower(string(out))
-	return strings.Contains(status, "connected") && !strings.Contains(status, "disconnected")
+	return strings.Contains(status, "connected") && !strings.Contains(status, "disconnected"), nil
 }
 
-func (v *ExpressVPN) Connect() error {
-	if v.IsConnected() {
-		return nil
-	}
-
-	if !v.cfg.AutoConnect {
-		return ErrVPNNotConnected
-	}
-
-	region := v.cfg.Region
+func (v *ExpressVPN) Connect(ctx context.Context, region string) error {
 	if region == "" {
 		region = "smart"
 	}
-
-	cmd := exec.Command("expressvpnctl", "connect", region)
-	if err := cmd.Run(); err != nil {
-		return ErrVPNConnectFail
-	}
-
-	for i := 0; i < 30; i++ {
-		time.Sleep(time.Second)
-		if v.IsConnected() {
-			return nil
-		}
-	}
-
-	return ErrVPNConnectFail
-}
-
-func (v *ExpressVPN) EnsureConnected() error {
-	if v.IsConnected() {
-		return nil
-	}
-	return v.Connect()
-}
-
-func (v *ExpressVPN) Disconnect() error {
-	return exec.Command("expressvpnctl", "disconnect").Run()
+	_, err := v.run.Run(ctx, "expressvpnctl", "connect", region)
+	return err
 }
 
-func (v *ExpressVPN) GetStatus() (string, error) {
-	out, err := exec.Command("expressvpnctl", "status").Output()
-	if err != nil {
-		return "", err
-	}
-	return strings.TrimSpace(string(out)), nil
+func (v *ExpressVPN) Disconnect(ctx context.Context) error {
+	_, err := v.run.Run(ctx, "expressvpnctl", "disconnect")
+	return err
 }
@@ -0,0 +1,286 @@
+package vpn
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"tct_scrooper/config"
+)
+
+const (
+	connectTimeout = 30 * time.Second
+	connectPoll    = time.Second
+)
+
+// Manager keeps the VPN tunnel up for scraping. It connects on demand,
+// rotates to the next region after repeated blocks and, with the kill switch
+// on, holds back outbound scraping (Wait) while the tunnel is down. A nil
+// Manager means no VPN: every method is a no-op.
+type Manager struct {
+	provider    Provider
+	regions     []string
+	autoConnect bool
+	rotateAfter int
+	killSwitch  bool
+	poll        time.Duration // between Connected checks while connecting
+	onRotate    func()
+
+	mu       sync.Mutex
+	region   int // index into regions
+	blocks   int // consecutive blocks since the last success or rotation
+	rotating bool
+	up       chan struct{} // closed while the tunnel is up
+}
+
+// New returns a Manager for cfg, or nil when no provider is configured
+func New(cfg config.VPNConfig, run Runner) (*Manager, error) {
+	if cfg.Provider == "" {
+		return nil, nil
+	}
+	provider, err := NewProvider(cfg, run)
+	if err != nil {
+		return nil, err
+	}
+	return NewManager(provider, cfg), nil
+}
+
+func NewManager(provider Provider, cfg config.VPNConfig) *Manager {
+	regions := cfg.Regions
+	if len(regions) == 0 {
+		regions = []string{""} // the provider's default
+	}
+	return &Manager{
+		provider:    provider,
+		regions:     regions,
+		autoConnect: cfg.AutoConnect,
+		rotateAfter: cfg.RotateAfterBlocks,
+		killSwitch:  cfg.KillSwitch,
+		poll:        connectPoll,
+		up:          make(chan struct{}),
+	}
+}
+
+// SetOnRotate sets fn to be called after each rotation, which changes the
+// exit (e.g. to drop a cached egress check)
+func (m *Manager) SetOnRotate(fn func()) {
+	if m != nil {
+		m.onRotate = fn
+	}
+}
+
+// Region is the region the tunnel is (or is being) connected to
+func (m *Manager) Region() string {
+	if m == nil {
+		return ""
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.regions[m.region]
+}
+
+// EnsureConnected checks the tunnel and, with auto-connect, brings it up on
+// the current region if it is down
+func (m *Manager) EnsureConnected(ctx context.Context) error {
+	if m == nil {
+		return nil
+	}
+	ok, err := m.provider.Connected(ctx)
+	if err == nil && ok {
+		m.markUp()
+		return nil
+	}
+	m.markDown()
+	if err != nil {
+		log.Printf("VPN: %s status check failed: %v", m.provider.Name(), err)
+	}
+	if !m.autoConnect {
+		return ErrVPNNotConnected
+	}
+	return m.connect(ctx, m.Region())
+}
+
+// connect starts connecting to region and waits for the tunnel to come up
+func (m *Manager) connect(ctx context.Context, region string) error {
+	log.Printf("VPN: connecting %s to %s", m.provider.Name(), regionName(region))
+	if err := m.provider.Connect(ctx, region); err != nil {
+		log.Printf("VPN: connect failed: %v", err)
+		return fmt.Errorf("%w: %v", ErrVPNConnectFail, err)
+	}
+
+	deadline := time.Now().Add(connectTimeout)
+	for time.Now().Before(deadline) {
+		if ok, err := m.provider.Connected(ctx); err == nil && ok {
+			m.markUp()
+			log.Printf("VPN: connected to %s", regionName(region))
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(m.poll):
+		}
+	}
+	return ErrVPNConnectFail
+}
+
+// ReportBlock records a bot-protection block seen through the tunnel. After
+// RotateAfterBlocks in a row it rotates to the next region in the background;
+// Wait holds scraping back meanwhile.
+func (m *Manager) ReportBlock() {
+	if m == nil || m.rotateAfter <= 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.blocks++
+	if m.blocks < m.rotateAfter || m.rotating {
+		return
+	}
+	if len(m.regions) < 2 {
+		log.Printf("VPN: %d blocks in a row but only one region configured, not rotating", m.blocks)
+		m.blocks = 0
+		return
+	}
+	m.rotating = true
+	log.Printf("VPN: %d blocks in a row on %s, rotating", m.blocks, regionName(m.regions[m.region]))
+	go func() {
+		if err := m.Rotate(context.Background()); err != nil {
+			log.Printf("VPN: rotation failed: %v", err)
+		}
+	}()
+}
+
+// ReportSuccess resets the block count after a page got through
+func (m *Manager) ReportSuccess() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.blocks = 0
+	m.mu.Unlock()
+}
+
+// Rotate disconnects and reconnects on the next region
+func (m *Manager) Rotate(ctx context.Context) error {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	m.rotating = true
+	from := m.regions[m.region]
+	m.region = (m.region + 1) % len(m.regions)
+	to := m.regions[m.region]
+	m.blocks = 0
+	m.mu.Unlock()
+
+	defer func() {
+		m.mu.Lock()
+		m.rotating = false
+		m.mu.Unlock()
+		if m.onRotate != nil {
+			m.onRotate()
+		}
+	}()
+
+	m.markDown()
+	log.Printf("VPN: rotating from %s to %s", regionName(from), regionName(to))
+	if err := m.provider.Disconnect(ctx); err != nil {
+		log.Printf("VPN: disconnect failed: %v", err)
+	}
+	return m.connect(ctx, to)
+}
+
+// Wait blocks while the kill switch holds scraping back (the tunnel is down
+// or rotating) or until ctx is done
+func (m *Manager) Wait(ctx context.Context) error {
+	if m == nil || !m.killSwitch {
+		return nil
+	}
+	m.mu.Lock()
+	up := m.up
+	m.mu.Unlock()
+
+	select {
+	case <-up:
+		return nil
+	default:
+	}
+	select {
+	case <-up:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Watch checks the tunnel every interval until ctx is done. When it drops,
+// scraping is held back and, with auto-connect, the tunnel is reconnected.
+func (m *Manager) Watch(ctx context.Context, interval time.Duration) {
+	if m == nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		m.mu.Lock()
+		rotating := m.rotating
+		m.mu.Unlock()
+		if rotating {
+			continue
+		}
+
+		ok, err := m.provider.Connected(ctx)
+		if err == nil && ok {
+			m.markUp()
+			continue
+		}
+		if m.markDown() {
+			log.Printf("VPN: tunnel down (%v), pausing scraping", err)
+		}
+		if m.autoConnect {
+			m.connect(ctx, m.Region())
+		}
+	}
+}
+
+// markUp releases Wait
+func (m *Manager) markUp() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	select {
+	case <-m.up:
+	default:
+		close(m.up)
+	}
+}
+
+// markDown makes Wait block again; it reports whether the tunnel was up
+func (m *Manager) markDown() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	select {
+	case <-m.up:
+		m.up = make(chan struct{})
+		return true
+	default:
+		return false
+	}
+}
+
+func regionName(region string) string {
+	if region == "" {
+		return "default location"
+	}
+	return region
+}
@@ -0,0 +1,162 @@
+package vpn
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"tct_scrooper/config"
+)
+
+// fakeExpressVPN answers expressvpnctl like the real client and records the
+// commands it was given
+type fakeExpressVPN struct {
+	mu        sync.Mutex
+	connected bool
+	commands  []string
+}
+
+func (f *fakeExpressVPN) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.commands = append(f.commands, strings.Join(append([]string{name}, args...), " "))
+
+	switch args[0] {
+	case "status":
+		if f.connected {
+			return []byte("Connected to Canada - Toronto\n"), nil
+		}
+		return []byte("Disconnected\n"), nil
+	case "connect":
+		f.connected = true
+	case "disconnect":
+		f.connected = false
+	}
+	return nil, nil
+}
+
+func (f *fakeExpressVPN) setConnected(c bool) {
+	f.mu.Lock()
+	f.connected = c
+	f.mu.Unlock()
+}
+
+func (f *fakeExpressVPN) ran(command string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, c := range f.commands {
+		if c == command {
+			return true
+		}
+	}
+	return false
+}
+
+func newTestManager(run Runner, regions ...string) *Manager {
+	m := NewManager(NewExpressVPN(run), config.VPNConfig{
+		Provider:          "expressvpn",
+		Regions:           regions,
+		AutoConnect:       true,
+		RotateAfterBlocks: 2,
+		KillSwitch:        true,
+	})
+	m.poll = time.Millisecond
+	return m
+}
+
+func TestManagerEnsureConnected(t *testing.T) {
+	fake := &fakeExpressVPN{}
+	m := newTestManager(fake, "canada-toronto", "canada-montreal")
+
+	if err := m.EnsureConnected(context.Background()); err != nil {
+		t.Fatalf("EnsureConnected: %v", err)
+	}
+	if !fake.ran("expressvpnctl connect canada-toronto") {
+		t.Fatalf("expected connect to the first region, ran %v", fake.commands)
+	}
+	if err := m.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait after connecting: %v", err)
+	}
+}
+
+func TestManagerRotatesAfterBlocks(t *testing.T) {
+	fake := &fakeExpressVPN{}
+	m := newTestManager(fake, "canada-toronto", "canada-montreal")
+	rotated := make(chan struct{}, 1)
+	m.SetOnRotate(func() { rotated <- struct{}{} })
+	if err := m.EnsureConnected(context.Background()); err != nil {
+		t.Fatalf("EnsureConnected: %v", err)
+	}
+
+	m.ReportBlock()
+	m.ReportSuccess()
+	m.ReportBlock()
+	if m.Region() != "canada-toronto" {
+		t.Fatalf("rotated after blocks that were not in a row")
+	}
+
+	m.ReportBlock()
+	deadline := time.Now().Add(time.Second)
+	for !fake.ran("expressvpnctl connect canada-montreal") {
+		if time.Now().After(deadline) {
+			t.Fatalf("no rotation after 2 blocks in a row, ran %v", fake.commands)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if !fake.ran("expressvpnctl disconnect") {
+		t.Fatalf("rotation did not disconnect first")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := m.Wait(ctx); err != nil {
+		t.Fatalf("Wait after rotation: %v", err)
+	}
+	select {
+	case <-rotated:
+	case <-ctx.Done():
+		t.Fatalf("expected the rotation hook called")
+	}
+}
+
+func TestManagerKillSwitch(t *testing.T) {
+	fake := &fakeExpressVPN{}
+	m := newTestManager(fake)
+	m.autoConnect = false
+
+	// Never connected: scraping is held back
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := m.Wait(ctx); err == nil {
+		t.Fatalf("Wait passed with the tunnel down")
+	}
+	if err := m.EnsureConnected(context.Background()); err != ErrVPNNotConnected {
+		t.Fatalf("EnsureConnected without auto-connect = %v, want ErrVPNNotConnected", err)
+	}
+
+	// The watcher releases scraping once the tunnel is back
+	watchCtx, stop := context.WithCancel(context.Background())
+	defer stop()
+	go m.Watch(watchCtx, 5*time.Millisecond)
+	fake.setConnected(true)
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := m.Wait(ctx); err != nil {
+		t.Fatalf("Wait after the tunnel came back: %v", err)
+	}
+}
+
+func TestNilManager(t *testing.T) {
+	var m *Manager
+	if err := m.EnsureConnected(context.Background()); err != nil {
+		t.Fatalf("nil EnsureConnected: %v", err)
+	}
+	if err := m.Wait(context.Background()); err != nil {
+		t.Fatalf("nil Wait: %v", err)
+	}
+	m.ReportBlock()
+	m.ReportSuccess()
+}
@@ -0,0 +1,54 @@
+package vpn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"tct_scrooper/config"
+)
+
+var (
+	ErrVPNNotConnected = errors.New("VPN not connected")
+	ErrVPNConnectFail  = errors.New("failed to connect VPN")
+)
+
+// Runner runs a command and returns its combined output. Providers shell out
+// through it so they can be driven by a fake in tests.
+type Runner interface {
+	Run(ctx context.Context, name string, args ...string) ([]byte, error)
+}
+
+// ExecRunner runs real commands
+type ExecRunner struct{}
+
+func (ExecRunner) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err != nil {
+		return out, fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return out, nil
+}
+
+// Provider controls one VPN client. A region is whatever the client connects
+// to: an ExpressVPN location, or a wg-quick config name.
+type Provider interface {
+	Name() string
+	Connected(ctx context.Context) (bool, error)
+	// Connect starts connecting to region; the Manager waits for Connected
+	Connect(ctx context.Context, region string) error
+	Disconnect(ctx context.Context) error
+}
+
+// NewProvider returns the provider named by cfg.Provider
+func NewProvider(cfg config.VPNConfig, run Runner) (Provider, error) {
+	switch cfg.Provider {
+	case "expressvpn":
+		return NewExpressVPN(run), nil
+	case "wireguard":
+		return NewWireGuard(run), nil
+	}
+	return nil, fmt.Errorf("unknown VPN provider %q", cfg.Provider)
+}
@@ -0,0 +1,57 @@
+package vpn
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// WireGuard drives wg-quick; a region is a config name in /etc/wireguard
+// (e.g. ca-tor for /etc/wireguard/ca-tor.conf) and names the interface
+type WireGuard struct {
+	run Runner
+}
+
+func NewWireGuard(run Runner) *WireGuard {
+	return &WireGuard{run: run}
+}
+
+func (v *WireGuard) Name() string {
+	return "wireguard"
+}
+
+func (v *WireGuard) Connected(ctx context.Context) (bool, error) {
+	ifaces, err := v.interfaces(ctx)
+	return len(ifaces) > 0, err
+}
+
+func (v *WireGuard) Connect(ctx context.Context, region string) error {
+	if region == "" {
+		return fmt.Errorf("wireguard: no config name to connect to (set VPN_REGIONS)")
+	}
+	_, err := v.run.Run(ctx, "wg-quick", "up", region)
+	return err
+}
+
+// Disconnect brings down every WireGuard interface, including any left up by
+// an earlier daemon
+func (v *WireGuard) Disconnect(ctx context.Context) error {
+	ifaces, err := v.interfaces(ctx)
+	if err != nil {
+		return err
+	}
+	for _, iface := range ifaces {
+		if _, err := v.run.Run(ctx, "wg-quick", "down", iface); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *WireGuard) interfaces(ctx context.Context) ([]string, error) {
+	out, err := v.run.Run(ctx, "wg", "show", "interfaces")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(out)), nil
+}
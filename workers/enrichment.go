@@ -3,6 +3,7 @@ package workers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -19,31 +20,62 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/playwright-community/playwright-go"
+	"tct_scrooper/browser"
+	"tct_scrooper/challenge"
+	"tct_scrooper/httputil"
 	"tct_scrooper/models"
 	"tct_scrooper/services"
 	"tct_scrooper/storage"
+	"tct_scrooper/vpn"
 )
 
 type EnrichmentWorker struct {
 	store           *storage.PostgresStore
 	mediaService    *services.MediaService
-	proxyURL        string
+	proxies         *httputil.ProxyPool
 	scrapingBeeKey  string
 	httpClient      *http.Client
+	limiter         *httputil.HostLimiter
+	vpn             *vpn.Manager
+	challenges      *challenge.Tracker
 	triggerCh       chan struct{}
 	logFunc         LogFunc
 
-	mu          sync.Mutex
-	pw          *playwright.Playwright
-	context     playwright.BrowserContext
-	initialized bool
+	mu    sync.Mutex
+	pool  *browser.Pool
+	lease *browser.Lease  // the realtor.ca browser profile, held for a batch
+	proxy *httputil.Proxy // the browser's proxy, held for the session
 }
 
+// enrichmentProxySession is the sticky proxy key for the browser session:
+// Incapsula ties its cookies to the IP, so the session keeps one proxy
+const enrichmentProxySession = "realtor_ca"
+
+// enrichmentProfile is the browser profile enrichment uses: the realtor_ca
+// scraper's, leased in turn so both share its cookies
+const enrichmentProfile = "realtor_ca"
+
+// listingContentMarkers are on listing pages that loaded
+var listingContentMarkers = []string{"listingPhotosCon", "propertyDescriptionCon"}
+
+
 func (w *EnrichmentWorker) SetLogger(fn LogFunc) {
 	w.logFunc = fn
 }
 
-func NewEnrichmentWorker(store *storage.PostgresStore, mediaService *services.MediaService, proxyURL string) *EnrichmentWorker {
+// SetVPN makes the worker report bot-protection blocks of its unproxied
+// browser to the VPN manager, whose tunnel they went through
+func (w *EnrichmentWorker) SetVPN(m *vpn.Manager) {
+	w.vpn = m
+}
+
+// SetChallengeTracker makes the worker count the blocks it hits per host and
+// proxy
+func (w *EnrichmentWorker) SetChallengeTracker(t *challenge.Tracker) {
+	w.challenges = t
+}
+
+func NewEnrichmentWorker(store *storage.PostgresStore, mediaService *services.MediaService, proxies *httputil.ProxyPool, limiter *httputil.HostLimiter, pool *browser.Pool) *EnrichmentWorker {
 	scrapingBeeKey := os.Getenv("SCRAPINGBEE_API_KEY")
 	if scrapingBeeKey != "" {
 		log.Printf("Enrichment: ScrapingBee API key loaded (%d chars)", len(scrapingBeeKey))
@@ -54,10 +86,13 @@ func NewEnrichmentWorker(store *storage.PostgresStore, mediaService *services.Me
 	return &EnrichmentWorker{
 		store:          store,
 		mediaService:   mediaService,
-		proxyURL:       proxyURL,
+		proxies:        proxies,
+		pool:           pool,
 		scrapingBeeKey: scrapingBeeKey,
+		limiter:        limiter,
 		triggerCh:      make(chan struct{}, 1),
 		logFunc:        NoOpLogger,
+		// ScrapingBee only; not retried, see HealthcheckWorker.checkWithScrapingBee
 		httpClient: &http.Client{
 			Timeout: 60 * time.Second,
 			Transport: &http.Transport{
@@ -77,83 +112,60 @@ func (w *EnrichmentWorker) Trigger() {
 	}
 }
 
-func (w *EnrichmentWorker) ensureBrowser() error {
+// ensureBrowser leases the browser profile unless the scraper holds it
+// (browser.ErrProfileBusy), on the session's sticky proxy, and warms up a
+// fresh session
+func (w *EnrichmentWorker) ensureBrowser(ctx context.Context) error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	if w.initialized {
-		return nil
-	}
-
-	var err error
-	w.pw, err = playwright.Run()
-	if err != nil {
-		return fmt.Errorf("failed to start playwright: %w", err)
-	}
-
-	// Use same browser_data as browser_handler for shared cookies/session
-	cwd, _ := os.Getwd()
-	userDataDir := filepath.Join(cwd, "browser_data")
-
-	launchOpts := playwright.BrowserTypeLaunchPersistentContextOptions{
-		Headless: playwright.Bool(false), // Headed mode to bypass Incapsula (use with xvfb)
-		Args: []string{
-			"--disable-blink-features=AutomationControlled",
-			"--disable-dev-shm-usage",
-			"--no-sandbox",
-			"--disable-gpu",
-		},
-		UserAgent: playwright.String("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
-	}
-
-	if w.proxyURL != "" {
-		proxyParsed, err := parseProxyURL(w.proxyURL)
+	if w.lease == nil {
+		lease, err := w.pool.TryAcquire(enrichmentProfile, "enrichment")
 		if err != nil {
-			log.Printf("Warning: failed to parse proxy URL: %v", err)
-		} else {
-			launchOpts.Proxy = proxyParsed
-			log.Printf("Enrichment browser using proxy: %s", proxyParsed.Server)
+			return err
 		}
+		w.lease = lease
 	}
 
-	w.context, err = w.pw.Chromium.LaunchPersistentContext(userDataDir, launchOpts)
-	if err != nil {
-		w.pw.Stop()
-		return fmt.Errorf("failed to launch browser: %w", err)
+	var opts browser.Options
+	if w.proxy == nil {
+		w.proxy = w.proxies.Sticky(enrichmentProxySession)
+		if w.proxy != nil {
+			log.Printf("Enrichment browser using proxy: %s", w.proxy.Label)
+		}
 	}
-
-	w.initialized = true
-	log.Println("Enrichment browser initialized (persistent context)")
+	if w.proxy != nil {
+		opts.Proxy = playwrightProxy(w.proxy.URL)
+	}
+	w.lease.Configure(opts)
 
 	// Warmup to get cookies/session
-	if err := w.warmup(); err != nil {
-		log.Printf("Enrichment warmup failed: %v", err)
+	if w.lease.Fresh() {
+		if err := w.warmup(ctx); err != nil {
+			log.Printf("Enrichment warmup failed: %v", err)
+		}
 	}
-
 	return nil
 }
 
-func (w *EnrichmentWorker) closeBrowser() {
+// releaseBrowser hands the browser profile back, so the scraper can have it
+// between batches
+func (w *EnrichmentWorker) releaseBrowser() {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	if w.context != nil {
-		w.context.Close()
-	}
-	if w.pw != nil {
-		w.pw.Stop()
-	}
-	w.initialized = false
+	w.lease.Release()
+	w.lease = nil
 }
 
-func (w *EnrichmentWorker) warmup() error {
+func (w *EnrichmentWorker) warmup(ctx context.Context) error {
 	log.Println("Enrichment: warming up browser session...")
 
-	page, err := w.context.NewPage()
+	page, err := w.lease.NewPage(ctx)
 	if err != nil {
 		return err
 	}
-	defer page.Close()
+	defer w.lease.ClosePage(page)
 
 	// First visit homepage to establish initial cookies
 	log.Println("Enrichment warmup: visiting homepage first")
@@ -168,15 +180,14 @@ func (w *EnrichmentWorker) warmup() error {
 	page.WaitForTimeout(3000)
 	w.simulateHuman(page)
 
-	// Handle any Incapsula on homepage
+	// Handle any challenge on homepage
 	for i := 0; i < 5; i++ {
-		content, _ := page.Content()
-		if !strings.Contains(content, "Incapsula") {
+		if challenge.CheckPage(page) == nil {
 			log.Println("Enrichment warmup: homepage loaded")
 			break
 		}
-		log.Println("Enrichment warmup: handling Incapsula on homepage...")
-		w.handleIncapsula(page)
+		log.Println("Enrichment warmup: handling challenge on homepage...")
+		challenge.Solve(page)
 		page.WaitForTimeout(3000)
 	}
 
@@ -220,16 +231,17 @@ func (w *EnrichmentWorker) warmup() error {
 		}
 	}
 
-	// Check for Incapsula and handle - wait longer
+	// Check for a challenge and handle - wait longer
+	searchMarkers := []string{"listingCard", "ResultsPaginationCon"}
 	for i := 0; i < 10; i++ {
 		content, _ := page.Content()
 		if strings.Contains(content, "listingCard") || strings.Contains(content, "ResultsPaginationCon") {
 			log.Println("Enrichment warmup: search results loaded successfully")
 			break
 		}
-		if strings.Contains(content, "Incapsula") {
-			log.Println("Enrichment warmup: handling Incapsula challenge...")
-			w.handleIncapsula(page)
+		if challenge.CheckPage(page, searchMarkers...) != nil {
+			log.Println("Enrichment warmup: handling challenge...")
+			challenge.Solve(page, searchMarkers...)
 		}
 		page.WaitForTimeout(2000)
 	}
@@ -241,11 +253,10 @@ func (w *EnrichmentWorker) warmup() error {
 		listingLink.Click()
 		page.WaitForTimeout(5000)
 
-		// Check for Incapsula on detail page
-		content, _ := page.Content()
-		if strings.Contains(content, "Incapsula") {
-			log.Println("Enrichment warmup: handling Incapsula on detail page...")
-			w.handleIncapsula(page)
+		// Check for a challenge on detail page
+		if challenge.CheckPage(page, listingContentMarkers...) != nil {
+			log.Println("Enrichment warmup: handling challenge on detail page...")
+			challenge.Solve(page, listingContentMarkers...)
 			page.WaitForTimeout(3000)
 		}
 	}
@@ -283,6 +294,11 @@ type Room struct {
 }
 
 func (w *EnrichmentWorker) Enrich(ctx context.Context, listingURL string) (*EnrichedData, error) {
+	// Both paths load the listing page from its host, directly or through ScrapingBee
+	if err := w.limiter.WaitURL(ctx, listingURL); err != nil {
+		return nil, err
+	}
+
 	// Try ScrapingBee first if available (faster, cheaper - 1 credit per request)
 	if w.scrapingBeeKey != "" {
 		data, err := w.enrichWithScrapingBee(ctx, listingURL)
@@ -329,9 +345,15 @@ func (w *EnrichmentWorker) enrichWithScrapingBee(ctx context.Context, listingURL
 
 	html := string(body)
 
-	// Check for Incapsula block (shouldn't happen with ScrapingBee but just in case)
-	if strings.Contains(html, "Request unsuccessful") && !strings.Contains(html, "listingPhotosCon") {
-		return nil, fmt.Errorf("blocked by Incapsula")
+	// Check for a block (shouldn't happen with ScrapingBee but just in case)
+	if err := challenge.Check(challenge.Page{
+		Host:   listingHost(listingURL),
+		Status: resp.StatusCode,
+		Body:   html,
+		Expect: listingContentMarkers,
+	}); err != nil {
+		w.challenges.RecordErr(err, "scrapingbee")
+		return nil, err
 	}
 
 	// Check we got actual listing content
@@ -344,26 +366,27 @@ func (w *EnrichmentWorker) enrichWithScrapingBee(ctx context.Context, listingURL
 }
 
 func (w *EnrichmentWorker) enrichWithPlaywright(ctx context.Context, listingURL string) (*EnrichedData, error) {
-	if err := w.ensureBrowser(); err != nil {
+	if err := w.ensureBrowser(ctx); err != nil {
 		return nil, err
 	}
 
-	page, err := w.context.NewPage()
+	page, err := w.lease.NewPage(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("create page: %w", err)
 	}
-	defer page.Close()
+	defer w.lease.ClosePage(page)
 
-	page.SetViewportSize(1920, 1080)
-
-	log.Printf("Enrichment (Playwright): navigating to %s", listingURL)
+	log.Printf("Enrichment (Playwright): navigating to %s as %s", listingURL, w.lease.Fingerprint())
+	start := time.Now()
 	_, err = page.Goto(listingURL, playwright.PageGotoOptions{
 		Timeout:   playwright.Float(60000),
 		WaitUntil: playwright.WaitUntilStateDomcontentloaded,
 	})
 	if err != nil {
+		w.proxies.Report(w.proxy, httputil.ClassRetryable, 0)
 		return nil, fmt.Errorf("navigate: %w", err)
 	}
+	latency := time.Since(start)
 
 	page.WaitForTimeout(3000)
 	w.simulateHuman(page)
@@ -376,9 +399,9 @@ func (w *EnrichmentWorker) enrichWithPlaywright(ctx context.Context, listingURL
 			break
 		}
 
-		if strings.Contains(content, "Incapsula") || strings.Contains(content, "Request unsuccessful") {
-			log.Printf("Enrichment (Playwright): Incapsula detected (attempt %d), handling...", attempt+1)
-			w.handleIncapsula(page)
+		if err := challenge.CheckPage(page, listingContentMarkers...); err != nil {
+			log.Printf("Enrichment (Playwright): %v (attempt %d), handling...", err, attempt+1)
+			challenge.Solve(page, listingContentMarkers...)
 			page.WaitForTimeout(5000)
 			w.simulateHuman(page)
 		} else {
@@ -386,9 +409,27 @@ func (w *EnrichmentWorker) enrichWithPlaywright(ctx context.Context, listingURL
 		}
 	}
 
+	if err := challenge.CheckPage(page, listingContentMarkers...); err != nil {
+		if b, ok := challenge.AsBlock(err); ok {
+			b.Fingerprint = w.lease.Fingerprint()
+		}
+		w.challenges.RecordErr(err, w.proxyLabel())
+		// Rotate: the next listing relaunches the browser on another proxy
+		w.proxies.Report(w.proxy, httputil.ClassBlocked, 0)
+		if w.proxy != nil {
+			w.mu.Lock()
+			w.proxy = nil
+			w.mu.Unlock()
+		} else {
+			w.vpn.ReportBlock()
+		}
+		w.lease.ReportBlock()
+		return nil, fmt.Errorf("after retries: %w", err)
+	}
 	content, _ := page.Content()
-	if strings.Contains(content, "Incapsula") {
-		return nil, fmt.Errorf("blocked by Incapsula after retries")
+	w.proxies.Report(w.proxy, httputil.ClassOK, latency)
+	if w.proxy == nil {
+		w.vpn.ReportSuccess()
 	}
 
 	os.WriteFile("/tmp/enrich_debug.html", []byte(content), 0644)
@@ -625,45 +666,20 @@ func (w *EnrichmentWorker) extractRooms(page playwright.Page) []Room {
 	return rooms
 }
 
-func (w *EnrichmentWorker) handleIncapsula(page playwright.Page) {
-	// Wait for challenge to load
-	page.WaitForTimeout(2000)
-
-	// Try clicking various elements
-	clickSelectors := []string{
-		"iframe#main-iframe",
-		"[id*='checkbox']",
-		"input[type='checkbox']",
-		"button:has-text('Verify')",
-		"button:has-text('Continue')",
-	}
-
-	for _, sel := range clickSelectors {
-		el := page.Locator(sel).First()
-		if visible, _ := el.IsVisible(); visible {
-			log.Printf("Enrichment: clicking %s", sel)
-			el.Click()
-			page.WaitForTimeout(3000)
-			break
-		}
+// proxyLabel names the browser's proxy for block tracking; empty when direct
+func (w *EnrichmentWorker) proxyLabel() string {
+	if w.proxy == nil {
+		return ""
 	}
+	return w.proxy.Label
+}
 
-	// Check iframes
-	for _, frame := range page.Frames() {
-		if frame == page.MainFrame() {
-			continue
-		}
-
-		iframeSelectors := []string{"[id*='checkbox']", "input[type='checkbox']", "button"}
-		for _, sel := range iframeSelectors {
-			el := frame.Locator(sel).First()
-			if visible, _ := el.IsVisible(); visible {
-				el.Click()
-				page.WaitForTimeout(3000)
-				return
-			}
-		}
+func listingHost(listingURL string) string {
+	u, err := url.Parse(listingURL)
+	if err != nil {
+		return ""
 	}
+	return u.Hostname()
 }
 
 func (w *EnrichmentWorker) simulateHuman(page playwright.Page) {
@@ -753,7 +769,7 @@ func (w *EnrichmentWorker) UpdateListing(ctx context.Context, listingID uuid.UUI
 func (w *EnrichmentWorker) Run(ctx context.Context, batchSize int, interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
-	defer w.closeBrowser()
+	defer w.releaseBrowser()
 
 	for {
 		select {
@@ -770,6 +786,8 @@ func (w *EnrichmentWorker) Run(ctx context.Context, batchSize int, interval time
 }
 
 func (w *EnrichmentWorker) processBatch(ctx context.Context, batchSize int) {
+	// Missing listings wait for their healthcheck to say whether they are
+	// still up before their pages are fetched
 	query := `
 		SELECT id, url, enrichment_attempts
 		FROM listings
@@ -803,6 +821,16 @@ func (w *EnrichmentWorker) processBatch(ctx context.Context, batchSize int) {
 		return
 	}
 
+	// Playwright: hold the browser profile for the batch; while the scraper
+	// has it, the batch waits for the next tick
+	if w.scrapingBeeKey == "" {
+		defer w.releaseBrowser()
+		if err := w.ensureBrowser(ctx); errors.Is(err, browser.ErrProfileBusy) {
+			log.Println("Enrichment: browser profile in use by the scraper, skipping batch")
+			return
+		}
+	}
+
 	log.Printf("Enrichment: processing %d listings", len(listings))
 
 	var enriched, blocked, failed int
@@ -813,7 +841,7 @@ func (w *EnrichmentWorker) processBatch(ctx context.Context, batchSize int) {
 
 			w.store.Pool().Exec(ctx, `UPDATE listings SET enrichment_attempts = enrichment_attempts + 1, updated_at = NOW() WHERE id = $1`, l.ID)
 
-			if strings.Contains(err.Error(), "Incapsula") || strings.Contains(err.Error(), "blocked") {
+			if errors.Is(err, challenge.ErrBlocked) {
 				blocked++
 			} else {
 				failed++
@@ -834,9 +862,6 @@ func (w *EnrichmentWorker) processBatch(ctx context.Context, batchSize int) {
 
 		enriched++
 		log.Printf("Enrichment: enriched %s (%d photos, %d rooms)", l.ID, len(data.Photos), len(data.Rooms))
-
-		// Longer delay between requests to avoid detection
-		time.Sleep(time.Duration(2000+rand.Intn(3000)) * time.Millisecond)
 	}
 
 	// Log batch summary
@@ -862,13 +887,8 @@ func (w *EnrichmentWorker) getListingLocation(ctx context.Context, listingID uui
 	return
 }
 
-// parseProxyURL parses a proxy URL with embedded credentials into Playwright format
-func parseProxyURL(proxyURL string) (*playwright.Proxy, error) {
-	u, err := url.Parse(proxyURL)
-	if err != nil {
-		return nil, err
-	}
-
+// playwrightProxy converts a proxy URL with embedded credentials into Playwright format
+func playwrightProxy(u *url.URL) *playwright.Proxy {
 	proxy := &playwright.Proxy{
 		Server: fmt.Sprintf("%s://%s", u.Scheme, u.Host),
 	}
@@ -881,7 +901,7 @@ func parseProxyURL(proxyURL string) (*playwright.Proxy, error) {
 		}
 	}
 
-	return proxy, nil
+	return proxy
 }
 
 // Keep these for compatibility but they're unused now
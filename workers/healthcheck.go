@@ -14,6 +14,7 @@ import (
 	"strings"
 	"time"
 
+	"tct_scrooper/httputil"
 	"tct_scrooper/models"
 	"tct_scrooper/storage"
 )
@@ -22,8 +23,8 @@ import (
 type HealthcheckWorker struct {
 	store          *storage.PostgresStore
 	httpClient     *http.Client
-	proxyURL       string
 	scrapingBeeKey string
+	limiter        *httputil.HostLimiter
 	triggerCh      chan struct{}
 	logFunc        LogFunc
 }
@@ -33,19 +34,12 @@ func (w *HealthcheckWorker) SetLogger(fn LogFunc) {
 }
 
 // NewHealthcheckWorker creates a new healthcheck worker
-func NewHealthcheckWorker(store *storage.PostgresStore, proxyURL string) *HealthcheckWorker {
+func NewHealthcheckWorker(store *storage.PostgresStore, proxies *httputil.ProxyPool, limiter *httputil.HostLimiter, fingerprints *httputil.Fingerprints) *HealthcheckWorker {
 	transport := http.DefaultTransport.(*http.Transport).Clone()
-
-	if proxyURL != "" {
-		if proxyParsed, err := url.Parse(proxyURL); err == nil {
-			transport.Proxy = http.ProxyURL(proxyParsed)
-			log.Printf("Healthcheck worker using proxy: %s", proxyParsed.Host)
-		}
-	}
+	log.Printf("Healthcheck worker using %d proxies", proxies.Len())
 
 	client := &http.Client{
-		Timeout:   30 * time.Second,
-		Transport: transport,
+		Transport: fingerprints.Transport("healthcheck", httputil.ScrapingRetry.Transport(limiter.Transport(proxies.Transport(transport)))),
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse // Don't follow redirects
 		},
@@ -61,8 +55,8 @@ func NewHealthcheckWorker(store *storage.PostgresStore, proxyURL string) *Health
 	return &HealthcheckWorker{
 		store:          store,
 		httpClient:     client,
-		proxyURL:       proxyURL,
 		scrapingBeeKey: sbKey,
+		limiter:        limiter,
 		triggerCh:      make(chan struct{}, 1),
 		logFunc:        NoOpLogger,
 	}
@@ -112,8 +106,6 @@ func (w *HealthcheckWorker) checkWithHEAD(ctx context.Context, listingURL string
 		return CheckResult{Error: err}
 	}
 
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
-
 	resp, err := w.httpClient.Do(req)
 	if err != nil {
 		return CheckResult{Error: err}
@@ -152,11 +144,18 @@ func (w *HealthcheckWorker) checkWithScrapingBee(ctx context.Context, listingURL
 
 	apiURL := "https://app.scrapingbee.com/api/v1/?" + params.Encode()
 
+	// ScrapingBee fetches the listing for us; it still counts against its host
+	if err := w.limiter.WaitURL(ctx, listingURL); err != nil {
+		return CheckResult{Error: err}
+	}
+
 	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
 	if err != nil {
 		return CheckResult{Error: err}
 	}
 
+	// No retry policy: ScrapingBee reports blocked pages as 500, which another
+	// (billed) attempt won't fix
 	client := &http.Client{Timeout: 30 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
@@ -199,7 +198,6 @@ func (w *HealthcheckWorker) checkWithProxy(ctx context.Context, listingURL strin
 		return CheckResult{Error: err}
 	}
 
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
 	req.Header.Set("Accept", "text/html,application/xhtml+xml")
 
 	resp, err := w.httpClient.Do(req)
@@ -336,7 +334,13 @@ func (w *HealthcheckWorker) Run(ctx context.Context, staleDuration time.Duration
 }
 
 func (w *HealthcheckWorker) processBatch(ctx context.Context, staleDuration time.Duration, batchSize int) {
-	listings, err := w.store.GetStaleActiveListings(ctx, staleDuration, batchSize)
+	// Listings a full region scrape stopped returning go first
+	missing := w.processMissing(ctx, batchSize)
+	if missing >= batchSize {
+		return
+	}
+
+	listings, err := w.store.GetStaleActiveListings(ctx, staleDuration, batchSize-missing)
 	if err != nil {
 		log.Printf("Healthcheck: query error: %v", err)
 		return
@@ -384,9 +388,6 @@ func (w *HealthcheckWorker) processBatch(ctx context.Context, staleDuration time
 			}
 			w.touchListing(ctx, &listing)
 		}
-
-		// Rate limit between requests
-		time.Sleep(500 * time.Millisecond)
 	}
 
 	if delisted > 0 || priceChanges > 0 {
@@ -402,6 +403,57 @@ func (w *HealthcheckWorker) processBatch(ctx context.Context, staleDuration time
 	}
 }
 
+// processMissing checks listings marked missing by disappearance detection:
+// confirmed gone they are delisted, still live they are restored to active.
+// Inconclusive ones stay missing until their grace period ends. Returns how
+// many listings it took from the batch.
+func (w *HealthcheckWorker) processMissing(ctx context.Context, limit int) int {
+	listings, err := w.store.GetMissingListingsToCheck(ctx, limit)
+	if err != nil {
+		log.Printf("Healthcheck: missing listings query error: %v", err)
+		return 0
+	}
+	if len(listings) == 0 {
+		return 0
+	}
+
+	log.Printf("Healthcheck: checking %d missing listings", len(listings))
+
+	var delisted, restored int
+	for _, listing := range listings {
+		if listing.URL != "" {
+			result := w.Check(ctx, listing.URL)
+			switch {
+			case result.Error != nil:
+				log.Printf("Healthcheck: error checking missing %s: %v", listing.URL, result.Error)
+			case !result.IsLive:
+				log.Printf("Healthcheck: missing listing confirmed delisted (status %d): %s", result.StatusCode, listing.URL)
+				if err := w.markDelisted(ctx, &listing); err != nil {
+					log.Printf("Healthcheck: failed to mark delisted: %v", err)
+				} else {
+					delisted++
+				}
+			default:
+				if err := w.store.RestoreMissingListing(ctx, listing.ID); err != nil {
+					log.Printf("Healthcheck: failed to restore %s: %v", listing.URL, err)
+				} else {
+					restored++
+				}
+			}
+		}
+		if err := w.store.MarkMissingChecked(ctx, listing.ID); err != nil {
+			log.Printf("Healthcheck: failed to mark %s checked: %v", listing.ID, err)
+		}
+	}
+
+	if delisted > 0 || restored > 0 {
+		msg := fmt.Sprintf("Checked %d missing listings, %d delisted, %d still live", len(listings), delisted, restored)
+		log.Printf("Healthcheck: %s", msg)
+		w.logFunc(models.LogLevelInfo, "healthcheck", msg)
+	}
+	return len(listings)
+}
+
 func (w *HealthcheckWorker) touchListing(ctx context.Context, listing *models.Listing) {
 	now := time.Now()
 	query := `UPDATE listings SET last_seen = $2, updated_at = $2 WHERE id = $1`
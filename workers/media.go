@@ -9,12 +9,12 @@ import (
 	"io"
 	"log"
 	"net/http"
-	"net/url"
 	"path"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"tct_scrooper/httputil"
 	"tct_scrooper/models"
 	"tct_scrooper/storage"
 )
@@ -24,7 +24,6 @@ type MediaWorker struct {
 	store      *storage.PostgresStore
 	httpClient *http.Client
 	uploader   S3Uploader
-	proxyURL   string
 	triggerCh  chan struct{}
 	logFunc    LogFunc
 }
@@ -39,26 +38,18 @@ type S3Uploader interface {
 }
 
 // NewMediaWorker creates a new media worker
-func NewMediaWorker(store *storage.PostgresStore, uploader S3Uploader, proxyURL string) *MediaWorker {
+func NewMediaWorker(store *storage.PostgresStore, uploader S3Uploader, proxies *httputil.ProxyPool, limiter *httputil.HostLimiter, fingerprints *httputil.Fingerprints) *MediaWorker {
 	transport := http.DefaultTransport.(*http.Transport).Clone()
-
-	if proxyURL != "" {
-		if proxyParsed, err := url.Parse(proxyURL); err == nil {
-			transport.Proxy = http.ProxyURL(proxyParsed)
-			log.Printf("Media worker using proxy: %s", proxyParsed.Host)
-		}
-	}
+	log.Printf("Media worker using %d proxies", proxies.Len())
 
 	client := &http.Client{
-		Timeout:   60 * time.Second,
-		Transport: transport,
+		Transport: fingerprints.Transport("media", httputil.MediaRetry.Transport(limiter.Transport(proxies.Transport(transport)))),
 	}
 
 	return &MediaWorker{
 		store:      store,
 		httpClient: client,
 		uploader:   uploader,
-		proxyURL:   proxyURL,
 		triggerCh:  make(chan struct{}, 1),
 		logFunc:    NoOpLogger,
 	}
@@ -94,7 +85,6 @@ func (w *MediaWorker) Process(ctx context.Context, media *models.Media) MediaPro
 		return result
 	}
 
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
 	req.Header.Set("Accept", "image/*,*/*")
 
 	resp, err := w.httpClient.Do(req)
@@ -104,7 +94,7 @@ func (w *MediaWorker) Process(ctx context.Context, media *models.Media) MediaPro
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == 404 || resp.StatusCode == 410 {
+	if httputil.ClassifyStatus(resp.StatusCode) == httputil.ClassGone {
 		result.IsGone = true
 		result.Error = fmt.Errorf("source gone: %d", resp.StatusCode)
 		return result
@@ -330,9 +320,6 @@ func (w *MediaWorker) processBatch(ctx context.Context, batchSize int) {
 
 		processed++
 		log.Printf("Media worker: uploaded %s -> %s (%d bytes)", m.ID, result.S3Key, result.Size)
-
-		// Rate limit between downloads
-		time.Sleep(200 * time.Millisecond)
 	}
 
 	if processed > 0 || failed > 0 {